@@ -144,35 +144,85 @@ func main() {
 	likeRepo := postgres.NewLikeRepository(db)
 	commentRepo := postgres.NewCommentRepository(db)
 	viewRepo := postgres.NewPostViewRepository(db)
+	refreshTokenRepo := postgres.NewRefreshTokenRepository(db)
+	followRepo := postgres.NewFollowRepository(db)
+	outboxRepo := postgres.NewOutboxRepository(db)
+	webhookRepo := postgres.NewWebhookRepository(db)
+	notificationRepo := postgres.NewNotificationRepository(db)
+	mentionRepo := postgres.NewMentionRepository(db)
+	saveRepo := postgres.NewSaveRepository(db)
 
 	// Initialize event publisher first
-	eventPublisher := events.NewPublisher(redisCache, appLogger.Logger)
+	eventBus := events.NewStreamBus(redisCache, cfg.EventStreamMaxLen, appLogger.Logger)
+	eventPublisher := events.NewPublisher(eventBus, appLogger.Logger)
+
+	// One event bus subscription per process, fanned out to every WebSocket/SSE client
+	eventHub := events.NewHub(eventBus, cfg.EventClientBuffer, appLogger.Logger)
+	go eventHub.Run(context.Background())
+
+	// Publish events recorded in the outbox; like, comment, follow and notification events go through it
+	outboxRelay := service.NewOutboxRelay(outboxRepo, eventPublisher, cfg.OutboxBatchSize, cfg.OutboxMaxAttempts, cfg.OutboxPollInterval, cfg.OutboxRetention, appLogger.Logger)
+	go outboxRelay.Run(context.Background())
+
+	// Queue events for webhooks and deliver them with retries
+	webhookSender := service.NewWebhookSender(cfg.WebhookTimeout, cfg.WebhookAllowPrivate)
+	webhookWorker := service.NewWebhookWorker(webhookRepo, eventBus, redisCache, webhookSender, cfg.WebhookMaxAttempts, cfg.WebhookPollInterval, appLogger.Logger)
+	go webhookWorker.Run(context.Background())
 
 	// Initialize services
-	authService := service.NewAuthService(userRepo, cfg.JWTSecret)
-	postService := service.NewPostService(postRepo, mediaStorage, redisCache, cfg.CacheTTL)
-	feedService := service.NewFeedService(postRepo, redisCache, cfg.CacheTTL)
-	interactionService := service.NewInteractionService(likeRepo, commentRepo, postRepo, redisCache, eventPublisher, appLogger.Logger)
+	authService := service.NewAuthService(userRepo, refreshTokenRepo, redisCache, cfg.JWTSecret, cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
+	timelineService := service.NewTimelineService(followRepo, userRepo, postRepo, redisCache, cfg.TimelineCelebrityThreshold, cfg.TimelineMaxLength, appLogger.Logger)
+	hashtagService := service.NewHashtagService(redisCache, appLogger.Logger)
+	mentionService := service.NewMentionService(userRepo, mentionRepo, appLogger.Logger)
+	postService := service.NewPostService(postRepo, mediaStorage, redisCache, cfg.CacheTTL, timelineService, hashtagService, mentionService)
+	rankingWeights := service.RankingWeights{
+		LikeWeight:    cfg.RankLikeWeight,
+		CommentWeight: cfg.RankCommentWeight,
+		ViewWeight:    cfg.RankViewWeight,
+		SaveWeight:    cfg.RankSaveWeight,
+		DecayRate:     cfg.RankDecayRate,
+		Gravity:       cfg.RankGravity,
+		WilsonZ:       cfg.RankWilsonZ,
+
+		ReactionWeights: cfg.RankReactionWeights,
+	}
+	feedRanker, err := service.NewRanker(cfg.FeedRanker, rankingWeights)
+	if err != nil {
+		appLogger.Fatal("invalid feed ranker", zap.String("ranker", cfg.FeedRanker), zap.Error(err))
+	}
+	feedService := service.NewFeedService(postRepo, redisCache, cfg.CacheTTL, cfg.FeedRankWindow, cfg.FeedRankSessionTTL, feedRanker, rankingWeights)
+	notificationService := service.NewNotificationService(notificationRepo, appLogger.Logger)
+	interactionService := service.NewInteractionService(likeRepo, commentRepo, postRepo, redisCache, notificationService, mentionService, appLogger.Logger)
 	viewService := service.NewPostViewService(viewRepo)
+	followService := service.NewFollowService(followRepo, userRepo, timelineService, appLogger.Logger)
+	userService := service.NewUserService(userRepo, appLogger.Logger)
+	webhookService := service.NewWebhookService(webhookRepo, webhookSender, appLogger.Logger)
+	saveService := service.NewSaveService(saveRepo, postRepo, appLogger.Logger)
 
 	// Initialize handlers
 	authHandler := handler.NewAuthHandler(authService)
-	postHandler := handler.NewPostHandler(postService, eventPublisher, appLogger.Logger)
-	feedHandler := handler.NewFeedHandler(feedService, cfg)
-	interactionHandler := handler.NewInteractionHandler(interactionService, eventPublisher, appLogger.Logger)
+	postHandler := handler.NewPostHandler(postService, interactionService, eventPublisher, appLogger.Logger)
+	feedHandler := handler.NewFeedHandler(feedService, timelineService, interactionService, cfg)
+	interactionHandler := handler.NewInteractionHandler(interactionService, cfg, appLogger.Logger)
 	viewHandler := handler.NewPostViewHandler(viewService)
+	userHandler := handler.NewUserHandler(userService, followService, cfg, appLogger.Logger)
+	webhookHandler := handler.NewWebhookHandler(webhookService, cfg, appLogger.Logger)
+	notificationHandler := handler.NewNotificationHandler(notificationService, cfg, appLogger.Logger)
+	hashtagHandler := handler.NewHashtagHandler(hashtagService, appLogger.Logger)
+	saveHandler := handler.NewSaveHandler(saveService, interactionService, cfg, appLogger.Logger)
 	testImageHandler := handler.NewTestImageHandler()
-	wsHandler := handler.NewWSHandler(redisCache, appLogger.Logger, cfg.JWTSecret)
+	wsHandler := handler.NewWSHandler(eventHub, authService, appLogger.Logger)
+	sseHandler := handler.NewSSEHandler(eventHub, authService, appLogger.Logger)
 
 	app := fiber.New()
 
 	// Add CORS middleware
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     "*",
-		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders:     "Origin,Content-Type,Accept,Authorization",
+		AllowMethods:     "GET,POST,PUT,PATCH,DELETE,OPTIONS",
+		AllowHeaders:     "Origin,Content-Type,Accept,Authorization,Last-Event-ID,If-Match",
 		AllowCredentials: false,
-		ExposeHeaders:    "Content-Type",
+		ExposeHeaders:    "Content-Type,ETag",
 	}))
 
 	// Add request logging middleware
@@ -183,7 +233,7 @@ func main() {
 	// @Description  Check API and dependencies health
 	// @Tags         system
 	// @Produce      json
-	// @Success      200  {object}  object{status=string,database=string,redis=string}
+	// @Success      200  {object}  object{status=string,database=string,redis=string,realtime=events.HubStats}
 	// @Failure      503  {object}  object{status=string,database=string,redis=string}
 	// @Router       /health [get]
 	app.Get("/health", func(c *fiber.Ctx) error {
@@ -200,6 +250,7 @@ func main() {
 			"status":   "ok",
 			"database": "connected",
 			"redis":    "connected",
+			"realtime": eventHub.Stats(),
 		})
 	})
 
@@ -238,22 +289,62 @@ func main() {
 	api := app.Group("/api")
 	api.Post("/auth/register", authHandler.Register)
 	api.Post("/auth/login", authHandler.Login)
+	api.Post("/auth/refresh", authHandler.Refresh)
 
 	// WebSocket endpoint (unprotected - handles auth internally via query param)
 	api.Get("/events/ws", websocket.New(wsHandler.HandleWebSocket))
 
 	// Protected routes with JWT
-	protected := api.Group("/", middleware.JWT(cfg.JWTSecret))
+	protected := api.Group("/", middleware.JWT(cfg.JWTSecret, authService))
 	protected.Get("/auth/me", authHandler.GetMe)
+	protected.Post("/auth/logout", authHandler.Logout)
+	protected.Get("/events/stream", sseHandler.Stream)
 	protected.Post("/posts", postHandler.CreatePost)
 	protected.Get("/posts/:id", postHandler.GetPost)
+	protected.Patch("/posts/:id", postHandler.UpdatePost)
 	protected.Delete("/posts/:id", postHandler.DeletePost)
 	protected.Post("/posts/:id/like", interactionHandler.LikePost)
+	protected.Put("/posts/:id/like", interactionHandler.PutLike)
+	protected.Delete("/posts/:id/like", interactionHandler.DeleteLike)
+	protected.Put("/posts/:id/reaction", interactionHandler.PutReaction)
+	protected.Delete("/posts/:id/reaction", interactionHandler.DeleteReaction)
+	protected.Get("/posts/:id/likes", interactionHandler.GetLikers)
+	protected.Put("/posts/:id/save", saveHandler.SavePost)
+	protected.Delete("/posts/:id/save", saveHandler.UnsavePost)
 	protected.Post("/posts/:id/comment", interactionHandler.CommentPost)
 	protected.Get("/posts/:id/comments", interactionHandler.GetComments)
+	protected.Get("/posts/:id/comments/:commentId/replies", interactionHandler.GetReplies)
+	protected.Put("/posts/:id/comments/:commentId", interactionHandler.UpdateComment)
+	protected.Delete("/posts/:id/comments/:commentId", interactionHandler.DeleteComment)
+	protected.Post("/posts/:id/comments/:commentId/like", interactionHandler.LikeComment)
+	protected.Delete("/posts/:id/comments/:commentId/like", interactionHandler.UnlikeComment)
 	protected.Post("/posts/:id/view/start", viewHandler.StartView)
 	protected.Post("/posts/:id/view/end", viewHandler.EndView)
 	protected.Get("/feed", feedHandler.GetFeed)
+	protected.Put("/users/me/settings", userHandler.UpdateSettings)
+	protected.Get("/users/me/saved", saveHandler.GetSavedPosts)
+	protected.Get("/users/:id", userHandler.GetUser)
+	protected.Post("/users/:id/follow", userHandler.Follow)
+	protected.Delete("/users/:id/follow", userHandler.Unfollow)
+	protected.Get("/users/:id/followers", userHandler.GetFollowers)
+	protected.Get("/users/:id/following", userHandler.GetFollowing)
+	protected.Get("/users/:id/posts", feedHandler.GetUserPosts)
+	protected.Get("/hashtags/trending", hashtagHandler.GetTrending)
+	protected.Get("/hashtags/:tag/posts", feedHandler.GetHashtagPosts)
+	protected.Get("/collections", saveHandler.ListCollections)
+	protected.Post("/collections", saveHandler.CreateCollection)
+	protected.Patch("/collections/:id", saveHandler.RenameCollection)
+	protected.Delete("/collections/:id", saveHandler.DeleteCollection)
+	protected.Get("/collections/:id/posts", saveHandler.GetCollectionPosts)
+	protected.Post("/webhooks", webhookHandler.CreateWebhook)
+	protected.Get("/webhooks", webhookHandler.ListWebhooks)
+	protected.Delete("/webhooks/:id", webhookHandler.DeleteWebhook)
+	protected.Post("/webhooks/:id/test", webhookHandler.TestWebhook)
+	protected.Get("/webhooks/:id/deliveries", webhookHandler.ListDeliveries)
+	protected.Get("/notifications", notificationHandler.ListNotifications)
+	protected.Get("/notifications/unread_count", notificationHandler.UnreadCount)
+	protected.Post("/notifications/read_all", notificationHandler.MarkAllRead)
+	protected.Post("/notifications/:id/read", notificationHandler.MarkRead)
 
 	appLogger.Info("server starting",
 		zap.String("port", cfg.Port),
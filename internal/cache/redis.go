@@ -3,6 +3,9 @@ package cache
 import (
 	"context"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -14,14 +17,46 @@ type Cache interface {
 	Get(ctx context.Context, key string) ([]byte, error)
 	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
 	Delete(ctx context.Context, key string) error
+	Exists(ctx context.Context, keys ...string) (int64, error)
 	Keys(ctx context.Context, pattern string) ([]string, error)
 	Ping(ctx context.Context) error
 	FlushAll(ctx context.Context) error
 	Publish(ctx context.Context, channel string, message string) error
 	Subscribe(ctx context.Context, channel string) (<-chan string, error)
+	ZAddToMany(ctx context.Context, keys []string, score float64, member string, maxLen int64) error
+	ZRemFromMany(ctx context.Context, keys []string, member string) error
+	ZAddMembers(ctx context.Context, key string, members []ZMember, maxLen int64) error
+	ZRemMembers(ctx context.Context, key string, members []string) error
+	ZRevRangeByScore(ctx context.Context, key string, max float64, offset, count int64) ([]ZMember, error)
+	ZIncrByMany(ctx context.Context, keys []string, members []string, incr float64, ttl time.Duration) error
+	ZUnionTop(ctx context.Context, keys []string, count int) ([]ZMember, error)
+	XAdd(ctx context.Context, stream string, maxLen int64, payload string) (string, error)
+	XRead(ctx context.Context, stream, afterID string, count int64, block time.Duration) ([]StreamEntry, error)
+	XLastID(ctx context.Context, stream string) (string, error)
 	Close() error
 }
 
+// ZMember is a sorted set member with its score
+type ZMember struct {
+	Member string
+	Score  float64
+}
+
+// StreamEntry is a Redis stream entry carrying a single payload field
+type StreamEntry struct {
+	ID      string
+	Payload string
+}
+
+// streamPayloadField is the field holding a stream entry's payload
+const streamPayloadField = "payload"
+
+// keysScanCount is how many keys Keys asks SCAN to look at per call
+const keysScanCount = 500
+
+// zunionScratchPrefix prefixes the scratch keys ZUnionTop stores unions in
+const zunionScratchPrefix = "zunion:"
+
 // RedisCache implements the Cache interface using Redis
 type RedisCache struct {
 	client *redis.Client
@@ -98,9 +133,35 @@ func (r *RedisCache) Delete(ctx context.Context, key string) error {
 	return nil
 }
 
-// Keys returns all keys matching the given pattern
+// Exists returns how many of the given keys are present
+func (r *RedisCache) Exists(ctx context.Context, keys ...string) (int64, error) {
+	count, err := r.client.Exists(ctx, keys...).Result()
+	if err != nil {
+		r.logger.Error("redis exists failed",
+			zap.Strings("keys", keys),
+			zap.Error(err),
+		)
+		return 0, err
+	}
+	return count, nil
+}
+
+// Keys returns all keys matching the given pattern. It walks the keyspace
+// with SCAN rather than KEYS so that Redis is not blocked while it looks.
 func (r *RedisCache) Keys(ctx context.Context, pattern string) ([]string, error) {
-	return r.client.Keys(ctx, pattern).Result()
+	var keys []string
+	iter := r.client.Scan(ctx, 0, pattern, keysScanCount).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		r.logger.Error("redis scan failed",
+			zap.String("pattern", pattern),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	return keys, nil
 }
 
 // Ping checks if Redis is reachable
@@ -158,6 +219,255 @@ func (r *RedisCache) Subscribe(ctx context.Context, channel string) (<-chan stri
 	return stringCh, nil
 }
 
+// ZAddToMany adds the same member to every sorted set in a single pipeline,
+// trimming each set to its maxLen highest scored members (0 disables trimming)
+func (r *RedisCache) ZAddToMany(ctx context.Context, keys []string, score float64, member string, maxLen int64) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	pipe := r.client.Pipeline()
+	for _, key := range keys {
+		pipe.ZAdd(ctx, key, redis.Z{Score: score, Member: member})
+		if maxLen > 0 {
+			pipe.ZRemRangeByRank(ctx, key, 0, -maxLen-1)
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		r.logger.Error("redis zadd pipeline failed",
+			zap.Int("keys", len(keys)),
+			zap.String("member", member),
+			zap.Error(err),
+		)
+		return err
+	}
+	return nil
+}
+
+// ZRemFromMany removes a member from every sorted set in a single pipeline
+func (r *RedisCache) ZRemFromMany(ctx context.Context, keys []string, member string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	pipe := r.client.Pipeline()
+	for _, key := range keys {
+		pipe.ZRem(ctx, key, member)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		r.logger.Error("redis zrem pipeline failed",
+			zap.Int("keys", len(keys)),
+			zap.String("member", member),
+			zap.Error(err),
+		)
+		return err
+	}
+	return nil
+}
+
+// ZAddMembers adds members to one sorted set, trimming it to its maxLen
+// highest scored members (0 disables trimming)
+func (r *RedisCache) ZAddMembers(ctx context.Context, key string, members []ZMember, maxLen int64) error {
+	if len(members) == 0 {
+		return nil
+	}
+
+	zs := make([]redis.Z, len(members))
+	for i, m := range members {
+		zs[i] = redis.Z{Score: m.Score, Member: m.Member}
+	}
+	pipe := r.client.Pipeline()
+	pipe.ZAdd(ctx, key, zs...)
+	if maxLen > 0 {
+		pipe.ZRemRangeByRank(ctx, key, 0, -maxLen-1)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		r.logger.Error("redis zadd failed",
+			zap.String("key", key),
+			zap.Int("members", len(members)),
+			zap.Error(err),
+		)
+		return err
+	}
+	return nil
+}
+
+// ZRemMembers removes members from one sorted set
+func (r *RedisCache) ZRemMembers(ctx context.Context, key string, members []string) error {
+	if len(members) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, len(members))
+	for i, m := range members {
+		args[i] = m
+	}
+	if err := r.client.ZRem(ctx, key, args...).Err(); err != nil {
+		r.logger.Error("redis zrem failed",
+			zap.String("key", key),
+			zap.Int("members", len(members)),
+			zap.Error(err),
+		)
+		return err
+	}
+	return nil
+}
+
+// ZRevRangeByScore returns members with score <= max, highest score first.
+// Members sharing a score are ordered by member descending.
+func (r *RedisCache) ZRevRangeByScore(ctx context.Context, key string, max float64, offset, count int64) ([]ZMember, error) {
+	maxStr := "+inf"
+	if !math.IsInf(max, 1) {
+		maxStr = strconv.FormatFloat(max, 'f', -1, 64)
+	}
+
+	results, err := r.client.ZRevRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{
+		Max:    maxStr,
+		Min:    "-inf",
+		Offset: offset,
+		Count:  count,
+	}).Result()
+	if err != nil {
+		r.logger.Error("redis zrevrangebyscore failed",
+			zap.String("key", key),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	members := make([]ZMember, len(results))
+	for i, z := range results {
+		members[i] = ZMember{Member: fmt.Sprint(z.Member), Score: z.Score}
+	}
+	return members, nil
+}
+
+// ZIncrByMany increments every member in every sorted set by incr in a single
+// pipeline, and sets each set to expire after ttl
+func (r *RedisCache) ZIncrByMany(ctx context.Context, keys []string, members []string, incr float64, ttl time.Duration) error {
+	if len(keys) == 0 || len(members) == 0 {
+		return nil
+	}
+
+	pipe := r.client.Pipeline()
+	for _, key := range keys {
+		for _, member := range members {
+			pipe.ZIncrBy(ctx, key, incr, member)
+		}
+		pipe.Expire(ctx, key, ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		r.logger.Error("redis zincrby pipeline failed",
+			zap.Int("keys", len(keys)),
+			zap.Int("members", len(members)),
+			zap.Error(err),
+		)
+		return err
+	}
+	return nil
+}
+
+// ZUnionTop sums the scores of each member across the sorted sets and returns
+// the count highest, highest score first. Members sharing a score are ordered
+// by member ascending.
+//
+// The union is stored negated in a scratch key and read back in ascending
+// order, so Redis does the sorting and only count members leave it. The
+// scratch key is written, read and deleted in one transaction.
+func (r *RedisCache) ZUnionTop(ctx context.Context, keys []string, count int) ([]ZMember, error) {
+	if len(keys) == 0 || count <= 0 {
+		return nil, nil
+	}
+
+	weights := make([]float64, len(keys))
+	for i := range weights {
+		weights[i] = -1
+	}
+	scratch := zunionScratchPrefix + strings.Join(keys, ",")
+
+	var top *redis.ZSliceCmd
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZUnionStore(ctx, scratch, &redis.ZStore{Keys: keys, Weights: weights})
+		top = pipe.ZRangeWithScores(ctx, scratch, 0, int64(count)-1)
+		pipe.Del(ctx, scratch)
+		return nil
+	})
+	if err != nil {
+		r.logger.Error("redis zunionstore failed",
+			zap.Int("keys", len(keys)),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	results := top.Val()
+	members := make([]ZMember, len(results))
+	for i, z := range results {
+		members[i] = ZMember{Member: fmt.Sprint(z.Member), Score: -z.Score}
+	}
+	return members, nil
+}
+
+// XAdd appends payload to a stream trimmed to roughly maxLen entries and
+// returns the ID Redis assigned to the entry
+func (r *RedisCache) XAdd(ctx context.Context, stream string, maxLen int64, payload string) (string, error) {
+	id, err := r.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: stream,
+		MaxLen: maxLen,
+		Approx: true,
+		Values: []string{streamPayloadField, payload},
+	}).Result()
+	if err != nil {
+		r.logger.Error("redis xadd failed",
+			zap.String("stream", stream),
+			zap.Error(err),
+		)
+		return "", err
+	}
+	return id, nil
+}
+
+// XRead returns up to count entries with an ID greater than afterID, oldest
+// first. A positive block waits up to that long for new entries (zero waits
+// forever, negative not at all); an empty result means none arrived.
+func (r *RedisCache) XRead(ctx context.Context, stream, afterID string, count int64, block time.Duration) ([]StreamEntry, error) {
+	if block < 0 {
+		block = -1
+	}
+	results, err := r.client.XRead(ctx, &redis.XReadArgs{
+		Streams: []string{stream, afterID},
+		Count:   count,
+		Block:   block,
+	}).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []StreamEntry
+	for _, result := range results {
+		for _, msg := range result.Messages {
+			payload, _ := msg.Values[streamPayloadField].(string)
+			entries = append(entries, StreamEntry{ID: msg.ID, Payload: payload})
+		}
+	}
+	return entries, nil
+}
+
+// XLastID returns the ID of the newest entry in a stream, or "0-0" when empty
+func (r *RedisCache) XLastID(ctx context.Context, stream string) (string, error) {
+	msgs, err := r.client.XRevRangeN(ctx, stream, "+", "-", 1).Result()
+	if err != nil {
+		return "", err
+	}
+	if len(msgs) == 0 {
+		return "0-0", nil
+	}
+	return msgs[0].ID, nil
+}
+
 // Close closes the Redis client connection
 func (r *RedisCache) Close() error {
 	return r.client.Close()
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
@@ -14,6 +15,8 @@ type Config struct {
 	S3Bucket        string
 	S3Region        string
 	JWTSecret       string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
 	Port            string
 	LogLevel        string
 	LogFormat       string
@@ -24,6 +27,43 @@ type Config struct {
 	RedisDB         int
 	CacheTTL        time.Duration
 
+	// Ranked feed configuration
+	FeedRankWindow     int
+	FeedRankSessionTTL time.Duration
+	FeedRanker         string
+	RankLikeWeight     float64
+	RankCommentWeight  float64
+	RankViewWeight     float64
+	RankSaveWeight     float64
+	RankDecayRate      float64
+	RankGravity        float64
+	RankWilsonZ        float64
+
+	// Weights of reaction types other than a plain like, which falls back to
+	// RankLikeWeight, e.g. RANK_REACTION_WEIGHTS=heart=3,fire=4
+	RankReactionWeights map[string]float64
+
+	// Home timeline configuration
+	TimelineCelebrityThreshold int
+	TimelineMaxLength          int
+
+	// Real-time events configuration
+	EventStreamMaxLen int
+	EventClientBuffer int
+
+	// Outbox relay configuration
+	OutboxBatchSize    int
+	OutboxMaxAttempts  int
+	OutboxPollInterval time.Duration
+	OutboxRetention    time.Duration
+
+	// Webhook configuration
+	WebhookTimeout      time.Duration
+	WebhookMaxAttempts  int
+	WebhookPollInterval time.Duration
+	// WebhookAllowPrivate lets webhooks target loopback and private networks
+	WebhookAllowPrivate bool
+
 	// Webclient configuration
 	WebclientUseMock     bool
 	WebclientMockBaseURL string
@@ -37,6 +77,8 @@ func Load() *Config {
 		S3Bucket:        getEnv("S3_BUCKET", "instagrano-media"),
 		S3Region:        getEnv("S3_REGION", "us-east-1"),
 		JWTSecret:       getEnv("JWT_SECRET", "dev-secret"),
+		AccessTokenTTL:  getDurationEnv("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL: getDurationEnv("REFRESH_TOKEN_TTL", 30*24*time.Hour),
 		Port:            getEnv("PORT", "8080"),
 		LogLevel:        getEnv("LOG_LEVEL", "info"),
 		LogFormat:       getEnv("LOG_FORMAT", "json"),
@@ -47,6 +89,40 @@ func Load() *Config {
 		RedisDB:         getEnvInt("REDIS_DB", 0),
 		CacheTTL:        getDurationEnv("CACHE_TTL", 5*time.Minute),
 
+		// Ranked feed configuration
+		FeedRankWindow:     getEnvInt("FEED_RANK_WINDOW", 1000),
+		FeedRankSessionTTL: getDurationEnv("FEED_RANK_SESSION_TTL", 30*time.Minute),
+		FeedRanker:         getEnv("FEED_RANKER", "decay"),
+		RankLikeWeight:     getFloatEnv("RANK_LIKE_WEIGHT", 2.0),
+		RankCommentWeight:  getFloatEnv("RANK_COMMENT_WEIGHT", 3.0),
+		RankViewWeight:     getFloatEnv("RANK_VIEW_WEIGHT", 0.1),
+		RankSaveWeight:     getFloatEnv("RANK_SAVE_WEIGHT", 4.0),
+		RankDecayRate:      getFloatEnv("RANK_DECAY_RATE", 0.1),
+		RankGravity:        getFloatEnv("RANK_GRAVITY", 1.8),
+		RankWilsonZ:        getFloatEnv("RANK_WILSON_Z", 1.96),
+
+		RankReactionWeights: getFloatMapEnv("RANK_REACTION_WEIGHTS", map[string]float64{}),
+
+		// Home timeline configuration
+		TimelineCelebrityThreshold: getEnvInt("TIMELINE_CELEBRITY_THRESHOLD", 10000),
+		TimelineMaxLength:          getEnvInt("TIMELINE_MAX_LENGTH", 800),
+
+		// Real-time events configuration
+		EventStreamMaxLen: getEnvInt("EVENT_STREAM_MAX_LEN", 10000),
+		EventClientBuffer: getEnvInt("EVENT_CLIENT_BUFFER", 256),
+
+		// Outbox relay configuration
+		OutboxBatchSize:    getEnvInt("OUTBOX_BATCH_SIZE", 100),
+		OutboxMaxAttempts:  getEnvInt("OUTBOX_MAX_ATTEMPTS", 20),
+		OutboxPollInterval: getDurationEnv("OUTBOX_POLL_INTERVAL", 250*time.Millisecond),
+		OutboxRetention:    getDurationEnv("OUTBOX_RETENTION", 24*time.Hour),
+
+		// Webhook configuration
+		WebhookTimeout:      getDurationEnv("WEBHOOK_TIMEOUT", 10*time.Second),
+		WebhookMaxAttempts:  getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+		WebhookPollInterval: getDurationEnv("WEBHOOK_POLL_INTERVAL", time.Second),
+		WebhookAllowPrivate: getBoolEnv("WEBHOOK_ALLOW_PRIVATE", false),
+
 		// Webclient configuration
 		WebclientUseMock:     getBoolEnv("WEBCLIENT_USE_MOCK", true),
 		WebclientMockBaseURL: getEnv("WEBCLIENT_MOCK_BASE_URL", "http://localhost:8080"),
@@ -85,6 +161,34 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getFloatEnv(key string, defaultValue float64) float64 {
+	if value, ok := os.LookupEnv(key); ok {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
+// getFloatMapEnv parses comma-separated key=value pairs, skipping malformed ones
+func getFloatMapEnv(key string, defaultValue map[string]float64) map[string]float64 {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultValue
+	}
+	result := make(map[string]float64)
+	for _, pair := range strings.Split(value, ",") {
+		k, v, found := strings.Cut(pair, "=")
+		if !found {
+			continue
+		}
+		if floatValue, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			result[strings.TrimSpace(k)] = floatValue
+		}
+	}
+	return result
+}
+
 func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
 	if value, ok := os.LookupEnv(key); ok {
 		if duration, err := time.ParseDuration(value); err == nil {
@@ -2,11 +2,27 @@ package domain
 
 import "time"
 
+// Orders for listing a post's top-level comments
+const (
+	CommentSortOldest = "oldest"
+	CommentSortNewest = "newest"
+	CommentSortTop    = "top" // Most liked first
+)
+
+// Comment is a comment on a post. Top-level comments have no ParentID and
+// carry the number of replies in their thread. EditedAt is set once the
+// author edits the text. LikedByMe is relative to the user who listed it.
 type Comment struct {
-	ID        uint      `json:"id"`
-	UserID    uint      `json:"user_id"`
-	PostID    uint      `json:"post_id"`
-	Text      string    `json:"text"`
-	Username  string    `json:"username"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         uint       `json:"id"`
+	UserID     uint       `json:"user_id"`
+	PostID     uint       `json:"post_id"`
+	ParentID   *uint      `json:"parent_id,omitempty"`
+	Text       string     `json:"text"`
+	Mentions   []Mention  `json:"mentions"`
+	Username   string     `json:"username"`
+	ReplyCount int        `json:"reply_count"`
+	LikesCount int        `json:"likes_count"`
+	LikedByMe  bool       `json:"liked_by_me"`
+	CreatedAt  time.Time  `json:"created_at"`
+	EditedAt   *time.Time `json:"edited_at,omitempty"`
 }
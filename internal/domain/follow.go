@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+// Follow is a directed edge: FollowerID follows FolloweeID
+type Follow struct {
+	FollowerID uint      `json:"follower_id"`
+	FolloweeID uint      `json:"followee_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// FollowUser is an entry of a followers/following list
+type FollowUser struct {
+	ID         uint      `json:"id"`
+	Username   string    `json:"username"`
+	FollowedAt time.Time `json:"followed_at"`
+}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// MaxHashtagLength is the longest hashtag, without the #, that is indexed
+const MaxHashtagLength = 100
+
+// hashtagPattern matches a # that does not follow a letter, digit or
+// underscore, so "page#anchor" is not a hashtag
+var hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_])#([\p{L}\p{N}_]+)`)
+
+// TrendingHashtag is a hashtag with the number of posts that used it in a
+// trending window
+type TrendingHashtag struct {
+	Name       string `json:"name"`
+	PostsCount int    `json:"posts_count"`
+}
+
+// ParseHashtags returns the hashtags in text, lowercased and without the #,
+// in order of first appearance
+func ParseHashtags(text string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, match := range hashtagPattern.FindAllStringSubmatch(text, -1) {
+		tag, ok := NormalizeHashtag(match[1])
+		if ok && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// NormalizeHashtag lowercases tag and strips a leading #. It reports false
+// if tag is not a valid hashtag: letters, digits and underscores with at
+// least one letter, up to MaxHashtagLength characters.
+func NormalizeHashtag(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+	if tag == "" || len([]rune(tag)) > MaxHashtagLength {
+		return "", false
+	}
+
+	hasLetter := false
+	for _, r := range tag {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsNumber(r) || r == '_':
+		default:
+			return "", false
+		}
+	}
+	if !hasLetter {
+		return "", false
+	}
+	return tag, true
+}
@@ -6,5 +6,16 @@ type Like struct {
     ID        uint      `json:"id"`
     UserID    uint      `json:"user_id"`
     PostID    uint      `json:"post_id"`
+    Reaction  string    `json:"reaction"`
     CreatedAt time.Time `json:"created_at"`
 }
+
+// Liker is an entry of a post's likers list. Reaction is the liker's
+// reaction type; FollowedByMe is whether the viewer follows them.
+type Liker struct {
+    ID           uint      `json:"id"`
+    Username     string    `json:"username"`
+    Reaction     string    `json:"reaction"`
+    LikedAt      time.Time `json:"liked_at"`
+    FollowedByMe bool      `json:"followed_by_me"`
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// mentionPattern matches an @ that does not follow a letter, digit or one of
+// _ . @, so "ana@example.com" is not a mention. Usernames may contain dots
+// but not end with one.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_.@])(@[\p{L}\p{N}_](?:[\p{L}\p{N}_.]*[\p{L}\p{N}_])?)`)
+
+// Mention is an @username in a caption or comment. Offset and Length locate
+// it in the text, counted in characters (Unicode code points) and including
+// the @. UserID is set once the username is resolved to a user.
+type Mention struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	Offset   int    `json:"offset"`
+	Length   int    `json:"length"`
+}
+
+// ParseMentions returns every @username in text, in order. The same user may
+// be mentioned more than once.
+func ParseMentions(text string) []Mention {
+	var mentions []Mention
+	for _, match := range mentionPattern.FindAllStringSubmatchIndex(text, -1) {
+		start, end := match[2], match[3]
+		mentions = append(mentions, Mention{
+			Username: strings.TrimPrefix(text[start:end], "@"),
+			Offset:   utf8.RuneCountInString(text[:start]),
+			Length:   utf8.RuneCountInString(text[start:end]),
+		})
+	}
+	return mentions
+}
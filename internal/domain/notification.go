@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Notification types
+const (
+	NotificationTypeLike    = "like"
+	NotificationTypeComment = "comment"
+)
+
+// Notification tells a user that others interacted with their post. Unread
+// notifications of the same type on the same post are coalesced: ActorID is
+// the most recent actor and ActorCount the number of distinct actors.
+type Notification struct {
+	ID            uint       `json:"id"`
+	UserID        uint       `json:"user_id"`
+	Type          string     `json:"type"`
+	PostID        uint       `json:"post_id"`
+	ActorID       uint       `json:"actor_id"`
+	ActorUsername string     `json:"actor_username"`
+	ActorCount    int        `json:"actor_count"`
+	ReadAt        *time.Time `json:"read_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// Message describes the notification, e.g. "alice and 12 others liked your post"
+func (n *Notification) Message() string {
+	action := "liked your post"
+	if n.Type == NotificationTypeComment {
+		action = "commented on your post"
+	}
+
+	switch others := n.ActorCount - 1; {
+	case others <= 0:
+		return fmt.Sprintf("%s %s", n.ActorUsername, action)
+	case others == 1:
+		return fmt.Sprintf("%s and 1 other %s", n.ActorUsername, action)
+	default:
+		return fmt.Sprintf("%s and %d others %s", n.ActorUsername, others, action)
+	}
+}
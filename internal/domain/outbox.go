@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// OutboxMessage is a domain event recorded in the same transaction as the
+// change it describes, waiting to be published
+type OutboxMessage struct {
+	ID        int64
+	EventType string
+	Payload   []byte // JSON-encoded event
+	Attempts  int
+	CreatedAt time.Time
+}
@@ -1,24 +1,53 @@
 package domain
 
-import (
-	"math"
-	"time"
-)
+import "time"
 
 type Post struct {
-	ID            uint      `json:"id"`
-	UserID        uint      `json:"user_id"`
-	Username      string    `json:"username"` // Populated from JOIN with users table
-	Title         string    `json:"title"`
-	Caption       string    `json:"caption"`
-	MediaType     MediaType `json:"media_type"`
-	MediaURL      string    `json:"media_url"`
-	LikesCount    int       `json:"likes_count"`
-	CommentsCount int       `json:"comments_count"`
-	ViewsCount    int       `json:"views_count"`
-	Score         float64   `json:"score"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID             uint           `json:"id"`
+	UserID         uint           `json:"user_id"`
+	Username       string         `json:"username"` // Populated from JOIN with users table
+	HideLikers     bool           `json:"-"`        // The author's setting, from the same JOIN
+	Title          string         `json:"title"`
+	Caption        string         `json:"caption"`
+	MediaType      MediaType      `json:"media_type"`  // Same as the first media item
+	MediaURL       string         `json:"media_url"`   // Same as the first media item
+	Media          []PostMedia    `json:"media"`       // In display order
+	Mentions       []Mention      `json:"mentions"`    // In the caption
+	LikesCount     int            `json:"likes_count"` // Reactions of any type
+	ReactionCounts ReactionCounts `json:"reaction_counts"`
+	CommentsCount  int            `json:"comments_count"`
+	ViewsCount     int            `json:"views_count"`
+	SavesCount     int            `json:"-"` // Saves are private, only used for ranking
+	Score          float64        `json:"score"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+// MaxPostMedia is the most media items a carousel post can have
+const MaxPostMedia = 10
+
+// PostMedia is one item of a post's carousel. Single-media posts have one.
+type PostMedia struct {
+	Position  int       `json:"position"`
+	MediaType MediaType `json:"media_type"`
+	MediaURL  string    `json:"media_url"`
+}
+
+// PostEdit is a post's title and caption as they were before an edit
+type PostEdit struct {
+	ID       uint      `json:"id"`
+	PostID   uint      `json:"post_id"`
+	Title    string    `json:"title"`
+	Caption  string    `json:"caption"`
+	EditedAt time.Time `json:"edited_at"`
+}
+
+// PostViewerState is what one viewer has done with a post. It is never
+// cached with the post, which is shared by every viewer.
+type PostViewerState struct {
+	Reaction  string // Empty if the viewer has not reacted
+	Commented bool
+	Saved     bool
 }
 
 type MediaType string
@@ -27,19 +56,3 @@ const (
 	MediaTypeImage MediaType = "image"
 	MediaTypeVideo MediaType = "video"
 )
-
-const (
-	LikeWeight    = 2.0
-	CommentWeight = 3.0
-	ViewWeight    = 0.1
-	DecayRate     = 0.1
-)
-
-func (p *Post) CalculateScore() float64 {
-	ageHours := time.Since(p.CreatedAt).Hours()
-	engagementScore := float64(p.LikesCount)*LikeWeight +
-		float64(p.CommentsCount)*CommentWeight +
-		float64(p.ViewsCount)*ViewWeight
-	timeDecay := math.Exp(-DecayRate * ageHours)
-	return engagementScore * timeDecay
-}
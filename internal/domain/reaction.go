@@ -0,0 +1,55 @@
+package domain
+
+// Reaction types. A like is the "like" reaction; a user has at most one
+// reaction per post.
+const (
+	ReactionLike  = "like"
+	ReactionHeart = "heart"
+	ReactionLaugh = "laugh"
+	ReactionWow   = "wow"
+	ReactionSad   = "sad"
+	ReactionFire  = "fire"
+)
+
+// ReactionTypes lists the supported reactions
+var ReactionTypes = []string{ReactionLike, ReactionHeart, ReactionLaugh, ReactionWow, ReactionSad, ReactionFire}
+
+// IsReactionType reports whether reaction is a supported reaction
+func IsReactionType(reaction string) bool {
+	for _, t := range ReactionTypes {
+		if t == reaction {
+			return true
+		}
+	}
+	return false
+}
+
+// ReactionCounts maps a reaction type to how many users reacted with it.
+// Types nobody reacted with are omitted.
+type ReactionCounts map[string]int
+
+// PostCounts are a post's interaction counters. LikesCount is the number of
+// reactions of any type.
+type PostCounts struct {
+	LikesCount     int
+	CommentsCount  int
+	ReactionCounts ReactionCounts
+}
+
+// MoveReaction updates the counters for a user whose reaction changed from
+// one type to another; an empty type means no reaction
+func (c *PostCounts) MoveReaction(from, to string) {
+	if c.ReactionCounts == nil {
+		c.ReactionCounts = ReactionCounts{}
+	}
+	if from != "" {
+		c.LikesCount--
+		if c.ReactionCounts[from]--; c.ReactionCounts[from] <= 0 {
+			delete(c.ReactionCounts, from)
+		}
+	}
+	if to != "" {
+		c.LikesCount++
+		c.ReactionCounts[to]++
+	}
+}
@@ -0,0 +1,26 @@
+package domain
+
+import "time"
+
+// RefreshToken is a persisted, rotating refresh token. Only the SHA-256 hash
+// of the token is stored; tokens rotated from the same login share a FamilyID.
+type RefreshToken struct {
+	ID         uint       `json:"id"`
+	UserID     uint       `json:"user_id"`
+	TokenHash  string     `json:"-"`
+	FamilyID   string     `json:"family_id"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	ReplacedBy *uint      `json:"replaced_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// IsExpired reports whether the token is past its expiry time
+func (t *RefreshToken) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
+
+// IsRevoked reports whether the token has been revoked or rotated
+func (t *RefreshToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// MaxCollectionNameLength is the longest collection name, in characters
+const MaxCollectionNameLength = 100
+
+// Collection is a named group of a user's saved posts
+type Collection struct {
+	ID         uint      `json:"id"`
+	UserID     uint      `json:"user_id"`
+	Name       string    `json:"name"`
+	PostsCount int       `json:"posts_count"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// SavedPost is a post saved by a user, in one of their collections or in none
+type SavedPost struct {
+	Post         *Post
+	CollectionID *uint
+	SavedAt      time.Time
+}
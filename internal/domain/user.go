@@ -6,12 +6,15 @@ import (
 )
 
 type User struct {
-    ID        uint      `json:"id"`
-    Username  string    `json:"username"`
-    Email     string    `json:"email"`
-    Password  string    `json:"-"`
-    CreatedAt time.Time `json:"created_at"`
-    UpdatedAt time.Time `json:"updated_at"`
+    ID             uint      `json:"id"`
+    Username       string    `json:"username"`
+    Email          string    `json:"email"`
+    Password       string    `json:"-"`
+    FollowersCount int       `json:"followers_count"`
+    FollowingCount int       `json:"following_count"`
+    HideLikers     bool      `json:"hide_likers"` // Only the user sees who liked their posts
+    CreatedAt      time.Time `json:"created_at"`
+    UpdatedAt      time.Time `json:"updated_at"`
 }
 
 func (u *User) ValidatePassword(password string) error {
@@ -0,0 +1,50 @@
+package domain
+
+import "time"
+
+// Webhook scopes
+const (
+	// WebhookScopeUser receives events on the owner's posts and events the owner triggered
+	WebhookScopeUser = "user"
+	// WebhookScopeApp receives every event of the subscribed types
+	WebhookScopeApp = "app"
+)
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// Webhook is an HTTP endpoint that receives events. Payloads are signed with
+// Secret, which is only shown to the owner when the webhook is registered.
+type Webhook struct {
+	ID         uint      `json:"id"`
+	UserID     uint      `json:"user_id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"-"`
+	Scope      string    `json:"scope"`
+	EventTypes []string  `json:"event_types"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// WebhookDelivery is one event queued for one webhook, with the outcome of
+// its latest attempt
+type WebhookDelivery struct {
+	ID             int64      `json:"id"`
+	WebhookID      uint       `json:"webhook_id"`
+	EventID        string     `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Payload        []byte     `json:"-"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	ResponseStatus *int       `json:"response_status,omitempty"`
+	LastError      *string    `json:"last_error,omitempty"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+
+	// Webhook is set on deliveries claimed for sending
+	Webhook *Webhook `json:"-"`
+}
@@ -1,6 +1,10 @@
 package dto
 
-import "time"
+import (
+	"time"
+
+	"github.com/rodolfodpk/instagrano/internal/domain"
+)
 
 type RegisterRequest struct {
 	Username string `json:"username" validate:"required,min=3,max=50"`
@@ -13,14 +17,35 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
 type UserResponse struct {
-	ID        uint      `json:"id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"created_at"`
+	ID             uint      `json:"id"`
+	Username       string    `json:"username"`
+	Email          string    `json:"email,omitempty"`
+	FollowersCount int       `json:"followers_count"`
+	FollowingCount int       `json:"following_count"`
+	HideLikers     bool      `json:"hide_likers"`
+	CreatedAt      time.Time `json:"created_at"`
 }
 
 type AuthResponse struct {
-	User  UserResponse `json:"user"`
-	Token string       `json:"token"`
+	User         UserResponse `json:"user"`
+	Token        string       `json:"token"`
+	RefreshToken string       `json:"refresh_token,omitempty"`
+	ExpiresAt    *time.Time   `json:"expires_at,omitempty"`
+}
+
+func ToUserResponse(user *domain.User) UserResponse {
+	return UserResponse{
+		ID:             user.ID,
+		Username:       user.Username,
+		Email:          user.Email,
+		FollowersCount: user.FollowersCount,
+		FollowingCount: user.FollowingCount,
+		HideLikers:     user.HideLikers,
+		CreatedAt:      user.CreatedAt,
+	}
 }
@@ -1,7 +1,14 @@
 package dto
 
+import "github.com/rodolfodpk/instagrano/internal/domain"
+
 type FeedResponse struct {
 	Posts      []*PostResponse `json:"posts"`
 	NextCursor string          `json:"next_cursor"`
 	HasMore    bool            `json:"has_more"`
 }
+
+type TrendingHashtagsResponse struct {
+	Window   string                   `json:"window"`
+	Hashtags []domain.TrendingHashtag `json:"hashtags"`
+}
@@ -0,0 +1,44 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/rodolfodpk/instagrano/internal/domain"
+)
+
+type FollowUserResponse struct {
+	ID         uint      `json:"id"`
+	Username   string    `json:"username"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+type FollowListResponse struct {
+	Users      []*FollowUserResponse `json:"users"`
+	NextCursor string                `json:"next_cursor"`
+	HasMore    bool                  `json:"has_more"`
+}
+
+type ProfileResponse struct {
+	User      UserResponse `json:"user"`
+	Following bool         `json:"following"`
+}
+
+type UpdateSettingsRequest struct {
+	HideLikers *bool `json:"hide_likers"`
+}
+
+func ToFollowListResponse(users []*domain.FollowUser, nextCursor string) *FollowListResponse {
+	response := &FollowListResponse{
+		Users:      make([]*FollowUserResponse, len(users)),
+		NextCursor: nextCursor,
+		HasMore:    nextCursor != "",
+	}
+	for i, user := range users {
+		response.Users[i] = &FollowUserResponse{
+			ID:         user.ID,
+			Username:   user.Username,
+			FollowedAt: user.FollowedAt,
+		}
+	}
+	return response
+}
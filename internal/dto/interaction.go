@@ -1,6 +1,13 @@
 package dto
 
+import "github.com/rodolfodpk/instagrano/internal/domain"
+
 type CommentRequest struct {
+	Content  string `json:"content" validate:"required,min=1,max=500"`
+	ParentID *uint  `json:"parent_id,omitempty"` // Reply to this comment
+}
+
+type UpdateCommentRequest struct {
 	Content string `json:"content" validate:"required,min=1,max=500"`
 }
 
@@ -9,7 +16,71 @@ type LikeResponse struct {
 	LikesCount int  `json:"likes_count"`
 }
 
+type ReactionRequest struct {
+	Reaction string `json:"reaction" validate:"required"` // like, heart, laugh, wow, sad or fire
+}
+
+type ReactionResponse struct {
+	PostID         uint                  `json:"post_id"`
+	LikesCount     int                   `json:"likes_count"` // Reactions of any type
+	ReactionCounts domain.ReactionCounts `json:"reaction_counts"`
+	MyReaction     string                `json:"my_reaction,omitempty"`
+}
+
+func ToReactionResponse(postID uint, counts domain.PostCounts, myReaction string) *ReactionResponse {
+	reactionCounts := counts.ReactionCounts
+	if reactionCounts == nil {
+		reactionCounts = domain.ReactionCounts{}
+	}
+	return &ReactionResponse{
+		PostID:         postID,
+		LikesCount:     counts.LikesCount,
+		ReactionCounts: reactionCounts,
+		MyReaction:     myReaction,
+	}
+}
+
+type CommentLikeResponse struct {
+	CommentID  uint `json:"comment_id"`
+	LikesCount int  `json:"likes_count"`
+	LikedByMe  bool `json:"liked_by_me"`
+}
+
 type CommentResponse struct {
 	PostID        uint `json:"post_id"`
 	CommentsCount int  `json:"comments_count"`
 }
+
+type CommentListResponse struct {
+	Comments   []*domain.Comment `json:"comments"`
+	NextCursor string            `json:"next_cursor"`
+	HasMore    bool              `json:"has_more"`
+}
+
+func ToCommentListResponse(comments []*domain.Comment, nextCursor string) *CommentListResponse {
+	if comments == nil {
+		comments = []*domain.Comment{}
+	}
+	return &CommentListResponse{
+		Comments:   comments,
+		NextCursor: nextCursor,
+		HasMore:    nextCursor != "",
+	}
+}
+
+type LikerListResponse struct {
+	Likers     []*domain.Liker `json:"likers"`
+	NextCursor string          `json:"next_cursor"`
+	HasMore    bool            `json:"has_more"`
+}
+
+func ToLikerListResponse(likers []*domain.Liker, nextCursor string) *LikerListResponse {
+	if likers == nil {
+		likers = []*domain.Liker{}
+	}
+	return &LikerListResponse{
+		Likers:     likers,
+		NextCursor: nextCursor,
+		HasMore:    nextCursor != "",
+	}
+}
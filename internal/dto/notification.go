@@ -0,0 +1,63 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/rodolfodpk/instagrano/internal/domain"
+)
+
+type NotificationResponse struct {
+	ID            uint       `json:"id"`
+	Type          string     `json:"type"`
+	PostID        uint       `json:"post_id"`
+	ActorID       uint       `json:"actor_id"`
+	ActorUsername string     `json:"actor_username"`
+	ActorCount    int        `json:"actor_count"`
+	Message       string     `json:"message"`
+	Read          bool       `json:"read"`
+	ReadAt        *time.Time `json:"read_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+type NotificationListResponse struct {
+	Notifications []*NotificationResponse `json:"notifications"`
+	NextCursor    string                  `json:"next_cursor"`
+	HasMore       bool                    `json:"has_more"`
+}
+
+type UnreadCountResponse struct {
+	UnreadCount int `json:"unread_count"`
+}
+
+type MarkAllReadResponse struct {
+	Marked int `json:"marked"`
+}
+
+func ToNotificationResponse(notification *domain.Notification) *NotificationResponse {
+	return &NotificationResponse{
+		ID:            notification.ID,
+		Type:          notification.Type,
+		PostID:        notification.PostID,
+		ActorID:       notification.ActorID,
+		ActorUsername: notification.ActorUsername,
+		ActorCount:    notification.ActorCount,
+		Message:       notification.Message(),
+		Read:          notification.ReadAt != nil,
+		ReadAt:        notification.ReadAt,
+		CreatedAt:     notification.CreatedAt,
+		UpdatedAt:     notification.UpdatedAt,
+	}
+}
+
+func ToNotificationListResponse(notifications []*domain.Notification, nextCursor string) *NotificationListResponse {
+	response := &NotificationListResponse{
+		Notifications: make([]*NotificationResponse, len(notifications)),
+		NextCursor:    nextCursor,
+		HasMore:       nextCursor != "",
+	}
+	for i, notification := range notifications {
+		response.Notifications[i] = ToNotificationResponse(notification)
+	}
+	return response
+}
@@ -6,23 +6,51 @@ import (
 	"github.com/rodolfodpk/instagrano/internal/domain"
 )
 
+// UpdatePostRequest changes a post's title and/or caption. UpdatedAt is the
+// post's updated_at as last read, unless an If-Match header is sent instead.
+type UpdatePostRequest struct {
+	Title     *string    `json:"title"`
+	Caption   *string    `json:"caption"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
 type PostResponse struct {
-	ID            uint             `json:"id"`
-	UserID        uint             `json:"user_id"`
-	Username      string           `json:"username"`
-	Title         string           `json:"title"`
-	Caption       string           `json:"caption"`
-	MediaType     domain.MediaType `json:"media_type"`
-	MediaURL      string           `json:"media_url"`
-	LikesCount    int              `json:"likes_count"`
-	CommentsCount int              `json:"comments_count"`
-	ViewsCount    int              `json:"views_count"`
-	Score         float64          `json:"score"`
-	CreatedAt     time.Time        `json:"created_at"`
-	UpdatedAt     time.Time        `json:"updated_at"`
+	ID            uint               `json:"id"`
+	UserID        uint               `json:"user_id"`
+	Username      string             `json:"username"`
+	Title         string             `json:"title"`
+	Caption       string             `json:"caption"`
+	MediaType     domain.MediaType   `json:"media_type"`
+	MediaURL      string             `json:"media_url"` // The first item of a carousel
+	Media         []domain.PostMedia `json:"media"`
+	Mentions      []domain.Mention   `json:"mentions"`    // In the caption
+	LikesCount    int                `json:"likes_count"` // Reactions of any type
+	CommentsCount int                `json:"comments_count"`
+	ViewsCount    int                `json:"views_count"`
+	Score         float64            `json:"score"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+
+	ReactionCounts domain.ReactionCounts `json:"reaction_counts"`
+	MyReaction     string                `json:"my_reaction,omitempty"` // The caller's reaction, if any
+	LikedByMe      bool                  `json:"liked_by_me"`           // The caller reacted, with any type
+	CommentedByMe  bool                  `json:"commented_by_me"`
+	SavedByMe      bool                  `json:"saved_by_me"`
 }
 
 func ToPostResponse(post *domain.Post) *PostResponse {
+	reactionCounts := post.ReactionCounts
+	if reactionCounts == nil {
+		reactionCounts = domain.ReactionCounts{}
+	}
+	media := post.Media
+	if len(media) == 0 && post.MediaURL != "" {
+		media = []domain.PostMedia{{MediaType: post.MediaType, MediaURL: post.MediaURL}}
+	}
+	mentions := post.Mentions
+	if mentions == nil {
+		mentions = []domain.Mention{}
+	}
 	return &PostResponse{
 		ID:            post.ID,
 		UserID:        post.UserID,
@@ -31,11 +59,27 @@ func ToPostResponse(post *domain.Post) *PostResponse {
 		Caption:       post.Caption,
 		MediaType:     post.MediaType,
 		MediaURL:      post.MediaURL,
+		Media:         media,
+		Mentions:      mentions,
 		LikesCount:    post.LikesCount,
 		CommentsCount: post.CommentsCount,
 		ViewsCount:    post.ViewsCount,
 		Score:         post.Score,
 		CreatedAt:     post.CreatedAt,
 		UpdatedAt:     post.UpdatedAt,
+
+		ReactionCounts: reactionCounts,
+	}
+}
+
+// SetViewerState fills in what the caller has done with the post. A nil state
+// means they have done nothing.
+func (r *PostResponse) SetViewerState(state *domain.PostViewerState) {
+	if state == nil {
+		state = &domain.PostViewerState{}
 	}
+	r.MyReaction = state.Reaction
+	r.LikedByMe = state.Reaction != ""
+	r.CommentedByMe = state.Commented
+	r.SavedByMe = state.Saved
 }
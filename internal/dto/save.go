@@ -0,0 +1,42 @@
+package dto
+
+import "github.com/rodolfodpk/instagrano/internal/domain"
+
+type SavePostRequest struct {
+	CollectionID *uint `json:"collection_id,omitempty"` // Save in this collection, or in none when omitted
+}
+
+type SaveResponse struct {
+	PostID       uint  `json:"post_id"`
+	Saved        bool  `json:"saved"`
+	CollectionID *uint `json:"collection_id"`
+}
+
+type CollectionRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+type CollectionListResponse struct {
+	Collections []*domain.Collection `json:"collections"`
+}
+
+func ToCollectionListResponse(collections []*domain.Collection) *CollectionListResponse {
+	if collections == nil {
+		collections = []*domain.Collection{}
+	}
+	return &CollectionListResponse{Collections: collections}
+}
+
+// ToSavedPostsResponse builds a page of saved posts. The caller's state is
+// set separately.
+func ToSavedPostsResponse(posts []*domain.Post, nextCursor string) *FeedResponse {
+	response := &FeedResponse{
+		Posts:      make([]*PostResponse, len(posts)),
+		NextCursor: nextCursor,
+		HasMore:    nextCursor != "",
+	}
+	for i, post := range posts {
+		response.Posts[i] = ToPostResponse(post)
+	}
+	return response
+}
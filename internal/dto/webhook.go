@@ -0,0 +1,64 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/rodolfodpk/instagrano/internal/domain"
+)
+
+type CreateWebhookRequest struct {
+	URL        string   `json:"url" validate:"required,url"`
+	Scope      string   `json:"scope" validate:"omitempty,oneof=user app"`
+	EventTypes []string `json:"event_types"`
+}
+
+type WebhookResponse struct {
+	ID         uint      `json:"id"`
+	URL        string    `json:"url"`
+	Scope      string    `json:"scope"`
+	EventTypes []string  `json:"event_types"`
+	Secret     string    `json:"secret,omitempty"` // Only returned when the webhook is registered
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type WebhookListResponse struct {
+	Webhooks []*WebhookResponse `json:"webhooks"`
+}
+
+type WebhookTestResponse struct {
+	Success    bool   `json:"success"`
+	StatusCode int    `json:"status_code,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+type WebhookDeliveryListResponse struct {
+	Deliveries []*domain.WebhookDelivery `json:"deliveries"`
+}
+
+func ToWebhookResponse(webhook *domain.Webhook) *WebhookResponse {
+	return &WebhookResponse{
+		ID:         webhook.ID,
+		URL:        webhook.URL,
+		Scope:      webhook.Scope,
+		EventTypes: webhook.EventTypes,
+		CreatedAt:  webhook.CreatedAt,
+	}
+}
+
+func ToWebhookListResponse(webhooks []*domain.Webhook) *WebhookListResponse {
+	response := &WebhookListResponse{
+		Webhooks: make([]*WebhookResponse, len(webhooks)),
+	}
+	for i, webhook := range webhooks {
+		response.Webhooks[i] = ToWebhookResponse(webhook)
+	}
+	return response
+}
+
+func ToWebhookDeliveryListResponse(deliveries []*domain.WebhookDelivery) *WebhookDeliveryListResponse {
+	if deliveries == nil {
+		deliveries = []*domain.WebhookDelivery{}
+	}
+	return &WebhookDeliveryListResponse{Deliveries: deliveries}
+}
@@ -0,0 +1,282 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rodolfodpk/instagrano/internal/cache"
+	"go.uber.org/zap"
+)
+
+// EventStream is the Redis stream holding the event log
+const EventStream = "instagrano:events:stream"
+
+// ErrInvalidEventID is returned for IDs not of the form "<ms>-<seq>"
+var ErrInvalidEventID = errors.New("invalid event id")
+
+// EventBus is a durable, ordered log of events. Append assigns each event an
+// ID greater than every ID assigned before it.
+type EventBus interface {
+	// Append stores an event and returns it with its assigned ID
+	Append(ctx context.Context, event Event) (Event, error)
+	// Since returns up to limit events after afterID, oldest first
+	Since(ctx context.Context, afterID string, limit int) ([]Event, error)
+	// Subscribe delivers every event after afterID, replaying stored events
+	// before live ones. An empty afterID only delivers new events. The
+	// channel is closed when ctx is done.
+	Subscribe(ctx context.Context, afterID string) (<-chan Event, error)
+}
+
+// ValidateEventID checks id has the "<ms>-<seq>" form of a Redis stream ID
+func ValidateEventID(id string) error {
+	_, _, err := parseEventID(id)
+	return err
+}
+
+// CompareEventIDs returns -1, 0 or 1 as a is before, equal to or after b
+func CompareEventIDs(a, b string) int {
+	aMs, aSeq, _ := parseEventID(a)
+	bMs, bSeq, _ := parseEventID(b)
+	switch {
+	case aMs != bMs:
+		if aMs < bMs {
+			return -1
+		}
+		return 1
+	case aSeq != bSeq:
+		if aSeq < bSeq {
+			return -1
+		}
+		return 1
+	default:
+		return 0
+	}
+}
+
+func parseEventID(id string) (ms, seq uint64, err error) {
+	msPart, seqPart, found := strings.Cut(id, "-")
+	if ms, err = strconv.ParseUint(msPart, 10, 64); err != nil {
+		return 0, 0, ErrInvalidEventID
+	}
+	if found {
+		if seq, err = strconv.ParseUint(seqPart, 10, 64); err != nil {
+			return 0, 0, ErrInvalidEventID
+		}
+	}
+	return ms, seq, nil
+}
+
+// streamReadBlock bounds each blocking read so cancelled subscriptions exit
+const streamReadBlock = 5 * time.Second
+
+// streamReadCount is how many entries a subscription reads at a time
+const streamReadCount = 100
+
+// StreamBus is an EventBus backed by a Redis stream capped at about maxLen entries
+type StreamBus struct {
+	cache  cache.Cache
+	maxLen int64
+	logger *zap.Logger
+}
+
+// NewStreamBus creates an event bus on the EventStream Redis stream
+func NewStreamBus(cache cache.Cache, maxLen int, logger *zap.Logger) *StreamBus {
+	return &StreamBus{
+		cache:  cache,
+		maxLen: int64(maxLen),
+		logger: logger,
+	}
+}
+
+// Append adds the event to the stream, using the entry ID as the event ID
+func (b *StreamBus) Append(ctx context.Context, event Event) (Event, error) {
+	event.ID = ""
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return event, fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	id, err := b.cache.XAdd(ctx, EventStream, b.maxLen, string(payload))
+	if err != nil {
+		return event, err
+	}
+	event.ID = id
+	return event, nil
+}
+
+func (b *StreamBus) Since(ctx context.Context, afterID string, limit int) ([]Event, error) {
+	if err := ValidateEventID(afterID); err != nil {
+		return nil, err
+	}
+	entries, err := b.cache.XRead(ctx, EventStream, afterID, int64(limit), -1)
+	if err != nil {
+		return nil, err
+	}
+	return b.decode(entries), nil
+}
+
+func (b *StreamBus) Subscribe(ctx context.Context, afterID string) (<-chan Event, error) {
+	if afterID == "" {
+		lastID, err := b.cache.XLastID(ctx, EventStream)
+		if err != nil {
+			return nil, err
+		}
+		afterID = lastID
+	} else if err := ValidateEventID(afterID); err != nil {
+		return nil, err
+	}
+
+	ch := make(chan Event)
+	go func() {
+		defer close(ch)
+		cursor := afterID
+		for ctx.Err() == nil {
+			entries, err := b.cache.XRead(ctx, EventStream, cursor, streamReadCount, streamReadBlock)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				b.logger.Warn("failed to read event stream", zap.Error(err))
+				select {
+				case <-time.After(time.Second):
+				case <-ctx.Done():
+				}
+				continue
+			}
+
+			for _, event := range b.decode(entries) {
+				select {
+				case ch <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if len(entries) > 0 {
+				cursor = entries[len(entries)-1].ID
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func (b *StreamBus) decode(entries []cache.StreamEntry) []Event {
+	events := make([]Event, 0, len(entries))
+	for _, entry := range entries {
+		var event Event
+		if err := json.Unmarshal([]byte(entry.Payload), &event); err != nil {
+			b.logger.Warn("skipping unreadable event",
+				zap.String("event_id", entry.ID),
+				zap.Error(err))
+			continue
+		}
+		event.ID = entry.ID
+		events = append(events, event)
+	}
+	return events
+}
+
+// MemoryBus is an in-process EventBus for tests, keeping the last maxLen events
+type MemoryBus struct {
+	mu     sync.Mutex
+	events []Event
+	maxLen int
+	seq    uint64
+	// notify is closed and replaced on every append to wake subscribers
+	notify chan struct{}
+}
+
+func NewMemoryBus(maxLen int) *MemoryBus {
+	return &MemoryBus{
+		maxLen: maxLen,
+		notify: make(chan struct{}),
+	}
+}
+
+func (b *MemoryBus) Append(ctx context.Context, event Event) (Event, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	event.ID = fmt.Sprintf("%d-0", b.seq)
+	b.events = append(b.events, event)
+	if len(b.events) > b.maxLen {
+		b.events = b.events[len(b.events)-b.maxLen:]
+	}
+
+	close(b.notify)
+	b.notify = make(chan struct{})
+	return event, nil
+}
+
+func (b *MemoryBus) Since(ctx context.Context, afterID string, limit int) ([]Event, error) {
+	if err := ValidateEventID(afterID); err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	events := b.after(afterID)
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+func (b *MemoryBus) Subscribe(ctx context.Context, afterID string) (<-chan Event, error) {
+	b.mu.Lock()
+	if afterID == "" {
+		afterID = "0-0"
+		if len(b.events) > 0 {
+			afterID = b.events[len(b.events)-1].ID
+		}
+	} else if err := ValidateEventID(afterID); err != nil {
+		b.mu.Unlock()
+		return nil, err
+	}
+	b.mu.Unlock()
+
+	ch := make(chan Event)
+	go func() {
+		defer close(ch)
+		cursor := afterID
+		for {
+			b.mu.Lock()
+			pending := b.after(cursor)
+			wake := b.notify
+			b.mu.Unlock()
+
+			for _, event := range pending {
+				select {
+				case ch <- event:
+					cursor = event.ID
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-wake:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// after returns a copy of the stored events after id; callers hold mu
+func (b *MemoryBus) after(id string) []Event {
+	var events []Event
+	for _, event := range b.events {
+		if CompareEventIDs(event.ID, id) > 0 {
+			events = append(events, event)
+		}
+	}
+	return events
+}
@@ -0,0 +1,120 @@
+package events
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Subscription selects which events a client receives. Each non-empty list
+// narrows delivery: an event must match one post ID, one user ID (as the
+// user who triggered it or its recipient) and one type. Events addressed to
+// the subscriber skip the post and user lists, so narrowing to some posts
+// does not hide the subscriber's own notifications. An empty subscription
+// matches every event.
+type Subscription struct {
+	PostIDs []uint      `json:"post_ids,omitempty"`
+	UserIDs []uint      `json:"user_ids,omitempty"`
+	Types   []EventType `json:"types,omitempty"`
+}
+
+// Validate rejects event types clients cannot subscribe to
+func (s Subscription) Validate() error {
+	for _, t := range s.Types {
+		if !t.IsBroadcast() {
+			return fmt.Errorf("unknown event type %q", t)
+		}
+	}
+	return nil
+}
+
+// IsBroadcast reports whether events of this type are published on the bus
+func (t EventType) IsBroadcast() bool {
+	switch t {
+	case EventTypeNewPost, EventTypePostLiked, EventTypePostCommented, EventTypePostDeleted, EventTypePostUpdated,
+		EventTypeCommentUpdated, EventTypeCommentDeleted, EventTypeCommentLiked, EventTypeUserFollowed, EventTypeUserMentioned, EventTypeNotification:
+		return true
+	default:
+		return false
+	}
+}
+
+// filter is the set form of a Subscription
+type filter struct {
+	postIDs map[uint]struct{}
+	userIDs map[uint]struct{}
+	types   map[EventType]struct{}
+}
+
+func newFilter() filter {
+	return filter{
+		postIDs: make(map[uint]struct{}),
+		userIDs: make(map[uint]struct{}),
+		types:   make(map[EventType]struct{}),
+	}
+}
+
+func (f filter) add(s Subscription) {
+	for _, id := range s.PostIDs {
+		f.postIDs[id] = struct{}{}
+	}
+	for _, id := range s.UserIDs {
+		f.userIDs[id] = struct{}{}
+	}
+	for _, t := range s.Types {
+		f.types[t] = struct{}{}
+	}
+}
+
+func (f filter) remove(s Subscription) {
+	for _, id := range s.PostIDs {
+		delete(f.postIDs, id)
+	}
+	for _, id := range s.UserIDs {
+		delete(f.userIDs, id)
+	}
+	for _, t := range s.Types {
+		delete(f.types, t)
+	}
+}
+
+// matches reports whether event passes the filter of userID's client
+func (f filter) matches(event *Event, userID uint) bool {
+	if len(f.types) > 0 {
+		if _, ok := f.types[event.Type]; !ok {
+			return false
+		}
+	}
+	if event.RecipientUserID != 0 && event.RecipientUserID == userID {
+		return true
+	}
+	if len(f.postIDs) > 0 {
+		if _, ok := f.postIDs[event.PostID]; !ok {
+			return false
+		}
+	}
+	if len(f.userIDs) > 0 {
+		_, triggered := f.userIDs[event.TriggeredByUserID]
+		_, received := f.userIDs[event.RecipientUserID]
+		if !triggered && !received {
+			return false
+		}
+	}
+	return true
+}
+
+func (f filter) subscription() Subscription {
+	var s Subscription
+	for id := range f.postIDs {
+		s.PostIDs = append(s.PostIDs, id)
+	}
+	for id := range f.userIDs {
+		s.UserIDs = append(s.UserIDs, id)
+	}
+	for t := range f.types {
+		s.Types = append(s.Types, t)
+	}
+	sort.Slice(s.PostIDs, func(i, j int) bool { return s.PostIDs[i] < s.PostIDs[j] })
+	sort.Slice(s.UserIDs, func(i, j int) bool { return s.UserIDs[i] < s.UserIDs[j] })
+	sort.Slice(s.Types, func(i, j int) bool { return s.Types[i] < s.Types[j] })
+	return s
+}
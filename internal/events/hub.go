@@ -0,0 +1,273 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Transports that register clients with the hub
+const (
+	TransportWebSocket = "websocket"
+	TransportSSE       = "sse"
+)
+
+// hubReplayBatch is how many missed events are read from the bus at a time
+const hubReplayBatch = 500
+
+// Hub holds the process's single event bus subscription and fans events out
+// to connected clients. Each client has a bounded buffer; a client whose
+// buffer is full is dropped rather than slowing down everyone else.
+type Hub struct {
+	bus        EventBus
+	bufferSize int
+	logger     *zap.Logger
+
+	mu      sync.RWMutex
+	clients map[*Client]struct{}
+	dropped atomic.Int64
+}
+
+// HubStats is a snapshot of the hub's connections
+type HubStats struct {
+	Connections int            `json:"connections"`
+	ByTransport map[string]int `json:"by_transport"`
+	Dropped     int64          `json:"dropped"`
+}
+
+// Client is a connection registered with the hub
+type Client struct {
+	UserID    uint
+	Transport string
+
+	events    chan Event
+	done      chan struct{}
+	closeOnce sync.Once
+	// lastID is the newest event handed to the connection, see Advance
+	lastID string
+
+	mu     sync.RWMutex
+	filter filter
+}
+
+// NewHub creates a hub giving each client a buffer of bufferSize events
+func NewHub(bus EventBus, bufferSize int, logger *zap.Logger) *Hub {
+	return &Hub{
+		bus:        bus,
+		bufferSize: bufferSize,
+		logger:     logger,
+		clients:    make(map[*Client]struct{}),
+	}
+}
+
+// Run subscribes to the bus and dispatches events until ctx is done, then
+// disconnects every client
+func (h *Hub) Run(ctx context.Context) {
+	defer h.closeAll()
+
+	var lastID string
+	for ctx.Err() == nil {
+		eventCh, err := h.bus.Subscribe(ctx, lastID)
+		if err != nil {
+			h.logger.Error("hub failed to subscribe to event bus", zap.Error(err))
+			select {
+			case <-time.After(time.Second):
+			case <-ctx.Done():
+			}
+			continue
+		}
+
+		for event := range eventCh {
+			h.dispatch(event)
+			lastID = event.ID
+		}
+	}
+}
+
+// Register adds a client for userID. When since is set, it also returns the
+// events after since that are still on the bus and visible to the user; the
+// same events may then arrive live, and Advance filters them out.
+//
+// The replay is capped at the client's buffer size. When more events were
+// missed, the backlog is a single resync event carrying the ID of the newest
+// event on the bus instead, telling the client to reload its state.
+func (h *Hub) Register(ctx context.Context, userID uint, transport, since string) (*Client, []Event, error) {
+	if since != "" {
+		if err := ValidateEventID(since); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	client := &Client{
+		UserID:    userID,
+		Transport: transport,
+		events:    make(chan Event, h.bufferSize),
+		done:      make(chan struct{}),
+		lastID:    since,
+		filter:    newFilter(),
+	}
+
+	// Register before reading the backlog so nothing falls in between
+	h.mu.Lock()
+	h.clients[client] = struct{}{}
+	h.mu.Unlock()
+
+	if since == "" {
+		return client, nil, nil
+	}
+
+	var backlog []Event
+	overflow := false
+	cursor := since
+	for {
+		batch, err := h.bus.Since(ctx, cursor, hubReplayBatch)
+		if err != nil {
+			h.Unregister(client)
+			return nil, nil, err
+		}
+		// Past the cap, keep reading only to find the newest event
+		for _, event := range batch {
+			if !overflow && event.IsVisibleTo(userID) {
+				backlog = append(backlog, event)
+				overflow = len(backlog) > h.bufferSize
+			}
+		}
+		if len(batch) > 0 {
+			cursor = batch[len(batch)-1].ID
+		}
+		if len(batch) < hubReplayBatch {
+			break
+		}
+	}
+
+	if overflow {
+		h.logger.Info("event replay too long, sending resync",
+			zap.Uint("user_id", userID),
+			zap.String("transport", transport),
+			zap.String("since", since))
+		return client, []Event{NewResyncEvent(userID, cursor)}, nil
+	}
+	return client, backlog, nil
+}
+
+// Unregister removes a client; it is safe to call more than once
+func (h *Hub) Unregister(client *Client) {
+	h.mu.Lock()
+	delete(h.clients, client)
+	h.mu.Unlock()
+	client.close()
+}
+
+// Stats returns the current connection counts
+func (h *Hub) Stats() HubStats {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	stats := HubStats{
+		Connections: len(h.clients),
+		ByTransport: map[string]int{TransportWebSocket: 0, TransportSSE: 0},
+		Dropped:     h.dropped.Load(),
+	}
+	for client := range h.clients {
+		stats.ByTransport[client.Transport]++
+	}
+	return stats
+}
+
+// dispatch queues event for every client allowed to see it and drops the
+// clients that have fallen a full buffer behind
+func (h *Hub) dispatch(event Event) {
+	var slow []*Client
+
+	h.mu.RLock()
+	for client := range h.clients {
+		if !event.IsVisibleTo(client.UserID) || !client.Wants(&event) {
+			continue
+		}
+		select {
+		case client.events <- event:
+		default:
+			slow = append(slow, client)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, client := range slow {
+		h.logger.Warn("dropping slow event client",
+			zap.Uint("user_id", client.UserID),
+			zap.String("transport", client.Transport),
+			zap.Int("buffer_size", h.bufferSize))
+		h.dropped.Add(1)
+		h.Unregister(client)
+	}
+}
+
+func (h *Hub) closeAll() {
+	h.mu.Lock()
+	clients := h.clients
+	h.clients = make(map[*Client]struct{})
+	h.mu.Unlock()
+
+	for client := range clients {
+		client.close()
+	}
+}
+
+// Events delivers live events for the client
+func (c *Client) Events() <-chan Event {
+	return c.events
+}
+
+// Done is closed when the client is unregistered, including when the hub
+// drops it for falling behind
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Advance records that the event with id is being sent and reports whether
+// it is newer than everything sent before. Backlog and live events must both
+// go through it so events replayed by Register are not sent twice. It is
+// meant to be called from the connection's single writer goroutine.
+func (c *Client) Advance(id string) bool {
+	if c.lastID != "" && CompareEventIDs(id, c.lastID) <= 0 {
+		return false
+	}
+	c.lastID = id
+	return true
+}
+
+// Subscribe narrows the events delivered to the client, see Subscription
+func (c *Client) Subscribe(s Subscription) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.filter.add(s)
+}
+
+// Unsubscribe removes post IDs, user IDs and types from the client's
+// subscription. Once every list is empty the client receives all events again.
+func (c *Client) Unsubscribe(s Subscription) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.filter.remove(s)
+}
+
+// Subscriptions returns the client's current subscription
+func (c *Client) Subscriptions() Subscription {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.filter.subscription()
+}
+
+// Wants reports whether event matches the client's subscription
+func (c *Client) Wants(event *Event) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.filter.matches(event, c.UserID)
+}
+
+func (c *Client) close() {
+	c.closeOnce.Do(func() { close(c.done) })
+}
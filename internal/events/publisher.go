@@ -2,51 +2,33 @@ package events
 
 import (
 	"context"
-	"encoding/json"
 	"time"
 
-	"github.com/rodolfodpk/instagrano/internal/cache"
 	"go.uber.org/zap"
 )
 
-const (
-	// EventChannel is the channel name for broadcasting events
-	EventChannel = "instagrano:events"
-)
-
-// Publisher handles publishing events to Redis pub/sub
+// Publisher appends events to the event bus
 type Publisher struct {
-	cache  cache.Cache
+	bus    EventBus
 	logger *zap.Logger
 }
 
 // NewPublisher creates a new event publisher
-func NewPublisher(cache cache.Cache, logger *zap.Logger) *Publisher {
+func NewPublisher(bus EventBus, logger *zap.Logger) *Publisher {
 	return &Publisher{
-		cache:  cache,
+		bus:    bus,
 		logger: logger,
 	}
 }
 
-// Publish sends an event to the Redis pub/sub channel
+// Publish appends an event to the event bus
 func (p *Publisher) Publish(ctx context.Context, event Event) error {
 	// Set timestamp if not already set
 	if event.Timestamp == 0 {
 		event.Timestamp = time.Now().Unix()
 	}
 
-	// Marshal event to JSON
-	eventJSON, err := json.Marshal(event)
-	if err != nil {
-		p.logger.Error("failed to marshal event",
-			zap.Error(err),
-			zap.String("event_type", string(event.Type)),
-			zap.Uint("post_id", event.PostID))
-		return err
-	}
-
-	// Publish to Redis channel
-	err = p.cache.Publish(ctx, EventChannel, string(eventJSON))
+	event, err := p.bus.Append(ctx, event)
 	if err != nil {
 		p.logger.Error("failed to publish event",
 			zap.Error(err),
@@ -56,6 +38,7 @@ func (p *Publisher) Publish(ctx context.Context, event Event) error {
 	}
 
 	p.logger.Info("event published successfully",
+		zap.String("event_id", event.ID),
 		zap.String("event_type", string(event.Type)),
 		zap.Uint("post_id", event.PostID),
 		zap.Uint("triggered_by_user_id", event.TriggeredByUserID))
@@ -65,44 +48,25 @@ func (p *Publisher) Publish(ctx context.Context, event Event) error {
 
 // PublishNewPost publishes a new post event
 func (p *Publisher) PublishNewPost(ctx context.Context, postID uint, triggeredByUserID uint, post interface{}) error {
-	event := Event{
-		Type:              EventTypeNewPost,
-		PostID:            postID,
-		TriggeredByUserID: triggeredByUserID,
-		Data:              NewPostData{Post: post},
-	}
-	return p.Publish(ctx, event)
+	return p.Publish(ctx, NewPostEvent(postID, triggeredByUserID, post))
 }
 
 // PublishPostLiked publishes a post liked event
 func (p *Publisher) PublishPostLiked(ctx context.Context, postID uint, triggeredByUserID uint, likesCount, commentsCount int) error {
-	event := Event{
-		Type:              EventTypePostLiked,
-		PostID:            postID,
-		TriggeredByUserID: triggeredByUserID,
-		Data:              PostInteractionData{LikesCount: likesCount, CommentsCount: commentsCount},
-	}
-	return p.Publish(ctx, event)
+	return p.Publish(ctx, NewPostLikedEvent(postID, triggeredByUserID, likesCount, commentsCount))
 }
 
 // PublishPostCommented publishes a post commented event with full comment data
 func (p *Publisher) PublishPostCommented(ctx context.Context, postID uint, triggeredByUserID uint, likesCount, commentsCount int, comment *Comment) error {
-	event := Event{
-		Type:              EventTypePostCommented,
-		PostID:            postID,
-		TriggeredByUserID: triggeredByUserID,
-		Data:              PostInteractionData{LikesCount: likesCount, CommentsCount: commentsCount, Comment: comment},
-	}
-	return p.Publish(ctx, event)
+	return p.Publish(ctx, NewPostCommentedEvent(postID, triggeredByUserID, likesCount, commentsCount, comment))
 }
 
 // PublishPostDeleted publishes a post deleted event
 func (p *Publisher) PublishPostDeleted(ctx context.Context, postID uint, triggeredByUserID uint) error {
-	event := Event{
-		Type:              EventTypePostDeleted,
-		PostID:            postID,
-		TriggeredByUserID: triggeredByUserID,
-		Data:              nil, // No additional data needed for deletion
-	}
-	return p.Publish(ctx, event)
+	return p.Publish(ctx, NewPostDeletedEvent(postID, triggeredByUserID))
+}
+
+// PublishUserFollowed publishes a user followed event addressed to the followee
+func (p *Publisher) PublishUserFollowed(ctx context.Context, followerID, followeeID uint, followerUsername string, followersCount int) error {
+	return p.Publish(ctx, NewUserFollowedEvent(followerID, followeeID, followerUsername, followersCount))
 }
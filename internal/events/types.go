@@ -1,27 +1,196 @@
 package events
 
+import "time"
+
 // EventType represents the type of event that occurred
 type EventType string
 
 const (
-	EventTypeNewPost       EventType = "new_post"
-	EventTypePostLiked     EventType = "post_liked"
-	EventTypePostCommented EventType = "post_commented"
-	EventTypePostDeleted   EventType = "post_deleted"
-	EventTypeConnected     EventType = "connected"
-	EventTypeHeartbeat     EventType = "heartbeat"
+	EventTypeNewPost        EventType = "new_post"
+	EventTypePostLiked      EventType = "post_liked"
+	EventTypePostCommented  EventType = "post_commented"
+	EventTypePostDeleted    EventType = "post_deleted"
+	EventTypePostUpdated    EventType = "post_updated"
+	EventTypeCommentUpdated EventType = "comment_updated"
+	EventTypeCommentDeleted EventType = "comment_deleted"
+	EventTypeCommentLiked   EventType = "comment_liked"
+	EventTypeUserFollowed   EventType = "user_followed"
+	EventTypeUserMentioned  EventType = "user_mentioned"
+	EventTypeNotification   EventType = "notification"
+	EventTypeConnected      EventType = "connected"
+	EventTypeHeartbeat      EventType = "heartbeat"
+	EventTypeResync         EventType = "resync"
 )
 
-// Event represents a real-time event that can be broadcast to clients
+// Event represents a real-time event that can be broadcast to clients.
+// When RecipientUserID is set the event is private to that user.
+// ID is assigned on publish and increases with every event.
 type Event struct {
+	ID                string      `json:"id,omitempty"`
 	Type              EventType   `json:"type"`
 	PostID            uint        `json:"post_id"`
 	TriggeredByUserID uint        `json:"triggered_by_user_id"`
+	RecipientUserID   uint        `json:"recipient_user_id,omitempty"`
 	Data              interface{} `json:"data"`
 	Timestamp         int64       `json:"timestamp"`
 }
 
-// NewPostData contains the post information for new_post events
+// IsVisibleTo reports whether the event may be delivered to the given user
+func (e *Event) IsVisibleTo(userID uint) bool {
+	return e.RecipientUserID == 0 || e.RecipientUserID == userID
+}
+
+// NewPostEvent builds a new_post event
+func NewPostEvent(postID, triggeredByUserID uint, post interface{}) Event {
+	return Event{
+		Type:              EventTypeNewPost,
+		PostID:            postID,
+		TriggeredByUserID: triggeredByUserID,
+		Data:              NewPostData{Post: post},
+	}
+}
+
+// NewPostLikedEvent builds a post_liked event, also used when a like is removed
+func NewPostLikedEvent(postID, triggeredByUserID uint, likesCount, commentsCount int) Event {
+	return Event{
+		Type:              EventTypePostLiked,
+		PostID:            postID,
+		TriggeredByUserID: triggeredByUserID,
+		Data:              PostInteractionData{LikesCount: likesCount, CommentsCount: commentsCount},
+	}
+}
+
+// NewPostReactedEvent builds a post_liked event for a change to a reaction of
+// any type. reaction is the user's reaction after the change and previous the
+// one it replaced; either is empty when there is none. An empty reaction
+// marks the event as a removal.
+func NewPostReactedEvent(postID, triggeredByUserID uint, reaction, previous string, likesCount, commentsCount int, reactionCounts map[string]int) Event {
+	return Event{
+		Type:              EventTypePostLiked,
+		PostID:            postID,
+		TriggeredByUserID: triggeredByUserID,
+		Data: PostInteractionData{
+			LikesCount:       likesCount,
+			CommentsCount:    commentsCount,
+			Reaction:         reaction,
+			PreviousReaction: previous,
+			Removed:          reaction == "",
+			ReactionCounts:   reactionCounts,
+		},
+	}
+}
+
+// NewPostCommentedEvent builds a post_commented event with full comment data
+func NewPostCommentedEvent(postID, triggeredByUserID uint, likesCount, commentsCount int, comment *Comment) Event {
+	return Event{
+		Type:              EventTypePostCommented,
+		PostID:            postID,
+		TriggeredByUserID: triggeredByUserID,
+		Data:              PostInteractionData{LikesCount: likesCount, CommentsCount: commentsCount, Comment: comment},
+	}
+}
+
+// NewCommentUpdatedEvent builds a comment_updated event with the edited comment
+func NewCommentUpdatedEvent(postID, triggeredByUserID uint, likesCount, commentsCount int, comment *Comment) Event {
+	return Event{
+		Type:              EventTypeCommentUpdated,
+		PostID:            postID,
+		TriggeredByUserID: triggeredByUserID,
+		Data:              PostInteractionData{LikesCount: likesCount, CommentsCount: commentsCount, Comment: comment},
+	}
+}
+
+// NewCommentDeletedEvent builds a comment_deleted event. The comment's text is
+// not included; deleting a top-level comment also deletes its replies.
+func NewCommentDeletedEvent(postID, triggeredByUserID uint, likesCount, commentsCount int, comment *Comment) Event {
+	return Event{
+		Type:              EventTypeCommentDeleted,
+		PostID:            postID,
+		TriggeredByUserID: triggeredByUserID,
+		Data:              PostInteractionData{LikesCount: likesCount, CommentsCount: commentsCount, Comment: comment},
+	}
+}
+
+// NewCommentLikedEvent builds a comment_liked event, also used when a like is removed
+func NewCommentLikedEvent(postID, commentID, triggeredByUserID uint, likesCount int) Event {
+	return Event{
+		Type:              EventTypeCommentLiked,
+		PostID:            postID,
+		TriggeredByUserID: triggeredByUserID,
+		Data:              CommentLikedData{CommentID: commentID, LikesCount: likesCount},
+	}
+}
+
+// NewPostUpdatedEvent builds a post_updated event with the edited post
+func NewPostUpdatedEvent(postID, triggeredByUserID uint, post interface{}) Event {
+	return Event{
+		Type:              EventTypePostUpdated,
+		PostID:            postID,
+		TriggeredByUserID: triggeredByUserID,
+		Data:              NewPostData{Post: post},
+	}
+}
+
+// NewPostDeletedEvent builds a post_deleted event
+func NewPostDeletedEvent(postID, triggeredByUserID uint) Event {
+	return Event{
+		Type:              EventTypePostDeleted,
+		PostID:            postID,
+		TriggeredByUserID: triggeredByUserID,
+		Data:              nil, // No additional data needed for deletion
+	}
+}
+
+// NewUserFollowedEvent builds a user_followed event addressed to the followee
+func NewUserFollowedEvent(followerID, followeeID uint, followerUsername string, followersCount int) Event {
+	return Event{
+		Type:              EventTypeUserFollowed,
+		TriggeredByUserID: followerID,
+		RecipientUserID:   followeeID,
+		Data: UserFollowedData{
+			FollowerID:       followerID,
+			FollowerUsername: followerUsername,
+			FollowersCount:   followersCount,
+		},
+	}
+}
+
+// NewUserMentionedEvent builds a user_mentioned event addressed to the
+// mentioned user. commentID is nil for a mention in the post's caption.
+func NewUserMentionedEvent(postID uint, commentID *uint, mentionedByID, mentionedID uint, text string) Event {
+	return Event{
+		Type:              EventTypeUserMentioned,
+		PostID:            postID,
+		TriggeredByUserID: mentionedByID,
+		RecipientUserID:   mentionedID,
+		Data:              UserMentionedData{CommentID: commentID, Text: text},
+	}
+}
+
+// NewNotificationEvent builds a notification event addressed to its recipient
+func NewNotificationEvent(recipientID, postID, actorID uint, data NotificationData) Event {
+	return Event{
+		Type:              EventTypeNotification,
+		PostID:            postID,
+		TriggeredByUserID: actorID,
+		RecipientUserID:   recipientID,
+		Data:              data,
+	}
+}
+
+// NewResyncEvent builds the resync event sent to a reconnecting client that
+// missed too many events to replay. It takes the ID of the newest missed
+// event, so resuming from it skips them.
+func NewResyncEvent(recipientID uint, id string) Event {
+	return Event{
+		ID:              id,
+		Type:            EventTypeResync,
+		RecipientUserID: recipientID,
+		Timestamp:       time.Now().Unix(),
+	}
+}
+
+// NewPostData contains the post information for new_post and post_updated events
 type NewPostData struct {
 	Post interface{} `json:"post"`
 }
@@ -30,14 +199,50 @@ type NewPostData struct {
 type PostInteractionData struct {
 	LikesCount    int      `json:"likes_count"`
 	CommentsCount int      `json:"comments_count"`
-	Comment       *Comment `json:"comment,omitempty"` // Include for post_commented events
+	Comment       *Comment `json:"comment,omitempty"` // Include for post_commented and comment_* events
+
+	// Included for post_liked events about reactions
+	Reaction         string         `json:"reaction,omitempty"`
+	PreviousReaction string         `json:"previous_reaction,omitempty"`
+	Removed          bool           `json:"removed,omitempty"` // The user's reaction was removed
+	ReactionCounts   map[string]int `json:"reaction_counts,omitempty"`
+}
+
+// CommentLikedData contains the comment's like count for comment_liked events
+type CommentLikedData struct {
+	CommentID  uint `json:"comment_id"`
+	LikesCount int  `json:"likes_count"`
+}
+
+// UserFollowedData contains the follower information for user_followed events
+type UserFollowedData struct {
+	FollowerID       uint   `json:"follower_id"`
+	FollowerUsername string `json:"follower_username"`
+	FollowersCount   int    `json:"followers_count"`
+}
+
+// UserMentionedData contains the caption or comment a user was mentioned in
+// for user_mentioned events
+type UserMentionedData struct {
+	CommentID *uint  `json:"comment_id,omitempty"` // Set for mentions in comments
+	Text      string `json:"text"`
+}
+
+// NotificationData contains a new or updated notification and the
+// recipient's unread count for notification events
+type NotificationData struct {
+	Notification interface{} `json:"notification"`
+	Message      string      `json:"message"`
+	UnreadCount  int         `json:"unread_count"`
 }
 
 // Comment represents a comment in events
 type Comment struct {
 	ID        uint   `json:"id"`
+	ParentID  *uint  `json:"parent_id,omitempty"` // Set for replies
 	Text      string `json:"text"`
 	Username  string `json:"username"`
 	UserID    uint   `json:"user_id"`
 	CreatedAt string `json:"created_at"`
+	EditedAt  string `json:"edited_at,omitempty"`
 }
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/rodolfodpk/instagrano/internal/dto"
 	"github.com/rodolfodpk/instagrano/internal/service"
@@ -36,12 +38,7 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 	}
 
 	response := dto.AuthResponse{
-		User: dto.UserResponse{
-			ID:        user.ID,
-			Username:  user.Username,
-			Email:     user.Email,
-			CreatedAt: user.CreatedAt,
-		},
+		User:  dto.ToUserResponse(user),
 		Token: "", // No token on registration
 	}
 	return c.JSON(response)
@@ -54,7 +51,7 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 // @Accept       json
 // @Produce      json
 // @Param        request body object{email=string,password=string} true "Login credentials"
-// @Success      200  {object}  object{user=domain.User,token=string}
+// @Success      200  {object}  dto.AuthResponse
 // @Failure      401  {object}  object{error=string}
 // @Router       /auth/login [post]
 func (h *AuthHandler) Login(c *fiber.Ctx) error {
@@ -63,23 +60,76 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid request"})
 	}
 
-	user, token, err := h.authService.Login(req.Email, req.Password)
+	user, tokens, err := h.authService.Login(req.Email, req.Password)
 	if err != nil {
 		return c.Status(401).JSON(fiber.Map{"error": "invalid credentials"})
 	}
 
 	response := dto.AuthResponse{
-		User: dto.UserResponse{
-			ID:        user.ID,
-			Username:  user.Username,
-			Email:     user.Email,
-			CreatedAt: user.CreatedAt,
-		},
-		Token: token,
+		User:         dto.ToUserResponse(user),
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresAt:    &tokens.ExpiresAt,
+	}
+	return c.JSON(response)
+}
+
+// Refresh godoc
+// @Summary      Refresh access token
+// @Description  Exchange a refresh token for a new access token and a rotated refresh token
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body object{refresh_token=string} true "Refresh token"
+// @Success      200  {object}  dto.AuthResponse
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Router       /auth/refresh [post]
+func (h *AuthHandler) Refresh(c *fiber.Ctx) error {
+	var req dto.RefreshRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request"})
+	}
+
+	user, tokens, err := h.authService.Refresh(c.Context(), req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRefreshToken) || errors.Is(err, service.ErrRefreshTokenReused) {
+			return c.Status(401).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "failed to refresh token"})
+	}
+
+	response := dto.AuthResponse{
+		User:         dto.ToUserResponse(user),
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresAt:    &tokens.ExpiresAt,
 	}
 	return c.JSON(response)
 }
 
+// Logout godoc
+// @Summary      Logout
+// @Description  Revoke the current access token and every refresh token of its session
+// @Tags         auth
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  object{message=string}
+// @Failure      401  {object}  object{error=string}
+// @Router       /auth/logout [post]
+func (h *AuthHandler) Logout(c *fiber.Ctx) error {
+	claims, ok := c.Locals("tokenClaims").(*service.AccessClaims)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "invalid token"})
+	}
+
+	if err := h.authService.Logout(c.Context(), claims); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to logout"})
+	}
+
+	return c.JSON(fiber.Map{"message": "logged out"})
+}
+
 // GetMe godoc
 // @Summary      Get current user
 // @Description  Get the current authenticated user's information
@@ -98,12 +148,7 @@ func (h *AuthHandler) GetMe(c *fiber.Ctx) error {
 	}
 
 	response := dto.AuthResponse{
-		User: dto.UserResponse{
-			ID:        user.ID,
-			Username:  user.Username,
-			Email:     user.Email,
-			CreatedAt: user.CreatedAt,
-		},
+		User:  dto.ToUserResponse(user),
 		Token: "", // Don't return token for security
 	}
 	return c.JSON(response)
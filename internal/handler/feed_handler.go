@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"errors"
+	"net/url"
 	"strconv"
 	"time"
 
@@ -8,60 +10,184 @@ import (
 	"github.com/rodolfodpk/instagrano/internal/config"
 	"github.com/rodolfodpk/instagrano/internal/domain"
 	"github.com/rodolfodpk/instagrano/internal/dto"
+	"github.com/rodolfodpk/instagrano/internal/pagination"
 	"github.com/rodolfodpk/instagrano/internal/service"
 	"go.uber.org/zap"
 )
 
 type FeedHandler struct {
-	feedService *service.FeedService
-	logger      *zap.Logger
-	config      *config.Config
+	feedService        *service.FeedService
+	timelineService    *service.TimelineService
+	interactionService *service.InteractionService
+	logger             *zap.Logger
+	config             *config.Config
 }
 
-func NewFeedHandler(feedService *service.FeedService, cfg *config.Config) *FeedHandler {
+func NewFeedHandler(feedService *service.FeedService, timelineService *service.TimelineService, interactionService *service.InteractionService, cfg *config.Config) *FeedHandler {
 	logger, _ := zap.NewProduction()
 	return &FeedHandler{
-		feedService: feedService,
-		logger:      logger,
-		config:      cfg,
+		feedService:        feedService,
+		timelineService:    timelineService,
+		interactionService: interactionService,
+		logger:             logger,
+		config:             cfg,
 	}
 }
 
 // GetFeed godoc
 // @Summary      Get user feed
-// @Description  Retrieve paginated feed using cursor-based pagination
+// @Description  Retrieve paginated feed using cursor-based pagination. type=following returns the home timeline of followed accounts.
 // @Tags         feed
 // @Produce      json
 // @Security     BearerAuth
+// @Param        type    query     string  false  "Feed type (global or following, default global)"
+// @Param        rank    query     string  false  "Ranker for the global feed (chronological, decay, gravity, wilson; default from FEED_RANKER)"
 // @Param        cursor  query     string  false  "Pagination cursor"
 // @Param        limit   query     int     false  "Number of posts (default 20, max 100)"
 // @Success      200  {object}  pagination.FeedResult
+// @Failure      400  {object}  object{error=string}
+// @Failure      410  {object}  object{error=string}
 // @Failure      500  {object}  object{error=string}
 // @Router       /feed [get]
 func (h *FeedHandler) GetFeed(c *fiber.Ctx) error {
 	// Always use cursor-based pagination (more efficient)
 	cursor := c.Query("cursor")
-	return h.getFeedWithCursor(c, cursor)
+	switch c.Query("type", "global") {
+	case "global":
+		return h.getFeedWithCursor(c, cursor)
+	case "following":
+		return h.getFollowingFeed(c, cursor)
+	default:
+		return c.Status(400).JSON(fiber.Map{"error": "invalid feed type"})
+	}
 }
 
 func (h *FeedHandler) getFeedWithCursor(c *fiber.Ctx, cursor string) error {
-	limitStr := c.Query("limit", strconv.Itoa(h.config.DefaultPageSize))
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 || limit > h.config.MaxPageSize {
-		limit = h.config.DefaultPageSize
-	}
+	limit := h.parseLimit(c)
 
 	h.logger.Info("getting feed with cursor",
 		zap.String("cursor", cursor),
 		zap.Int("limit", limit),
 	)
 
-	result, err := h.feedService.GetFeedWithCursor(limit, cursor)
+	result, err := h.feedService.GetRankedFeed(limit, cursor, c.Query("rank"))
 	if err != nil {
+		if errors.Is(err, service.ErrUnknownRanker) {
+			return c.Status(400).JSON(fiber.Map{"error": "unknown ranker"})
+		}
+		if errors.Is(err, service.ErrInvalidCursor) {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid cursor"})
+		}
+		if errors.Is(err, service.ErrCursorExpired) {
+			return c.Status(410).JSON(fiber.Map{"error": "cursor expired, restart from the first page"})
+		}
 		h.logger.Error("failed to get feed with cursor", zap.Error(err))
 		return c.Status(500).JSON(fiber.Map{"error": "failed to get feed"})
 	}
 
+	return h.writeFeed(c, result)
+}
+
+func (h *FeedHandler) getFollowingFeed(c *fiber.Ctx, cursor string) error {
+	userID := c.Locals("userID").(uint)
+	limit := h.parseLimit(c)
+
+	result, err := h.timelineService.GetFeed(userID, limit, cursor)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid cursor"})
+		}
+		h.logger.Error("failed to get following feed", zap.Error(err), zap.Uint("user_id", userID))
+		return c.Status(500).JSON(fiber.Map{"error": "failed to get feed"})
+	}
+
+	return h.writeFeed(c, result)
+}
+
+// GetUserPosts godoc
+// @Summary      List a user's posts
+// @Description  Retrieve the posts of a user for their profile, newest first, using cursor-based pagination
+// @Tags         feed
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id      path      int     true   "User ID"
+// @Param        cursor  query     string  false  "Pagination cursor"
+// @Param        limit   query     int     false  "Number of posts (default 20, max 100)"
+// @Success      200  {object}  dto.FeedResponse
+// @Failure      400  {object}  object{error=string}
+// @Failure      500  {object}  object{error=string}
+// @Router       /users/{id}/posts [get]
+func (h *FeedHandler) GetUserPosts(c *fiber.Ctx) error {
+	userID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid user id"})
+	}
+
+	result, err := h.feedService.GetUserPosts(uint(userID), h.parseLimit(c), c.Query("cursor"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid cursor"})
+		}
+		h.logger.Error("failed to get user posts", zap.Error(err), zap.Uint64("user_id", userID))
+		return c.Status(500).JSON(fiber.Map{"error": "failed to get posts"})
+	}
+
+	return h.writeFeed(c, result)
+}
+
+// GetHashtagPosts godoc
+// @Summary      List posts with a hashtag
+// @Description  Retrieve the posts whose caption has a hashtag, ranked like the global feed, using cursor-based pagination
+// @Tags         feed
+// @Produce      json
+// @Security     BearerAuth
+// @Param        tag     path      string  true   "Hashtag, with or without the #"
+// @Param        rank    query     string  false  "Ranker (chronological, decay, gravity, wilson; default from FEED_RANKER)"
+// @Param        cursor  query     string  false  "Pagination cursor"
+// @Param        limit   query     int     false  "Number of posts (default 20, max 100)"
+// @Success      200  {object}  dto.FeedResponse
+// @Failure      400  {object}  object{error=string}
+// @Failure      410  {object}  object{error=string}
+// @Failure      500  {object}  object{error=string}
+// @Router       /hashtags/{tag}/posts [get]
+func (h *FeedHandler) GetHashtagPosts(c *fiber.Ctx) error {
+	// Tags may be percent-encoded, including a leading # as %23
+	tag, err := url.PathUnescape(c.Params("tag"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid hashtag"})
+	}
+
+	result, err := h.feedService.GetHashtagFeed(tag, h.parseLimit(c), c.Query("cursor"), c.Query("rank"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidHashtag) {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid hashtag"})
+		}
+		if errors.Is(err, service.ErrUnknownRanker) {
+			return c.Status(400).JSON(fiber.Map{"error": "unknown ranker"})
+		}
+		if errors.Is(err, service.ErrInvalidCursor) {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid cursor"})
+		}
+		if errors.Is(err, service.ErrCursorExpired) {
+			return c.Status(410).JSON(fiber.Map{"error": "cursor expired, restart from the first page"})
+		}
+		h.logger.Error("failed to get hashtag posts", zap.Error(err), zap.String("tag", tag))
+		return c.Status(500).JSON(fiber.Map{"error": "failed to get posts"})
+	}
+
+	return h.writeFeed(c, result)
+}
+
+func (h *FeedHandler) parseLimit(c *fiber.Ctx) int {
+	limitStr := c.Query("limit", strconv.Itoa(h.config.DefaultPageSize))
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit <= 0 || limit > h.config.MaxPageSize {
+		limit = h.config.DefaultPageSize
+	}
+	return limit
+}
+
+func (h *FeedHandler) writeFeed(c *fiber.Ctx, result *pagination.FeedResult) error {
 	h.logger.Info("feed retrieved successfully",
 		zap.Int("posts_count", len(result.Posts)),
 		zap.Bool("has_more", result.HasMore),
@@ -87,6 +213,13 @@ func (h *FeedHandler) getFeedWithCursor(c *fiber.Ctx, cursor string) error {
 			return c.Status(500).JSON(fiber.Map{"error": "invalid post data"})
 		}
 	}
+
+	// Feed pages are cached for everyone, so the caller's state is added afterwards
+	userID, _ := c.Locals("userID").(uint)
+	if err := setViewerStates(h.interactionService, userID, response.Posts); err != nil {
+		h.logger.Error("failed to get the caller's state", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "failed to get feed"})
+	}
 	return c.JSON(response)
 }
 
@@ -115,9 +248,58 @@ func convertMapToPost(postMap map[string]interface{}) *domain.Post {
 	if mediaURL, ok := postMap["media_url"].(string); ok {
 		post.MediaURL = mediaURL
 	}
+	if media, ok := postMap["media"].([]interface{}); ok {
+		for _, item := range media {
+			itemMap, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			var postMedia domain.PostMedia
+			if position, ok := itemMap["position"].(float64); ok {
+				postMedia.Position = int(position)
+			}
+			if mediaType, ok := itemMap["media_type"].(string); ok {
+				postMedia.MediaType = domain.MediaType(mediaType)
+			}
+			if mediaURL, ok := itemMap["media_url"].(string); ok {
+				postMedia.MediaURL = mediaURL
+			}
+			post.Media = append(post.Media, postMedia)
+		}
+	}
+	if mentions, ok := postMap["mentions"].([]interface{}); ok {
+		for _, item := range mentions {
+			itemMap, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			var mention domain.Mention
+			if userID, ok := itemMap["user_id"].(float64); ok {
+				mention.UserID = uint(userID)
+			}
+			if username, ok := itemMap["username"].(string); ok {
+				mention.Username = username
+			}
+			if offset, ok := itemMap["offset"].(float64); ok {
+				mention.Offset = int(offset)
+			}
+			if length, ok := itemMap["length"].(float64); ok {
+				mention.Length = int(length)
+			}
+			post.Mentions = append(post.Mentions, mention)
+		}
+	}
 	if likesCount, ok := postMap["likes_count"].(float64); ok {
 		post.LikesCount = int(likesCount)
 	}
+	if reactionCounts, ok := postMap["reaction_counts"].(map[string]interface{}); ok {
+		post.ReactionCounts = make(domain.ReactionCounts, len(reactionCounts))
+		for reaction, count := range reactionCounts {
+			if n, ok := count.(float64); ok {
+				post.ReactionCounts[reaction] = int(n)
+			}
+		}
+	}
 	if commentsCount, ok := postMap["comments_count"].(float64); ok {
 		post.CommentsCount = int(commentsCount)
 	}
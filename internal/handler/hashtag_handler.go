@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rodolfodpk/instagrano/internal/dto"
+	"github.com/rodolfodpk/instagrano/internal/service"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultTrendingLimit = 10
+	maxTrendingLimit     = 50
+)
+
+type HashtagHandler struct {
+	hashtagService *service.HashtagService
+	logger         *zap.Logger
+}
+
+func NewHashtagHandler(hashtagService *service.HashtagService, logger *zap.Logger) *HashtagHandler {
+	return &HashtagHandler{
+		hashtagService: hashtagService,
+		logger:         logger,
+	}
+}
+
+// GetTrending godoc
+// @Summary      List trending hashtags
+// @Description  Retrieve the hashtags used in the most new or edited posts within a sliding window
+// @Tags         hashtags
+// @Produce      json
+// @Security     BearerAuth
+// @Param        window  query     string  false  "Window (1h, 24h or 7d, default 24h)"
+// @Param        limit   query     int     false  "Number of hashtags (default 10, max 50)"
+// @Success      200  {object}  dto.TrendingHashtagsResponse
+// @Failure      400  {object}  object{error=string}
+// @Failure      500  {object}  object{error=string}
+// @Router       /hashtags/trending [get]
+func (h *HashtagHandler) GetTrending(c *fiber.Ctx) error {
+	window := c.Query("window", service.TrendingDay)
+	limit, err := strconv.Atoi(c.Query("limit", strconv.Itoa(defaultTrendingLimit)))
+	if err != nil || limit <= 0 || limit > maxTrendingLimit {
+		limit = defaultTrendingLimit
+	}
+
+	hashtags, err := h.hashtagService.GetTrending(window, limit)
+	if err != nil {
+		if errors.Is(err, service.ErrUnknownTrendingWindow) {
+			return c.Status(400).JSON(fiber.Map{"error": "unknown trending window"})
+		}
+		h.logger.Error("failed to get trending hashtags", zap.Error(err), zap.String("window", window))
+		return c.Status(500).JSON(fiber.Map{"error": "failed to get trending hashtags"})
+	}
+
+	return c.JSON(dto.TrendingHashtagsResponse{
+		Window:   window,
+		Hashtags: hashtags,
+	})
+}
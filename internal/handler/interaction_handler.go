@@ -1,32 +1,33 @@
 package handler
 
 import (
+	"errors"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/rodolfodpk/instagrano/internal/config"
 	"github.com/rodolfodpk/instagrano/internal/dto"
-	"github.com/rodolfodpk/instagrano/internal/events"
 	"github.com/rodolfodpk/instagrano/internal/service"
 	"go.uber.org/zap"
 )
 
 type InteractionHandler struct {
 	interactionService *service.InteractionService
-	eventPublisher     *events.Publisher
+	config             *config.Config
 	logger             *zap.Logger
 }
 
-func NewInteractionHandler(interactionService *service.InteractionService, eventPublisher *events.Publisher, logger *zap.Logger) *InteractionHandler {
+func NewInteractionHandler(interactionService *service.InteractionService, cfg *config.Config, logger *zap.Logger) *InteractionHandler {
 	return &InteractionHandler{
 		interactionService: interactionService,
-		eventPublisher:     eventPublisher,
+		config:             cfg,
 		logger:             logger,
 	}
 }
 
 // LikePost godoc
-// @Summary      Like a post
-// @Description  Add a like to a post
+// @Summary      Toggle a like on a post
+// @Description  Like a post, or remove your like if you already liked it. Retrying flips it back; prefer PUT and DELETE /posts/{id}/like.
 // @Tags         interactions
 // @Produce      json
 // @Security     BearerAuth
@@ -41,43 +42,148 @@ func (h *InteractionHandler) LikePost(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid post id"})
 	}
 
-	likesCount, commentsCount, err := h.interactionService.LikePost(userID, uint(postID))
+	// The post_liked event is published by the outbox relay
+	likesCount, _, err := h.interactionService.LikePost(userID, uint(postID))
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	// Publish post liked event
-	if err := h.eventPublisher.PublishPostLiked(c.Context(), uint(postID), userID, likesCount, commentsCount); err != nil {
-		h.logger.Error("failed to publish post liked event",
-			zap.Error(err),
-			zap.Uint("post_id", uint(postID)),
-			zap.Uint("user_id", userID))
+	return c.JSON(dto.LikeResponse{
+		PostID:     uint(postID),
+		LikesCount: likesCount,
+	})
+}
+
+// PutLike godoc
+// @Summary      Like a post
+// @Description  Like a post. Liking a post you already like changes nothing, so the request is safe to retry.
+// @Tags         interactions
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Post ID"
+// @Success      200  {object}  dto.LikeResponse
+// @Failure      400  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /posts/{id}/like [put]
+func (h *InteractionHandler) PutLike(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+	postID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid post id"})
 	}
 
-	// Get updated post to return like count
-	post, err := h.interactionService.GetPost(uint(postID))
+	likesCount, _, err := h.interactionService.AddLike(userID, uint(postID))
 	if err != nil {
-		h.logger.Error("failed to get updated post", zap.Error(err))
-		return c.Status(500).JSON(fiber.Map{"error": "failed to get updated post"})
+		return h.handleReactionError(c, err)
 	}
 
 	return c.JSON(dto.LikeResponse{
 		PostID:     uint(postID),
-		LikesCount: post.LikesCount,
+		LikesCount: likesCount,
 	})
 }
 
+// DeleteLike godoc
+// @Summary      Unlike a post
+// @Description  Remove your like from a post. Unliking a post you do not like changes nothing, so the request is safe to retry.
+// @Tags         interactions
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Post ID"
+// @Success      200  {object}  dto.LikeResponse
+// @Failure      400  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /posts/{id}/like [delete]
+func (h *InteractionHandler) DeleteLike(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+	postID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid post id"})
+	}
+
+	likesCount, _, err := h.interactionService.RemoveLike(userID, uint(postID))
+	if err != nil {
+		return h.handleReactionError(c, err)
+	}
+
+	return c.JSON(dto.LikeResponse{
+		PostID:     uint(postID),
+		LikesCount: likesCount,
+	})
+}
+
+// PutReaction godoc
+// @Summary      React to a post
+// @Description  Set your reaction on a post (like, heart, laugh, wow, sad or fire), replacing any other reaction you had. Reacting the same way again changes nothing, so the request is safe to retry.
+// @Tags         interactions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                  true  "Post ID"
+// @Param        request  body      dto.ReactionRequest  true  "Reaction type"
+// @Success      200  {object}  dto.ReactionResponse
+// @Failure      400  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /posts/{id}/reaction [put]
+func (h *InteractionHandler) PutReaction(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+	postID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid post id"})
+	}
+
+	var req dto.ReactionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request"})
+	}
+
+	// The post_liked event is published by the outbox relay
+	counts, err := h.interactionService.React(userID, uint(postID), req.Reaction)
+	if err != nil {
+		return h.handleReactionError(c, err)
+	}
+
+	return c.JSON(dto.ToReactionResponse(uint(postID), counts, req.Reaction))
+}
+
+// DeleteReaction godoc
+// @Summary      Remove your reaction from a post
+// @Description  Remove your reaction, of any type, from a post. Removing a reaction you do not have changes nothing, so the request is safe to retry.
+// @Tags         interactions
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Post ID"
+// @Success      200  {object}  dto.ReactionResponse
+// @Failure      400  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /posts/{id}/reaction [delete]
+func (h *InteractionHandler) DeleteReaction(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+	postID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid post id"})
+	}
+
+	counts, err := h.interactionService.RemoveReaction(userID, uint(postID))
+	if err != nil {
+		return h.handleReactionError(c, err)
+	}
+
+	return c.JSON(dto.ToReactionResponse(uint(postID), counts, ""))
+}
+
 // CommentPost godoc
 // @Summary      Comment on a post
-// @Description  Add a comment to a post
+// @Description  Add a comment to a post, or a reply to one of its comments when parent_id is set
 // @Tags         interactions
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
-// @Param        id       path      int                          true  "Post ID"
-// @Param        request  body      object{text=string}          true  "Comment text"
-// @Success      200  {object}  object{message=string}
+// @Param        id       path      int                 true  "Post ID"
+// @Param        request  body      dto.CommentRequest  true  "Comment text and optional parent comment"
+// @Success      200  {object}  dto.CommentResponse
 // @Failure      400  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
 // @Router       /posts/{id}/comment [post]
 func (h *InteractionHandler) CommentPost(c *fiber.Ctx) error {
 	userID := c.Locals("userID").(uint)
@@ -93,8 +199,15 @@ func (h *InteractionHandler) CommentPost(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid request"})
 	}
 
-	// CommentPost now handles publishing the event internally
-	_, _, err = h.interactionService.CommentPost(userID, uint(postID), req.Content, username)
+	// The post_commented event is published by the outbox relay
+	if req.ParentID != nil {
+		_, _, err = h.interactionService.ReplyToComment(userID, uint(postID), *req.ParentID, req.Content, username)
+	} else {
+		_, _, err = h.interactionService.CommentPost(userID, uint(postID), req.Content, username)
+	}
+	if errors.Is(err, service.ErrCommentNotFound) {
+		return c.Status(404).JSON(fiber.Map{"error": "parent comment not found"})
+	}
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -114,25 +227,277 @@ func (h *InteractionHandler) CommentPost(c *fiber.Ctx) error {
 
 // GetComments godoc
 // @Summary      Get comments for a post
-// @Description  Retrieve all comments for a specific post
+// @Description  Retrieve the top-level comments for a specific post with their reply and like counts, using cursor-based pagination
 // @Tags         interactions
 // @Produce      json
 // @Security     BearerAuth
-// @Param        id   path      int  true  "Post ID"
-// @Success      200  {array}   domain.Comment
+// @Param        id      path      int     true   "Post ID"
+// @Param        sort    query     string  false  "Sort order: oldest (default), newest or top"
+// @Param        cursor  query     string  false  "Pagination cursor"
+// @Param        limit   query     int     false  "Number of comments (default 20, max 100)"
+// @Success      200  {object}  dto.CommentListResponse
 // @Failure      400  {object}  object{error=string}
 // @Router       /posts/{id}/comments [get]
 func (h *InteractionHandler) GetComments(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+	postID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid post id"})
+	}
+
+	comments, nextCursor, err := h.interactionService.GetComments(uint(postID), userID, c.Query("sort"), h.parseLimit(c), c.Query("cursor"))
+	if err != nil {
+		return h.handleCommentError(c, err)
+	}
+
+	return c.JSON(dto.ToCommentListResponse(comments, nextCursor))
+}
+
+// GetLikers godoc
+// @Summary      List who liked a post
+// @Description  Retrieve the users who reacted to a post, most recent first, marking those the caller follows, using cursor-based pagination. Hidden from everyone but the author when the author hides like counts.
+// @Tags         interactions
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id      path      int     true   "Post ID"
+// @Param        cursor  query     string  false  "Pagination cursor"
+// @Param        limit   query     int     false  "Number of users (default 20, max 100)"
+// @Success      200  {object}  dto.LikerListResponse
+// @Failure      400  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /posts/{id}/likes [get]
+func (h *InteractionHandler) GetLikers(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
 	postID, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid post id"})
 	}
 
-	comments, err := h.interactionService.GetComments(uint(postID))
+	likers, nextCursor, err := h.interactionService.GetLikers(uint(postID), userID, h.parseLimit(c), c.Query("cursor"))
+	switch {
+	case errors.Is(err, service.ErrPostNotFound):
+		return c.Status(404).JSON(fiber.Map{"error": "post not found"})
+	case errors.Is(err, service.ErrLikesHidden):
+		return c.Status(403).JSON(fiber.Map{"error": "the author has hidden who liked this post"})
+	case errors.Is(err, service.ErrInvalidCursor):
+		return c.Status(400).JSON(fiber.Map{"error": "invalid cursor"})
+	case err != nil:
+		h.logger.Error("failed to list likers", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+	}
+
+	return c.JSON(dto.ToLikerListResponse(likers, nextCursor))
+}
+
+// GetReplies godoc
+// @Summary      Get replies to a comment
+// @Description  Retrieve the replies to a comment, oldest first, using cursor-based pagination
+// @Tags         interactions
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id         path      int     true   "Post ID"
+// @Param        commentId  path      int     true   "Comment ID"
+// @Param        cursor     query     string  false  "Pagination cursor"
+// @Param        limit      query     int     false  "Number of replies (default 20, max 100)"
+// @Success      200  {object}  dto.CommentListResponse
+// @Failure      400  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /posts/{id}/comments/{commentId}/replies [get]
+func (h *InteractionHandler) GetReplies(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+	postID, commentID, err := parseCommentParams(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	replies, nextCursor, err := h.interactionService.GetReplies(postID, commentID, userID, h.parseLimit(c), c.Query("cursor"))
+	if err != nil {
+		return h.handleCommentError(c, err)
+	}
+
+	return c.JSON(dto.ToCommentListResponse(replies, nextCursor))
+}
+
+// UpdateComment godoc
+// @Summary      Edit a comment
+// @Description  Replace the text of your comment; the comment is marked edited
+// @Tags         interactions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id         path      int                       true  "Post ID"
+// @Param        commentId  path      int                       true  "Comment ID"
+// @Param        request    body      dto.UpdateCommentRequest  true  "New comment text"
+// @Success      200  {object}  domain.Comment
+// @Failure      400  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /posts/{id}/comments/{commentId} [put]
+func (h *InteractionHandler) UpdateComment(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+	postID, commentID, err := parseCommentParams(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var req dto.UpdateCommentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request"})
+	}
+
+	// The comment_updated event is published by the outbox relay
+	comment, err := h.interactionService.UpdateComment(userID, postID, commentID, req.Content)
+	if err != nil {
+		return h.handleCommentError(c, err)
+	}
+
+	return c.JSON(comment)
+}
+
+// DeleteComment godoc
+// @Summary      Delete a comment
+// @Description  Delete a comment and its replies. Comment authors can delete their comments and post authors any comment on their post.
+// @Tags         interactions
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id         path      int  true  "Post ID"
+// @Param        commentId  path      int  true  "Comment ID"
+// @Success      200  {object}  dto.CommentResponse
+// @Failure      400  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /posts/{id}/comments/{commentId} [delete]
+func (h *InteractionHandler) DeleteComment(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+	postID, commentID, err := parseCommentParams(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// The comment_deleted event is published by the outbox relay
+	_, commentsCount, err := h.interactionService.DeleteComment(userID, postID, commentID)
+	if err != nil {
+		return h.handleCommentError(c, err)
+	}
+
+	return c.JSON(dto.CommentResponse{
+		PostID:        postID,
+		CommentsCount: commentsCount,
+	})
+}
+
+// LikeComment godoc
+// @Summary      Like a comment
+// @Description  Add your like to a comment. Liking a comment again has no effect.
+// @Tags         interactions
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id         path      int  true  "Post ID"
+// @Param        commentId  path      int  true  "Comment ID"
+// @Success      200  {object}  dto.CommentLikeResponse
+// @Failure      400  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /posts/{id}/comments/{commentId}/like [post]
+func (h *InteractionHandler) LikeComment(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+	postID, commentID, err := parseCommentParams(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// The comment_liked event is published by the outbox relay
+	likesCount, err := h.interactionService.LikeComment(userID, postID, commentID)
 	if err != nil {
-		h.logger.Error("failed to get comments", zap.Error(err))
-		return c.Status(500).JSON(fiber.Map{"error": "failed to get comments"})
+		return h.handleCommentError(c, err)
 	}
 
-	return c.JSON(comments)
+	return c.JSON(dto.CommentLikeResponse{
+		CommentID:  commentID,
+		LikesCount: likesCount,
+		LikedByMe:  true,
+	})
+}
+
+// UnlikeComment godoc
+// @Summary      Unlike a comment
+// @Description  Remove your like from a comment. Unliking a comment you have not liked has no effect.
+// @Tags         interactions
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id         path      int  true  "Post ID"
+// @Param        commentId  path      int  true  "Comment ID"
+// @Success      200  {object}  dto.CommentLikeResponse
+// @Failure      400  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /posts/{id}/comments/{commentId}/like [delete]
+func (h *InteractionHandler) UnlikeComment(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+	postID, commentID, err := parseCommentParams(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	likesCount, err := h.interactionService.UnlikeComment(userID, postID, commentID)
+	if err != nil {
+		return h.handleCommentError(c, err)
+	}
+
+	return c.JSON(dto.CommentLikeResponse{
+		CommentID:  commentID,
+		LikesCount: likesCount,
+		LikedByMe:  false,
+	})
+}
+
+// parseLimit reads the page size, falling back to the default when it is
+// missing, invalid or above the maximum
+func (h *InteractionHandler) parseLimit(c *fiber.Ctx) int {
+	limit, err := strconv.Atoi(c.Query("limit", strconv.Itoa(h.config.DefaultPageSize)))
+	if err != nil || limit <= 0 || limit > h.config.MaxPageSize {
+		limit = h.config.DefaultPageSize
+	}
+	return limit
+}
+
+// parseCommentParams reads the post and comment IDs from the path
+func parseCommentParams(c *fiber.Ctx) (uint, uint, error) {
+	postID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return 0, 0, errors.New("invalid post id")
+	}
+	commentID, err := strconv.ParseUint(c.Params("commentId"), 10, 32)
+	if err != nil {
+		return 0, 0, errors.New("invalid comment id")
+	}
+	return uint(postID), uint(commentID), nil
+}
+
+// handleReactionError maps errors from likes and reactions to responses
+func (h *InteractionHandler) handleReactionError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrPostNotFound):
+		return c.Status(404).JSON(fiber.Map{"error": "post not found"})
+	case errors.Is(err, service.ErrInvalidReaction):
+		return c.Status(400).JSON(fiber.Map{"error": "reaction must be one of like, heart, laugh, wow, sad or fire"})
+	default:
+		h.logger.Error("reaction request failed", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+	}
+}
+
+func (h *InteractionHandler) handleCommentError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrCommentNotFound):
+		return c.Status(404).JSON(fiber.Map{"error": "comment not found"})
+	case errors.Is(err, service.ErrCommentForbidden):
+		return c.Status(403).JSON(fiber.Map{"error": "you can only change your own comments"})
+	case errors.Is(err, service.ErrInvalidCursor):
+		return c.Status(400).JSON(fiber.Map{"error": "invalid cursor"})
+	case errors.Is(err, service.ErrInvalidSort):
+		return c.Status(400).JSON(fiber.Map{"error": "sort must be one of oldest, newest or top"})
+	default:
+		h.logger.Error("comment request failed", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+	}
 }
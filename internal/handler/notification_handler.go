@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rodolfodpk/instagrano/internal/config"
+	"github.com/rodolfodpk/instagrano/internal/dto"
+	"github.com/rodolfodpk/instagrano/internal/service"
+	"go.uber.org/zap"
+)
+
+type NotificationHandler struct {
+	notificationService *service.NotificationService
+	config              *config.Config
+	logger              *zap.Logger
+}
+
+func NewNotificationHandler(notificationService *service.NotificationService, cfg *config.Config, logger *zap.Logger) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+		config:              cfg,
+		logger:              logger,
+	}
+}
+
+// ListNotifications godoc
+// @Summary      List notifications
+// @Description  Retrieve the current user's notifications, most recently updated first, using cursor-based pagination
+// @Tags         notifications
+// @Produce      json
+// @Security     BearerAuth
+// @Param        cursor  query     string  false  "Pagination cursor"
+// @Param        limit   query     int     false  "Number of notifications (default 20, max 100)"
+// @Success      200  {object}  dto.NotificationListResponse
+// @Failure      400  {object}  object{error=string}
+// @Router       /notifications [get]
+func (h *NotificationHandler) ListNotifications(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+
+	limit, err := strconv.Atoi(c.Query("limit", strconv.Itoa(h.config.DefaultPageSize)))
+	if err != nil || limit <= 0 || limit > h.config.MaxPageSize {
+		limit = h.config.DefaultPageSize
+	}
+
+	notifications, nextCursor, err := h.notificationService.List(userID, limit, c.Query("cursor"))
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	return c.JSON(dto.ToNotificationListResponse(notifications, nextCursor))
+}
+
+// UnreadCount godoc
+// @Summary      Count unread notifications
+// @Description  Retrieve how many of the current user's notifications are unread
+// @Tags         notifications
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  dto.UnreadCountResponse
+// @Router       /notifications/unread_count [get]
+func (h *NotificationHandler) UnreadCount(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+
+	count, err := h.notificationService.UnreadCount(userID)
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	return c.JSON(dto.UnreadCountResponse{UnreadCount: count})
+}
+
+// MarkRead godoc
+// @Summary      Mark a notification read
+// @Description  Mark one of the current user's notifications read. Marking it again is a no-op.
+// @Tags         notifications
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Notification ID"
+// @Success      200  {object}  object{message=string}
+// @Failure      400  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /notifications/{id}/read [post]
+func (h *NotificationHandler) MarkRead(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+	notificationID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid notification id"})
+	}
+
+	if err := h.notificationService.MarkRead(userID, uint(notificationID)); err != nil {
+		return h.handleError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "notification marked as read"})
+}
+
+// MarkAllRead godoc
+// @Summary      Mark all notifications read
+// @Description  Mark every unread notification of the current user read
+// @Tags         notifications
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  dto.MarkAllReadResponse
+// @Router       /notifications/read_all [post]
+func (h *NotificationHandler) MarkAllRead(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+
+	marked, err := h.notificationService.MarkAllRead(userID)
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	return c.JSON(dto.MarkAllReadResponse{Marked: marked})
+}
+
+func (h *NotificationHandler) handleError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrNotificationNotFound):
+		return c.Status(404).JSON(fiber.Map{"error": "notification not found"})
+	case errors.Is(err, service.ErrInvalidCursor):
+		return c.Status(400).JSON(fiber.Map{"error": "invalid cursor"})
+	default:
+		h.logger.Error("notification request failed", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+	}
+}
@@ -1,7 +1,11 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/rodolfodpk/instagrano/internal/domain"
@@ -12,29 +16,33 @@ import (
 )
 
 type PostHandler struct {
-	postService    *service.PostService
-	eventPublisher *events.Publisher
-	logger         *zap.Logger
+	postService        *service.PostService
+	interactionService *service.InteractionService
+	eventPublisher     *events.Publisher
+	logger             *zap.Logger
 }
 
-func NewPostHandler(postService *service.PostService, eventPublisher *events.Publisher, logger *zap.Logger) *PostHandler {
+// NewPostHandler creates a post handler. interactionService may be nil, in
+// which case responses leave out what the caller has done with each post.
+func NewPostHandler(postService *service.PostService, interactionService *service.InteractionService, eventPublisher *events.Publisher, logger *zap.Logger) *PostHandler {
 	return &PostHandler{
-		postService:    postService,
-		eventPublisher: eventPublisher,
-		logger:         logger,
+		postService:        postService,
+		interactionService: interactionService,
+		eventPublisher:     eventPublisher,
+		logger:             logger,
 	}
 }
 
 // CreatePost godoc
 // @Summary      Create a new post
-// @Description  Upload image/video post with title and caption (file upload or URL)
+// @Description  Upload an image/video post with title and caption (file upload or URL). Repeat media, or media_url, to create a carousel of up to 10 items in that order.
 // @Tags         posts
 // @Accept       multipart/form-data
 // @Produce      json
 // @Security     BearerAuth
 // @Param        title       formData  string  true   "Post title"
 // @Param        caption     formData  string  false  "Post caption"
-// @Param        media_type  formData  string  false  "Media type (image or video) - required for file upload"
+// @Param        media_type  formData  string  false  "Media type (image or video) for file uploads, once per file; defaults to the file's content type"
 // @Param        media       formData  file    false  "Media file (alternative to media_url)"
 // @Param        media_url   formData  string  false  "Media URL (alternative to file upload)"
 // @Success      201  {object}  domain.Post
@@ -44,22 +52,22 @@ func (h *PostHandler) CreatePost(c *fiber.Ctx) error {
 	userID := c.Locals("userID").(uint)
 
 	// Parse multipart form
-	_, err := c.MultipartForm()
+	form, err := c.MultipartForm()
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid form data"})
 	}
 
 	title := c.FormValue("title")
 	caption := c.FormValue("caption")
-	mediaURL := c.FormValue("media_url") // NEW: URL input
+	mediaURLs := form.Value["media_url"] // NEW: URL input
 
 	if title == "" {
 		return c.Status(400).JSON(fiber.Map{"error": "title is required"})
 	}
 
-	// Check if URL is provided
-	if mediaURL != "" {
-		post, err := h.postService.CreatePostFromURL(userID, title, caption, mediaURL)
+	// Check if URLs are provided
+	if len(mediaURLs) > 0 {
+		post, err := h.postService.CreatePostFromURLs(userID, title, caption, mediaURLs)
 		if err != nil {
 			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 		}
@@ -76,27 +84,32 @@ func (h *PostHandler) CreatePost(c *fiber.Ctx) error {
 		return c.Status(201).JSON(dto.ToPostResponse(post))
 	}
 
-	// Otherwise, handle file upload (existing logic)
-	mediaTypeStr := c.FormValue("media_type")
-	mediaType := domain.MediaType(mediaTypeStr)
-	if mediaType != domain.MediaTypeImage && mediaType != domain.MediaTypeVideo {
-		mediaType = domain.MediaTypeImage // default
-	}
-
-	// Get uploaded file
-	file, err := c.FormFile("media")
-	if err != nil {
+	// Otherwise, handle file uploads
+	files := form.File["media"]
+	if len(files) == 0 {
 		return c.Status(400).JSON(fiber.Map{"error": "either media file or media_url is required"})
 	}
+	if len(files) > domain.MaxPostMedia {
+		return c.Status(400).JSON(fiber.Map{"error": service.ErrTooManyMedia.Error()})
+	}
 
-	// Open file
-	fileReader, err := file.Open()
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "failed to open file"})
+	uploads := make([]service.MediaUpload, len(files))
+	for i, file := range files {
+		// Open file
+		fileReader, err := file.Open()
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "failed to open file"})
+		}
+		defer fileReader.Close()
+
+		uploads[i] = service.MediaUpload{
+			File:      fileReader,
+			Filename:  file.Filename,
+			MediaType: uploadMediaType(form.Value["media_type"], i, file.Header.Get(fiber.HeaderContentType)),
+		}
 	}
-	defer fileReader.Close()
 
-	post, err := h.postService.CreatePost(userID, title, caption, mediaType, fileReader, file.Filename)
+	post, err := h.postService.CreateCarouselPost(userID, title, caption, uploads)
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -113,9 +126,28 @@ func (h *PostHandler) CreatePost(c *fiber.Ctx) error {
 	return c.Status(201).JSON(post)
 }
 
+// uploadMediaType picks the media type of the i-th uploaded file: the i-th
+// media_type value, or the only one if a single value is sent, else the
+// file's content type. Images are the default.
+func uploadMediaType(mediaTypes []string, i int, contentType string) domain.MediaType {
+	var mediaType domain.MediaType
+	switch {
+	case i < len(mediaTypes):
+		mediaType = domain.MediaType(mediaTypes[i])
+	case len(mediaTypes) == 1:
+		mediaType = domain.MediaType(mediaTypes[0])
+	case strings.HasPrefix(contentType, "video/"):
+		mediaType = domain.MediaTypeVideo
+	}
+	if mediaType != domain.MediaTypeImage && mediaType != domain.MediaTypeVideo {
+		mediaType = domain.MediaTypeImage // default
+	}
+	return mediaType
+}
+
 // GetPost godoc
 // @Summary      Get post by ID
-// @Description  Retrieve a single post with all details
+// @Description  Retrieve a single post with all details, its reaction counts and whether the caller reacted to, commented on and saved it
 // @Tags         posts
 // @Produce      json
 // @Param        id   path      int  true  "Post ID"
@@ -134,7 +166,113 @@ func (h *PostHandler) GetPost(c *fiber.Ctx) error {
 		return c.Status(404).JSON(fiber.Map{"error": "post not found"})
 	}
 
-	return c.JSON(dto.ToPostResponse(post))
+	response := dto.ToPostResponse(post)
+	c.Set(fiber.HeaderETag, postETag(post))
+	userID, _ := c.Locals("userID").(uint)
+	if err := setViewerStates(h.interactionService, userID, []*dto.PostResponse{response}); err != nil {
+		h.logger.Warn("failed to get the caller's state", zap.Error(err), zap.Uint("post_id", post.ID))
+	}
+	return c.JSON(response)
+}
+
+// UpdatePost godoc
+// @Summary      Edit a post
+// @Description  Change the title and/or caption of your post. Send the ETag from GET /posts/{id} in If-Match, or the post's updated_at in the body; the edit fails with 412 if the post changed since. Previous versions are kept in the post's edit history.
+// @Tags         posts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      int                    true   "Post ID"
+// @Param        If-Match  header    string                 false  "ETag of the post as last read"
+// @Param        request   body      dto.UpdatePostRequest  true   "New title and/or caption"
+// @Success      200  {object}  dto.PostResponse
+// @Failure      400  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Failure      412  {object}  object{error=string}
+// @Failure      428  {object}  object{error=string}
+// @Router       /posts/{id} [patch]
+func (h *PostHandler) UpdatePost(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+	postID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid post id"})
+	}
+
+	var req dto.UpdatePostRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	var version time.Time
+	if ifMatch := c.Get(fiber.HeaderIfMatch); ifMatch != "" {
+		if version, err = parsePostETag(ifMatch); err != nil {
+			return c.Status(412).JSON(fiber.Map{"error": "post was changed since it was read"})
+		}
+	} else if req.UpdatedAt != nil {
+		version = *req.UpdatedAt
+	} else {
+		return c.Status(428).JSON(fiber.Map{"error": "If-Match header or updated_at is required"})
+	}
+
+	post, err := h.postService.UpdatePost(uint(postID), userID, req.Title, req.Caption, version)
+	switch {
+	case errors.Is(err, service.ErrInvalidInput):
+		return c.Status(400).JSON(fiber.Map{"error": "title or caption is required, and title cannot be empty"})
+	case errors.Is(err, service.ErrPostNotFound):
+		return c.Status(404).JSON(fiber.Map{"error": "post not found"})
+	case errors.Is(err, service.ErrPostForbidden):
+		return c.Status(403).JSON(fiber.Map{"error": "you can only edit your own posts"})
+	case errors.Is(err, service.ErrPostConflict):
+		return c.Status(412).JSON(fiber.Map{"error": err.Error()})
+	case err != nil:
+		h.logger.Error("failed to update post", zap.Error(err), zap.Uint64("post_id", postID))
+		return c.Status(500).JSON(fiber.Map{"error": "failed to update post"})
+	}
+
+	// The post_updated event is published by the outbox relay
+	response := dto.ToPostResponse(post)
+	c.Set(fiber.HeaderETag, postETag(post))
+	if err := setViewerStates(h.interactionService, userID, []*dto.PostResponse{response}); err != nil {
+		h.logger.Warn("failed to get the caller's state", zap.Error(err), zap.Uint("post_id", post.ID))
+	}
+	return c.JSON(response)
+}
+
+// postETag identifies a version of a post by its updated_at
+func postETag(post *domain.Post) string {
+	return fmt.Sprintf(`"%d"`, post.UpdatedAt.UnixMicro())
+}
+
+// parsePostETag returns the updated_at identified by a postETag
+func parsePostETag(etag string) (time.Time, error) {
+	micros, err := strconv.ParseInt(strings.Trim(strings.TrimPrefix(etag, "W/"), `"`), 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMicro(micros), nil
+}
+
+// setViewerStates fills in what userID has done with each post, in one query
+// for the whole page. The shared post and feed caches hold no per-user data,
+// so this runs on every request.
+func setViewerStates(interactionService *service.InteractionService, userID uint, posts []*dto.PostResponse) error {
+	if interactionService == nil || userID == 0 || len(posts) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+	states, err := interactionService.ViewerStates(userID, ids)
+	if err != nil {
+		return err
+	}
+	for _, post := range posts {
+		post.SetViewerState(states[post.ID])
+	}
+	return nil
 }
 
 // DeletePost godoc
@@ -159,7 +297,7 @@ func (h *PostHandler) DeletePost(c *fiber.Ctx) error {
 
 	err = h.postService.DeletePost(uint(postID), userID)
 	if err != nil {
-		if err.Error() == "post not found" {
+		if errors.Is(err, service.ErrPostNotFound) {
 			return c.Status(404).JSON(fiber.Map{"error": "post not found"})
 		}
 		if err.Error() == "unauthorized" {
@@ -0,0 +1,281 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rodolfodpk/instagrano/internal/config"
+	"github.com/rodolfodpk/instagrano/internal/domain"
+	"github.com/rodolfodpk/instagrano/internal/dto"
+	"github.com/rodolfodpk/instagrano/internal/service"
+	"go.uber.org/zap"
+)
+
+type SaveHandler struct {
+	saveService        *service.SaveService
+	interactionService *service.InteractionService
+	config             *config.Config
+	logger             *zap.Logger
+}
+
+func NewSaveHandler(saveService *service.SaveService, interactionService *service.InteractionService, cfg *config.Config, logger *zap.Logger) *SaveHandler {
+	return &SaveHandler{
+		saveService:        saveService,
+		interactionService: interactionService,
+		config:             cfg,
+		logger:             logger,
+	}
+}
+
+// SavePost godoc
+// @Summary      Save a post
+// @Description  Save a post, in one of your collections when collection_id is given. Saving a saved post again moves it to that collection, or out of any collection when collection_id is omitted.
+// @Tags         saves
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                  true   "Post ID"
+// @Param        request  body      dto.SavePostRequest  false  "Collection to save the post in"
+// @Success      200  {object}  dto.SaveResponse
+// @Failure      400  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /posts/{id}/save [put]
+func (h *SaveHandler) SavePost(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+	postID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid post id"})
+	}
+
+	var req dto.SavePostRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid request"})
+		}
+	}
+
+	if err := h.saveService.SavePost(userID, uint(postID), req.CollectionID); err != nil {
+		return h.handleError(c, err)
+	}
+
+	return c.JSON(dto.SaveResponse{
+		PostID:       uint(postID),
+		Saved:        true,
+		CollectionID: req.CollectionID,
+	})
+}
+
+// UnsavePost godoc
+// @Summary      Unsave a post
+// @Description  Remove a post from your saves and its collection. Unsaving a post you have not saved changes nothing.
+// @Tags         saves
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Post ID"
+// @Success      200  {object}  dto.SaveResponse
+// @Failure      400  {object}  object{error=string}
+// @Router       /posts/{id}/save [delete]
+func (h *SaveHandler) UnsavePost(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+	postID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid post id"})
+	}
+
+	if err := h.saveService.UnsavePost(userID, uint(postID)); err != nil {
+		return h.handleError(c, err)
+	}
+
+	return c.JSON(dto.SaveResponse{PostID: uint(postID)})
+}
+
+// GetSavedPosts godoc
+// @Summary      List my saved posts
+// @Description  Retrieve every post you saved, in a collection or not, most recently saved first, using cursor-based pagination
+// @Tags         saves
+// @Produce      json
+// @Security     BearerAuth
+// @Param        cursor  query     string  false  "Pagination cursor"
+// @Param        limit   query     int     false  "Number of posts (default 20, max 100)"
+// @Success      200  {object}  dto.FeedResponse
+// @Failure      400  {object}  object{error=string}
+// @Router       /users/me/saved [get]
+func (h *SaveHandler) GetSavedPosts(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+
+	posts, nextCursor, err := h.saveService.GetSavedPosts(userID, h.parseLimit(c), c.Query("cursor"))
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	return h.writePosts(c, userID, posts, nextCursor)
+}
+
+// ListCollections godoc
+// @Summary      List my collections
+// @Description  List your collections of saved posts in the order they were created, with the number of posts in each
+// @Tags         saves
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  dto.CollectionListResponse
+// @Router       /collections [get]
+func (h *SaveHandler) ListCollections(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+
+	collections, err := h.saveService.ListCollections(userID)
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	return c.JSON(dto.ToCollectionListResponse(collections))
+}
+
+// CreateCollection godoc
+// @Summary      Create a collection
+// @Description  Create a named collection to save posts in. Names are unique among your collections.
+// @Tags         saves
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      dto.CollectionRequest  true  "Collection name"
+// @Success      201  {object}  domain.Collection
+// @Failure      400  {object}  object{error=string}
+// @Failure      409  {object}  object{error=string}
+// @Router       /collections [post]
+func (h *SaveHandler) CreateCollection(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+
+	var req dto.CollectionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request"})
+	}
+
+	collection, err := h.saveService.CreateCollection(userID, req.Name)
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	return c.Status(201).JSON(collection)
+}
+
+// RenameCollection godoc
+// @Summary      Rename a collection
+// @Description  Rename one of your collections
+// @Tags         saves
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                    true  "Collection ID"
+// @Param        request  body      dto.CollectionRequest  true  "New name"
+// @Success      200  {object}  domain.Collection
+// @Failure      400  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Failure      409  {object}  object{error=string}
+// @Router       /collections/{id} [patch]
+func (h *SaveHandler) RenameCollection(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+	collectionID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid collection id"})
+	}
+
+	var req dto.CollectionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request"})
+	}
+
+	collection, err := h.saveService.RenameCollection(userID, uint(collectionID), req.Name)
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	return c.JSON(collection)
+}
+
+// DeleteCollection godoc
+// @Summary      Delete a collection
+// @Description  Delete one of your collections. Its posts stay saved, outside any collection.
+// @Tags         saves
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Collection ID"
+// @Success      200  {object}  object{message=string}
+// @Failure      400  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /collections/{id} [delete]
+func (h *SaveHandler) DeleteCollection(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+	collectionID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid collection id"})
+	}
+
+	if err := h.saveService.DeleteCollection(userID, uint(collectionID)); err != nil {
+		return h.handleError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "collection deleted successfully"})
+}
+
+// GetCollectionPosts godoc
+// @Summary      List a collection's posts
+// @Description  Retrieve the posts in one of your collections, most recently saved first, using cursor-based pagination
+// @Tags         saves
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id      path      int     true   "Collection ID"
+// @Param        cursor  query     string  false  "Pagination cursor"
+// @Param        limit   query     int     false  "Number of posts (default 20, max 100)"
+// @Success      200  {object}  dto.FeedResponse
+// @Failure      400  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /collections/{id}/posts [get]
+func (h *SaveHandler) GetCollectionPosts(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+	collectionID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid collection id"})
+	}
+
+	posts, nextCursor, err := h.saveService.GetCollectionPosts(userID, uint(collectionID), h.parseLimit(c), c.Query("cursor"))
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	return h.writePosts(c, userID, posts, nextCursor)
+}
+
+func (h *SaveHandler) parseLimit(c *fiber.Ctx) int {
+	limit, err := strconv.Atoi(c.Query("limit", strconv.Itoa(h.config.DefaultPageSize)))
+	if err != nil || limit <= 0 || limit > h.config.MaxPageSize {
+		limit = h.config.DefaultPageSize
+	}
+	return limit
+}
+
+func (h *SaveHandler) writePosts(c *fiber.Ctx, userID uint, posts []*domain.Post, nextCursor string) error {
+	response := dto.ToSavedPostsResponse(posts, nextCursor)
+	if err := setViewerStates(h.interactionService, userID, response.Posts); err != nil {
+		return h.handleError(c, err)
+	}
+	return c.JSON(response)
+}
+
+func (h *SaveHandler) handleError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrPostNotFound):
+		return c.Status(404).JSON(fiber.Map{"error": "post not found"})
+	case errors.Is(err, service.ErrCollectionNotFound):
+		return c.Status(404).JSON(fiber.Map{"error": "collection not found"})
+	case errors.Is(err, service.ErrInvalidCollectionName):
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrCollectionNameTaken):
+		return c.Status(409).JSON(fiber.Map{"error": "collection name already in use"})
+	case errors.Is(err, service.ErrInvalidCursor):
+		return c.Status(400).JSON(fiber.Map{"error": "invalid cursor"})
+	default:
+		h.logger.Error("save request failed", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+	}
+}
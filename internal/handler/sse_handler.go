@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rodolfodpk/instagrano/internal/events"
+	"github.com/rodolfodpk/instagrano/internal/service"
+	"github.com/valyala/fasthttp"
+	"go.uber.org/zap"
+)
+
+// sseHeartbeatInterval is how often an SSE comment is sent to keep proxies
+// from closing idle streams
+const sseHeartbeatInterval = 15 * time.Second
+
+type SSEHandler struct {
+	hub         *events.Hub
+	authService *service.AuthService
+	logger      *zap.Logger
+}
+
+func NewSSEHandler(hub *events.Hub, authService *service.AuthService, logger *zap.Logger) *SSEHandler {
+	return &SSEHandler{
+		hub:         hub,
+		authService: authService,
+		logger:      logger,
+	}
+}
+
+// Stream godoc
+// @Summary      Server-Sent Events stream
+// @Description  Stream the same real-time events as the WebSocket as text/event-stream. Send Last-Event-ID to replay events missed while disconnected.
+// @Tags         events
+// @Produce      text/event-stream
+// @Security     BearerAuth
+// @Param        Last-Event-ID  header    string  false  "ID of the last event received"
+// @Success      200  {string}  string  "SSE stream"
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Router       /events/stream [get]
+func (h *SSEHandler) Stream(c *fiber.Ctx) error {
+	claims := c.Locals("tokenClaims").(*service.AccessClaims)
+	userID := claims.UserID
+
+	lastEventID := c.Get("Last-Event-ID")
+	if lastEventID != "" && events.ValidateEventID(lastEventID) != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid Last-Event-ID"})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	client, backlog, err := h.hub.Register(ctx, userID, events.TransportSSE, lastEventID)
+	if err != nil {
+		cancel()
+		h.logger.Error("failed to register SSE client",
+			zap.Error(err),
+			zap.Uint("user_id", userID))
+		return c.Status(503).JSON(fiber.Map{"error": "failed to subscribe to events"})
+	}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	h.logger.Info("SSE connection established",
+		zap.Uint("user_id", userID),
+		zap.String("last_event_id", lastEventID))
+
+	c.Context().SetBodyStreamWriter(fasthttp.StreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+		defer h.hub.Unregister(client)
+		defer h.logger.Info("SSE connection closed", zap.Uint("user_id", userID))
+
+		// Events missed since Last-Event-ID go out before live ones
+		for _, event := range backlog {
+			if err := h.sendEvent(w, client, event); err != nil {
+				return
+			}
+		}
+
+		heartbeatTicker := time.NewTicker(sseHeartbeatInterval)
+		defer heartbeatTicker.Stop()
+
+		for {
+			select {
+			case event := <-client.Events():
+				if err := h.sendEvent(w, client, event); err != nil {
+					return
+				}
+
+			case <-client.Done():
+				h.logger.Info("SSE client disconnected by hub", zap.Uint("user_id", userID))
+				return
+
+			case <-heartbeatTicker.C:
+				// Drop the stream once its token (or session) has been revoked by logout
+				if revoked, err := h.authService.IsTokenRevoked(ctx, claims.TokenID, claims.SessionID); err != nil {
+					h.logger.Warn("failed to check token revocation",
+						zap.Error(err),
+						zap.Uint("user_id", userID))
+				} else if revoked {
+					h.logger.Info("closing SSE stream for revoked token", zap.Uint("user_id", userID))
+					return
+				}
+
+				// Comments are ignored by EventSource but keep the connection alive
+				if err := h.write(w, fmt.Sprintf(": heartbeat %d\n\n", time.Now().Unix())); err != nil {
+					return
+				}
+			}
+		}
+	}))
+
+	return nil
+}
+
+// sendEvent writes event to the stream unless it was already sent
+func (h *SSEHandler) sendEvent(w *bufio.Writer, client *events.Client, event events.Event) error {
+	if !client.Advance(event.ID) {
+		return nil
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		h.logger.Error("failed to marshal SSE event",
+			zap.Error(err),
+			zap.String("event_type", string(event.Type)))
+		return nil
+	}
+
+	frame := fmt.Sprintf("id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return h.write(w, frame)
+}
+
+// write sends a frame and flushes it; an error means the client went away
+func (h *SSEHandler) write(w *bufio.Writer, frame string) error {
+	if _, err := w.WriteString(frame); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		h.logger.Debug("SSE client disconnected", zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rodolfodpk/instagrano/internal/config"
+	"github.com/rodolfodpk/instagrano/internal/domain"
+	"github.com/rodolfodpk/instagrano/internal/dto"
+	"github.com/rodolfodpk/instagrano/internal/service"
+	"go.uber.org/zap"
+)
+
+type UserHandler struct {
+	userService   *service.UserService
+	followService *service.FollowService
+	config        *config.Config
+	logger        *zap.Logger
+}
+
+func NewUserHandler(userService *service.UserService, followService *service.FollowService, cfg *config.Config, logger *zap.Logger) *UserHandler {
+	return &UserHandler{
+		userService:   userService,
+		followService: followService,
+		config:        cfg,
+		logger:        logger,
+	}
+}
+
+// GetUser godoc
+// @Summary      Get user profile
+// @Description  Retrieve a user's public profile with follower/following counts
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "User ID"
+// @Success      200  {object}  dto.ProfileResponse
+// @Failure      400  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /users/{id} [get]
+func (h *UserHandler) GetUser(c *fiber.Ctx) error {
+	viewerID := c.Locals("userID").(uint)
+	userID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid user id"})
+	}
+
+	user, err := h.followService.GetUser(uint(userID))
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	following := false
+	if viewerID != user.ID {
+		following, err = h.followService.IsFollowing(viewerID, user.ID)
+		if err != nil {
+			return h.handleError(c, err)
+		}
+	}
+
+	return c.JSON(h.toProfileResponse(viewerID, user, following))
+}
+
+// Follow godoc
+// @Summary      Follow a user
+// @Description  Follow another user. Following a user twice is a no-op.
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "User ID"
+// @Success      200  {object}  dto.ProfileResponse
+// @Failure      400  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /users/{id}/follow [post]
+func (h *UserHandler) Follow(c *fiber.Ctx) error {
+	viewerID := c.Locals("userID").(uint)
+	username, _ := c.Locals("username").(string)
+	userID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid user id"})
+	}
+
+	user, err := h.followService.Follow(viewerID, uint(userID), username)
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	return c.JSON(h.toProfileResponse(viewerID, user, true))
+}
+
+// Unfollow godoc
+// @Summary      Unfollow a user
+// @Description  Stop following a user. Unfollowing a user not followed is a no-op.
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "User ID"
+// @Success      200  {object}  dto.ProfileResponse
+// @Failure      400  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /users/{id}/follow [delete]
+func (h *UserHandler) Unfollow(c *fiber.Ctx) error {
+	viewerID := c.Locals("userID").(uint)
+	userID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid user id"})
+	}
+
+	user, err := h.followService.Unfollow(viewerID, uint(userID))
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	return c.JSON(h.toProfileResponse(viewerID, user, false))
+}
+
+// UpdateSettings godoc
+// @Summary      Update my settings
+// @Description  Change the caller's settings. hide_likers hides the likers list of the caller's posts from everyone else.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      dto.UpdateSettingsRequest  true  "Settings to change"
+// @Success      200  {object}  dto.ProfileResponse
+// @Failure      400  {object}  object{error=string}
+// @Router       /users/me/settings [put]
+func (h *UserHandler) UpdateSettings(c *fiber.Ctx) error {
+	viewerID := c.Locals("userID").(uint)
+
+	var req dto.UpdateSettingsRequest
+	if err := c.BodyParser(&req); err != nil || req.HideLikers == nil {
+		return c.Status(400).JSON(fiber.Map{"error": "hide_likers is required"})
+	}
+
+	user, err := h.userService.UpdateSettings(viewerID, *req.HideLikers)
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	return c.JSON(h.toProfileResponse(viewerID, user, false))
+}
+
+// GetFollowers godoc
+// @Summary      List followers
+// @Description  Retrieve users following a user, newest first, using cursor-based pagination
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id      path      int     true   "User ID"
+// @Param        cursor  query     string  false  "Pagination cursor"
+// @Param        limit   query     int     false  "Number of users (default 20, max 100)"
+// @Success      200  {object}  dto.FollowListResponse
+// @Failure      400  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /users/{id}/followers [get]
+func (h *UserHandler) GetFollowers(c *fiber.Ctx) error {
+	return h.listFollows(c, h.followService.GetFollowers)
+}
+
+// GetFollowing godoc
+// @Summary      List followed users
+// @Description  Retrieve users a user follows, newest first, using cursor-based pagination
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id      path      int     true   "User ID"
+// @Param        cursor  query     string  false  "Pagination cursor"
+// @Param        limit   query     int     false  "Number of users (default 20, max 100)"
+// @Success      200  {object}  dto.FollowListResponse
+// @Failure      400  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /users/{id}/following [get]
+func (h *UserHandler) GetFollowing(c *fiber.Ctx) error {
+	return h.listFollows(c, h.followService.GetFollowing)
+}
+
+func (h *UserHandler) listFollows(c *fiber.Ctx, list func(uint, int, string) ([]*domain.FollowUser, string, error)) error {
+	userID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid user id"})
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", strconv.Itoa(h.config.DefaultPageSize)))
+	if err != nil || limit <= 0 || limit > h.config.MaxPageSize {
+		limit = h.config.DefaultPageSize
+	}
+
+	users, nextCursor, err := list(uint(userID), limit, c.Query("cursor"))
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	return c.JSON(dto.ToFollowListResponse(users, nextCursor))
+}
+
+func (h *UserHandler) toProfileResponse(viewerID uint, user *domain.User, following bool) *dto.ProfileResponse {
+	response := &dto.ProfileResponse{
+		User:      dto.ToUserResponse(user),
+		Following: following,
+	}
+	// Email is only visible to its owner
+	if viewerID != user.ID {
+		response.User.Email = ""
+	}
+	return response
+}
+
+func (h *UserHandler) handleError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		return c.Status(404).JSON(fiber.Map{"error": "user not found"})
+	case errors.Is(err, service.ErrCannotFollowSelf):
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidCursor):
+		return c.Status(400).JSON(fiber.Map{"error": "invalid cursor"})
+	default:
+		h.logger.Error("user request failed", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+	}
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rodolfodpk/instagrano/internal/config"
+	"github.com/rodolfodpk/instagrano/internal/dto"
+	"github.com/rodolfodpk/instagrano/internal/service"
+	"go.uber.org/zap"
+)
+
+type WebhookHandler struct {
+	webhookService *service.WebhookService
+	config         *config.Config
+	logger         *zap.Logger
+}
+
+func NewWebhookHandler(webhookService *service.WebhookService, cfg *config.Config, logger *zap.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+		config:         cfg,
+		logger:         logger,
+	}
+}
+
+// CreateWebhook godoc
+// @Summary      Register a webhook
+// @Description  Register a URL that receives signed new_post, post_liked, post_commented and post_deleted events. The signing secret is only returned here.
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      dto.CreateWebhookRequest  true  "Webhook URL, scope (user or app) and event types (default all)"
+// @Success      201  {object}  dto.WebhookResponse
+// @Failure      400  {object}  object{error=string}
+// @Router       /webhooks [post]
+func (h *WebhookHandler) CreateWebhook(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+
+	var req dto.CreateWebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request"})
+	}
+
+	webhook, err := h.webhookService.Register(c.Context(), userID, req.URL, req.Scope, req.EventTypes)
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	response := dto.ToWebhookResponse(webhook)
+	response.Secret = webhook.Secret
+	return c.Status(201).JSON(response)
+}
+
+// ListWebhooks godoc
+// @Summary      List webhooks
+// @Description  List the webhooks registered by the current user
+// @Tags         webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  dto.WebhookListResponse
+// @Router       /webhooks [get]
+func (h *WebhookHandler) ListWebhooks(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+
+	webhooks, err := h.webhookService.List(userID)
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	return c.JSON(dto.ToWebhookListResponse(webhooks))
+}
+
+// DeleteWebhook godoc
+// @Summary      Delete a webhook
+// @Description  Delete one of the current user's webhooks and its pending deliveries
+// @Tags         webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Webhook ID"
+// @Success      200  {object}  object{message=string}
+// @Failure      400  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+	webhookID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid webhook id"})
+	}
+
+	if err := h.webhookService.Delete(userID, uint(webhookID)); err != nil {
+		return h.handleError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "webhook deleted successfully"})
+}
+
+// TestWebhook godoc
+// @Summary      Test-fire a webhook
+// @Description  Send a signed ping event to the webhook right away and report the response
+// @Tags         webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Webhook ID"
+// @Success      200  {object}  dto.WebhookTestResponse
+// @Failure      400  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /webhooks/{id}/test [post]
+func (h *WebhookHandler) TestWebhook(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+	webhookID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid webhook id"})
+	}
+
+	result, err := h.webhookService.Test(c.Context(), userID, uint(webhookID))
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	response := dto.WebhookTestResponse{
+		Success:    result.Err == nil,
+		StatusCode: result.StatusCode,
+		DurationMs: result.Duration.Milliseconds(),
+	}
+	if result.Err != nil {
+		response.Error = result.Err.Error()
+	}
+	return c.JSON(response)
+}
+
+// ListDeliveries godoc
+// @Summary      List webhook deliveries
+// @Description  List a webhook's most recent deliveries, newest first, with the outcome of their latest attempt
+// @Tags         webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id     path      int  true   "Webhook ID"
+// @Param        limit  query     int  false  "Number of deliveries (default 20, max 100)"
+// @Success      200  {object}  dto.WebhookDeliveryListResponse
+// @Failure      400  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListDeliveries(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+	webhookID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid webhook id"})
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", strconv.Itoa(h.config.DefaultPageSize)))
+	if err != nil || limit <= 0 || limit > h.config.MaxPageSize {
+		limit = h.config.DefaultPageSize
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(userID, uint(webhookID), limit)
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	return c.JSON(dto.ToWebhookDeliveryListResponse(deliveries))
+}
+
+func (h *WebhookHandler) handleError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrWebhookNotFound):
+		return c.Status(404).JSON(fiber.Map{"error": "webhook not found"})
+	case errors.Is(err, service.ErrInvalidWebhook):
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	default:
+		h.logger.Error("webhook request failed", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+	}
+}
@@ -8,27 +8,27 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/websocket/v2"
-	"github.com/golang-jwt/jwt/v5"
-	"github.com/rodolfodpk/instagrano/internal/cache"
 	"github.com/rodolfodpk/instagrano/internal/events"
+	"github.com/rodolfodpk/instagrano/internal/service"
 	"go.uber.org/zap"
 )
 
 type WSHandler struct {
-	cache     cache.Cache
-	logger    *zap.Logger
-	jwtSecret string
+	hub         *events.Hub
+	authService *service.AuthService
+	logger      *zap.Logger
 }
 
-func NewWSHandler(cache cache.Cache, logger *zap.Logger, jwtSecret string) *WSHandler {
+func NewWSHandler(hub *events.Hub, authService *service.AuthService, logger *zap.Logger) *WSHandler {
 	return &WSHandler{
-		cache:     cache,
-		logger:    logger,
-		jwtSecret: jwtSecret,
+		hub:         hub,
+		authService: authService,
+		logger:      logger,
 	}
 }
 
-// HandleWebSocket handles WebSocket connections for real-time events
+// HandleWebSocket handles WebSocket connections for real-time events.
+// Clients reconnecting with ?since=<event id> first receive the events they missed.
 func (h *WSHandler) HandleWebSocket(c *websocket.Conn) {
 	// Get token from query parameter
 	token := c.Query("token")
@@ -40,7 +40,7 @@ func (h *WSHandler) HandleWebSocket(c *websocket.Conn) {
 	}
 
 	// Validate JWT token
-	userID, err := h.validateJWT(token)
+	claims, err := h.validateJWT(token)
 	if err != nil {
 		h.logger.Error("invalid JWT token",
 			zap.Error(err),
@@ -49,27 +49,34 @@ func (h *WSHandler) HandleWebSocket(c *websocket.Conn) {
 		c.Close()
 		return
 	}
+	userID := claims.UserID
 
-	h.logger.Info("WebSocket connection established", zap.Uint("user_id", userID))
+	since := c.Query("since")
+	if since != "" && events.ValidateEventID(since) != nil {
+		c.WriteJSON(fiber.Map{"error": "invalid since"})
+		c.Close()
+		return
+	}
+
+	h.logger.Info("WebSocket connection established",
+		zap.Uint("user_id", userID),
+		zap.String("since", since))
 
 	// Create context for this connection
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Subscribe to Redis events channel
-	eventCh, err := h.cache.Subscribe(ctx, events.EventChannel)
+	// Register with the hub, collecting events missed after since
+	client, backlog, err := h.hub.Register(ctx, userID, events.TransportWebSocket, since)
 	if err != nil {
-		h.logger.Error("failed to subscribe to events channel",
+		h.logger.Error("failed to register WebSocket client",
 			zap.Error(err),
 			zap.Uint("user_id", userID))
 		c.WriteJSON(fiber.Map{"error": "failed to subscribe to events"})
 		c.Close()
 		return
 	}
-
-	h.logger.Info("subscribed to Redis events channel",
-		zap.String("channel", events.EventChannel),
-		zap.Uint("user_id", userID))
+	defer h.hub.Unregister(client)
 
 	// Send initial connection message
 	err = c.WriteJSON(map[string]interface{}{
@@ -82,56 +89,67 @@ func (h *WSHandler) HandleWebSocket(c *websocket.Conn) {
 		return
 	}
 
+	// Replay missed events before live ones
+	for _, event := range backlog {
+		if err := h.sendEvent(c, client, event); err != nil {
+			return
+		}
+	}
+
 	// Create ticker for heartbeat
 	heartbeatTicker := time.NewTicker(5 * time.Second)
 	defer heartbeatTicker.Stop()
 
-	// Handle incoming messages (ping/pong)
+	// Read subscription commands; replies are written by the main loop
+	commands := make(chan []byte, 16)
 	go func() {
 		for {
-			if _, _, err := c.ReadMessage(); err != nil {
+			_, msg, err := c.ReadMessage()
+			if err != nil {
 				h.logger.Info("WebSocket read error, closing connection",
 					zap.Error(err),
 					zap.Uint("user_id", userID))
 				cancel()
 				return
 			}
+			select {
+			case commands <- msg:
+			case <-ctx.Done():
+				return
+			}
 		}
 	}()
 
 	// Main event loop
 	for {
 		select {
-		case eventJSON := <-eventCh:
-			// Parse the event
-			var event events.Event
-			if err := json.Unmarshal([]byte(eventJSON), &event); err != nil {
-				h.logger.Error("failed to parse event",
-					zap.Error(err),
-					zap.String("event_json", eventJSON))
-				continue
+		case event := <-client.Events():
+			if err := h.sendEvent(c, client, event); err != nil {
+				return
+			}
+
+		case msg := <-commands:
+			if err := h.handleCommand(c, client, msg); err != nil {
+				return
 			}
 
-			// Log event received
-			h.logger.Info("WebSocket event received",
-				zap.String("event_type", string(event.Type)),
-				zap.Uint("post_id", event.PostID),
-				zap.Uint("triggered_by_user_id", event.TriggeredByUserID),
-				zap.Uint("current_user_id", userID))
+		case <-client.Done():
+			h.logger.Info("WebSocket client disconnected by hub", zap.Uint("user_id", userID))
+			c.WriteJSON(fiber.Map{"error": "too slow, reconnect with since"})
+			return
 
-			// Send event to client
-			if err := c.WriteJSON(event); err != nil {
-				h.logger.Error("failed to send event to client",
+		case <-heartbeatTicker.C:
+			// Drop the socket once its token (or session) has been revoked by logout
+			if revoked, err := h.authService.IsTokenRevoked(ctx, claims.TokenID, claims.SessionID); err != nil {
+				h.logger.Warn("failed to check token revocation",
 					zap.Error(err),
 					zap.Uint("user_id", userID))
+			} else if revoked {
+				h.logger.Info("closing WebSocket for revoked token", zap.Uint("user_id", userID))
+				c.WriteJSON(fiber.Map{"error": "token revoked"})
 				return
 			}
 
-			h.logger.Info("WebSocket event sent",
-				zap.String("event_type", string(event.Type)),
-				zap.Uint("post_id", event.PostID))
-
-		case <-heartbeatTicker.C:
 			// Send heartbeat
 			err := c.WriteJSON(map[string]interface{}{
 				"type":      "heartbeat",
@@ -150,27 +168,85 @@ func (h *WSHandler) HandleWebSocket(c *websocket.Conn) {
 	}
 }
 
-// validateJWT validates a JWT token and returns the user ID
-func (h *WSHandler) validateJWT(tokenString string) (uint, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(h.jwtSecret), nil
+// wsCommand changes the events a socket receives, e.g.
+// {"action": "subscribe", "post_ids": [7], "types": ["post_commented"]}
+type wsCommand struct {
+	Action string `json:"action"`
+	events.Subscription
+}
+
+// handleCommand applies a subscribe/unsubscribe command and replies with the
+// resulting subscription. Malformed commands get an error reply; only a
+// failed write is returned.
+func (h *WSHandler) handleCommand(c *websocket.Conn, client *events.Client, msg []byte) error {
+	var cmd wsCommand
+	if err := json.Unmarshal(msg, &cmd); err != nil {
+		return c.WriteJSON(fiber.Map{"type": "error", "error": "invalid command"})
+	}
+	if err := cmd.Subscription.Validate(); err != nil {
+		return c.WriteJSON(fiber.Map{"type": "error", "error": err.Error()})
+	}
+
+	var reply string
+	switch cmd.Action {
+	case "subscribe":
+		client.Subscribe(cmd.Subscription)
+		reply = "subscribed"
+	case "unsubscribe":
+		client.Unsubscribe(cmd.Subscription)
+		reply = "unsubscribed"
+	default:
+		return c.WriteJSON(fiber.Map{"type": "error", "error": "unknown action"})
+	}
+
+	h.logger.Info("WebSocket subscription changed",
+		zap.String("action", cmd.Action),
+		zap.Uint("user_id", client.UserID))
+
+	return c.WriteJSON(fiber.Map{
+		"type":          reply,
+		"subscriptions": client.Subscriptions(),
 	})
+}
 
+// sendEvent writes an event to the socket unless it was already sent or no
+// longer matches the client's subscription
+func (h *WSHandler) sendEvent(c *websocket.Conn, client *events.Client, event events.Event) error {
+	if !client.Wants(&event) || !client.Advance(event.ID) {
+		return nil
+	}
+
+	if err := c.WriteJSON(event); err != nil {
+		h.logger.Error("failed to send event to client",
+			zap.Error(err),
+			zap.Uint("user_id", client.UserID))
+		return err
+	}
+
+	h.logger.Info("WebSocket event sent",
+		zap.String("event_id", event.ID),
+		zap.String("event_type", string(event.Type)),
+		zap.Uint("post_id", event.PostID),
+		zap.Uint("user_id", client.UserID))
+	return nil
+}
+
+// validateJWT validates a JWT token and checks it has not been revoked
+func (h *WSHandler) validateJWT(tokenString string) (*service.AccessClaims, error) {
+	claims, err := h.authService.ParseAccessToken(tokenString)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		if userIDFloat, ok := claims["user_id"].(float64); ok {
-			return uint(userIDFloat), nil
-		}
-		return 0, fmt.Errorf("invalid user_id in token")
+	revoked, err := h.authService.IsTokenRevoked(context.Background(), claims.TokenID, claims.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	if revoked {
+		return nil, fmt.Errorf("token revoked")
 	}
 
-	return 0, fmt.Errorf("invalid token")
+	return claims, nil
 }
 
 // min returns the minimum of two integers
@@ -1,14 +1,21 @@
 package middleware
 
 import (
-	"fmt"
+	"context"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/rodolfodpk/instagrano/internal/service"
 )
 
-func JWT(jwtSecret string) fiber.Handler {
+// TokenRevocationChecker reports whether an access token (by jti) or its session was revoked
+type TokenRevocationChecker interface {
+	IsTokenRevoked(ctx context.Context, tokenID, sessionID string) (bool, error)
+}
+
+// JWT validates the bearer access token. When revocations is nil the
+// revocation check is skipped, which is only meant for isolated tests.
+func JWT(jwtSecret string, revocations TokenRevocationChecker) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
@@ -22,23 +29,24 @@ func JWT(jwtSecret string) fiber.Handler {
 		}
 		tokenString := parts[1]
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("invalid signing method")
-			}
-			return []byte(jwtSecret), nil
-		})
-
-		if err != nil || !token.Valid {
+		claims, err := service.ParseAccessToken(tokenString, jwtSecret)
+		if err != nil {
 			return c.Status(401).JSON(fiber.Map{"error": "invalid token"})
 		}
 
-		claims := token.Claims.(jwt.MapClaims)
-		userID := uint(claims["user_id"].(float64))
-		username, _ := claims["username"].(string)
+		if revocations != nil {
+			revoked, err := revocations.IsTokenRevoked(c.Context(), claims.TokenID, claims.SessionID)
+			if err != nil {
+				return c.Status(503).JSON(fiber.Map{"error": "unable to verify token"})
+			}
+			if revoked {
+				return c.Status(401).JSON(fiber.Map{"error": "token revoked"})
+			}
+		}
 
-		c.Locals("userID", userID)
-		c.Locals("username", username)
+		c.Locals("userID", claims.UserID)
+		c.Locals("username", claims.Username)
+		c.Locals("tokenClaims", claims)
 		return c.Next()
 	}
 }
@@ -14,9 +14,14 @@ type Cursor struct {
 	ID        uint
 }
 
-// Encode encodes a cursor to a base64 string
+// cursorVersionPrefix marks cursors whose timestamp is in microseconds.
+// Unprefixed cursors, issued before, hold Unix seconds.
+const cursorVersionPrefix = "v2_"
+
+// Encode encodes a cursor to a base64 string. The timestamp keeps microsecond
+// precision so rows created within the same second are not skipped.
 func (c *Cursor) Encode() string {
-	cursorStr := fmt.Sprintf("%d_%d", c.Timestamp.Unix(), c.ID)
+	cursorStr := fmt.Sprintf("%s%d_%d", cursorVersionPrefix, c.Timestamp.UnixMicro(), c.ID)
 	return base64.StdEncoding.EncodeToString([]byte(cursorStr))
 }
 
@@ -31,7 +36,8 @@ func DecodeCursor(cursorStr string) (*Cursor, error) {
 		return nil, fmt.Errorf("invalid cursor format: %w", err)
 	}
 
-	parts := strings.Split(string(decoded), "_")
+	value, micro := strings.CutPrefix(string(decoded), cursorVersionPrefix)
+	parts := strings.Split(value, "_")
 	if len(parts) != 2 {
 		return nil, fmt.Errorf("invalid cursor format")
 	}
@@ -46,8 +52,14 @@ func DecodeCursor(cursorStr string) (*Cursor, error) {
 		return nil, fmt.Errorf("invalid id in cursor: %w", err)
 	}
 
+	// Cursors issued before microsecond precision are read as seconds
+	t := time.Unix(timestamp, 0)
+	if micro {
+		t = time.UnixMicro(timestamp)
+	}
+
 	return &Cursor{
-		Timestamp: time.Unix(timestamp, 0),
+		Timestamp: t,
 		ID:        uint(id),
 	}, nil
 }
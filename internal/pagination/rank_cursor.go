@@ -0,0 +1,58 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const rankCursorPrefix = "rank:"
+
+// RankCursor points into a ranking session: a server-side snapshot of the
+// ranked feed order. Paging by offset into a frozen order guarantees every
+// post is returned exactly once even while scores keep changing.
+type RankCursor struct {
+	SessionID string
+	Offset    int
+}
+
+// Encode encodes a rank cursor to a base64 string
+func (c *RankCursor) Encode() string {
+	cursorStr := fmt.Sprintf("%s%s:%d", rankCursorPrefix, c.SessionID, c.Offset)
+	return base64.StdEncoding.EncodeToString([]byte(cursorStr))
+}
+
+// IsRankCursor reports whether cursorStr was produced by RankCursor.Encode
+func IsRankCursor(cursorStr string) bool {
+	decoded, err := base64.StdEncoding.DecodeString(cursorStr)
+	return err == nil && strings.HasPrefix(string(decoded), rankCursorPrefix)
+}
+
+// DecodeRankCursor decodes a base64 string to a rank cursor
+func DecodeRankCursor(cursorStr string) (*RankCursor, error) {
+	decoded, err := base64.StdEncoding.DecodeString(cursorStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor format: %w", err)
+	}
+
+	value, ok := strings.CutPrefix(string(decoded), rankCursorPrefix)
+	if !ok {
+		return nil, fmt.Errorf("invalid cursor format")
+	}
+
+	sessionID, offsetStr, ok := strings.Cut(value, ":")
+	if !ok || sessionID == "" {
+		return nil, fmt.Errorf("invalid cursor format")
+	}
+
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil || offset < 0 {
+		return nil, fmt.Errorf("invalid offset in cursor")
+	}
+
+	return &RankCursor{
+		SessionID: sessionID,
+		Offset:    offset,
+	}, nil
+}
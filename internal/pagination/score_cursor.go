@@ -0,0 +1,60 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const scoreCursorPrefix = "score:"
+
+// ScoreCursor represents a pagination cursor for lists ordered by a count
+// (such as likes) and then by ID, both descending
+type ScoreCursor struct {
+	Score int
+	ID    uint
+}
+
+// Encode encodes a score cursor to a base64 string
+func (c *ScoreCursor) Encode() string {
+	cursorStr := fmt.Sprintf("%s%d:%d", scoreCursorPrefix, c.Score, c.ID)
+	return base64.StdEncoding.EncodeToString([]byte(cursorStr))
+}
+
+// DecodeScoreCursor decodes a base64 string to a score cursor
+func DecodeScoreCursor(cursorStr string) (*ScoreCursor, error) {
+	if cursorStr == "" {
+		return nil, nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(cursorStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor format: %w", err)
+	}
+
+	value, ok := strings.CutPrefix(string(decoded), scoreCursorPrefix)
+	if !ok {
+		return nil, fmt.Errorf("invalid cursor format")
+	}
+
+	scoreStr, idStr, ok := strings.Cut(value, ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid cursor format")
+	}
+
+	score, err := strconv.Atoi(scoreStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid score in cursor: %w", err)
+	}
+
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id in cursor: %w", err)
+	}
+
+	return &ScoreCursor{
+		Score: score,
+		ID:    uint(id),
+	}, nil
+}
@@ -0,0 +1,227 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/rodolfodpk/instagrano/internal/domain"
+	"github.com/rodolfodpk/instagrano/internal/pagination"
+)
+
+// FollowEventFunc builds the outbox message for a new follow from the
+// followee's follower count after it. It runs inside the follow's transaction.
+type FollowEventFunc func(followersCount int) (*domain.OutboxMessage, error)
+
+type FollowRepository interface {
+	Follow(followerID, followeeID uint, event FollowEventFunc) (bool, error)
+	Unfollow(followerID, followeeID uint) (bool, error)
+	IsFollowing(followerID, followeeID uint) (bool, error)
+	FindFollowers(userID uint, limit int, cursor *pagination.Cursor) ([]*domain.FollowUser, error)
+	FindFollowing(userID uint, limit int, cursor *pagination.Cursor) ([]*domain.FollowUser, error)
+	FindFollowerIDs(userID uint) ([]uint, error)
+	FindFollowingIDsWithMinFollowers(userID uint, minFollowers int) ([]uint, error)
+}
+
+type postgresFollowRepository struct {
+	db *sql.DB
+}
+
+func NewFollowRepository(db *sql.DB) FollowRepository {
+	return &postgresFollowRepository{db: db}
+}
+
+// Follow creates the follow edge, bumps both counters and records the follow
+// event in one transaction. It returns false when the edge already existed.
+func (r *postgresFollowRepository) Follow(followerID, followeeID uint, event FollowEventFunc) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO follows (follower_id, followee_id) VALUES ($1, $2)
+		ON CONFLICT (follower_id, followee_id) DO NOTHING`, followerID, followeeID)
+	if err != nil {
+		return false, fmt.Errorf("failed to create follow: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(`UPDATE users SET following_count = following_count + 1 WHERE id = $1`, followerID); err != nil {
+		return false, fmt.Errorf("failed to update following count: %w", err)
+	}
+	var followersCount int
+	if err := tx.QueryRow(`UPDATE users SET followers_count = followers_count + 1 WHERE id = $1 RETURNING followers_count`, followeeID).Scan(&followersCount); err != nil {
+		return false, fmt.Errorf("failed to update followers count: %w", err)
+	}
+
+	msg, err := event(followersCount)
+	if err != nil {
+		return false, err
+	}
+	if err := recordOutbox(tx, msg); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// Unfollow removes the follow edge and decrements both counters in one transaction.
+// It returns false when there was nothing to remove.
+func (r *postgresFollowRepository) Unfollow(followerID, followeeID uint) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2`, followerID, followeeID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete follow: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(`UPDATE users SET following_count = following_count - 1 WHERE id = $1`, followerID); err != nil {
+		return false, fmt.Errorf("failed to update following count: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE users SET followers_count = followers_count - 1 WHERE id = $1`, followeeID); err != nil {
+		return false, fmt.Errorf("failed to update followers count: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+func (r *postgresFollowRepository) IsFollowing(followerID, followeeID uint) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM follows WHERE follower_id = $1 AND followee_id = $2)`
+	err := r.db.QueryRow(query, followerID, followeeID).Scan(&exists)
+	return exists, err
+}
+
+// FindFollowers lists the users following userID, newest follow first
+func (r *postgresFollowRepository) FindFollowers(userID uint, limit int, cursor *pagination.Cursor) ([]*domain.FollowUser, error) {
+	var query string
+	var args []interface{}
+
+	if cursor == nil {
+		query = `
+			SELECT u.id, u.username, f.created_at
+			FROM follows f
+			JOIN users u ON f.follower_id = u.id
+			WHERE f.followee_id = $1
+			ORDER BY f.created_at DESC, f.follower_id DESC
+			LIMIT $2`
+		args = []interface{}{userID, limit}
+	} else {
+		query = `
+			SELECT u.id, u.username, f.created_at
+			FROM follows f
+			JOIN users u ON f.follower_id = u.id
+			WHERE f.followee_id = $1
+			  AND ((f.created_at < $3) OR (f.created_at = $3 AND f.follower_id < $4))
+			ORDER BY f.created_at DESC, f.follower_id DESC
+			LIMIT $2`
+		args = []interface{}{userID, limit, cursor.Timestamp, cursor.ID}
+	}
+
+	return r.queryFollowUsers(query, args...)
+}
+
+// FindFollowing lists the users userID follows, newest follow first
+func (r *postgresFollowRepository) FindFollowing(userID uint, limit int, cursor *pagination.Cursor) ([]*domain.FollowUser, error) {
+	var query string
+	var args []interface{}
+
+	if cursor == nil {
+		query = `
+			SELECT u.id, u.username, f.created_at
+			FROM follows f
+			JOIN users u ON f.followee_id = u.id
+			WHERE f.follower_id = $1
+			ORDER BY f.created_at DESC, f.followee_id DESC
+			LIMIT $2`
+		args = []interface{}{userID, limit}
+	} else {
+		query = `
+			SELECT u.id, u.username, f.created_at
+			FROM follows f
+			JOIN users u ON f.followee_id = u.id
+			WHERE f.follower_id = $1
+			  AND ((f.created_at < $3) OR (f.created_at = $3 AND f.followee_id < $4))
+			ORDER BY f.created_at DESC, f.followee_id DESC
+			LIMIT $2`
+		args = []interface{}{userID, limit, cursor.Timestamp, cursor.ID}
+	}
+
+	return r.queryFollowUsers(query, args...)
+}
+
+// FindFollowerIDs returns the IDs of every user following userID
+func (r *postgresFollowRepository) FindFollowerIDs(userID uint) ([]uint, error) {
+	return r.queryIDs(`SELECT follower_id FROM follows WHERE followee_id = $1`, userID)
+}
+
+// FindFollowingIDsWithMinFollowers returns the users followed by userID that
+// have more than minFollowers followers
+func (r *postgresFollowRepository) FindFollowingIDsWithMinFollowers(userID uint, minFollowers int) ([]uint, error) {
+	query := `
+		SELECT f.followee_id
+		FROM follows f
+		JOIN users u ON f.followee_id = u.id
+		WHERE f.follower_id = $1 AND u.followers_count > $2`
+	return r.queryIDs(query, userID, minFollowers)
+}
+
+func (r *postgresFollowRepository) queryIDs(query string, args ...interface{}) ([]uint, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query follows: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uint
+	for rows.Next() {
+		var id uint
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan follow: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *postgresFollowRepository) queryFollowUsers(query string, args ...interface{}) ([]*domain.FollowUser, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query follows: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*domain.FollowUser
+	for rows.Next() {
+		user := &domain.FollowUser{}
+		if err := rows.Scan(&user.ID, &user.Username, &user.FollowedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan follow: %w", err)
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
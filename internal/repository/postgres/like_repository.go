@@ -2,24 +2,73 @@ package postgres
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 
 	"github.com/rodolfodpk/instagrano/internal/domain"
+	"github.com/rodolfodpk/instagrano/internal/pagination"
 )
 
+// OutboxEventFunc builds the outbox message for a comment from the
+// post's counters after the change. It runs inside the change's transaction.
+type OutboxEventFunc func(likesCount, commentsCount int) (*domain.OutboxMessage, error)
+
+// ReactionEventFunc builds the outbox message for a reaction from the user's
+// previous reaction ("" if none) and the post's counters after the change. It
+// runs inside the change's transaction.
+type ReactionEventFunc func(previous string, counts domain.PostCounts) (*domain.OutboxMessage, error)
+
+// CommentLikeEventFunc builds the outbox message for a comment like from the
+// comment's like count after the change. It runs inside the change's transaction.
+type CommentLikeEventFunc func(likesCount int) (*domain.OutboxMessage, error)
+
 type LikeRepository interface {
 	Create(like *domain.Like) error
+	// React sets userID's reaction on the post, replacing any other one, and
+	// Unreact removes it. Only when that changes the reaction do they update
+	// the post's counters and record the event, all in one transaction;
+	// changed reports whether they did. They return sql.ErrNoRows when the
+	// post does not exist.
+	React(userID, postID uint, reaction string, event ReactionEventFunc) (counts domain.PostCounts, changed bool, err error)
+	Unreact(userID, postID uint, event ReactionEventFunc) (counts domain.PostCounts, changed bool, err error)
 	IncrementPostLikeCount(postID uint) error
 	DecrementPostLikeCount(postID uint) error
 	FindByPostID(postID uint) ([]*domain.Like, error)
+	// FindLikers lists the users who reacted to a post, most recent first,
+	// marking those viewerID follows
+	FindLikers(postID, viewerID uint, limit int, cursor *pagination.Cursor) ([]*domain.Liker, error)
 	FindByUserAndPost(userID, postID uint) (*domain.Like, error)
 	Delete(userID, postID uint) error
 }
 
 type CommentRepository interface {
 	Create(comment *domain.Comment) error
+	// CreateWithOutbox saves the comment, bumps the counter and records its event in one transaction
+	CreateWithOutbox(comment *domain.Comment, event OutboxEventFunc) (likesCount, commentsCount int, err error)
+	// UpdateWithOutbox saves the comment's new text, stamps EditedAt and records its event in one transaction.
+	// It returns sql.ErrNoRows when the comment no longer exists. When
+	// mentionEvent is not nil, the comment's Mentions replace those of its
+	// previous text in the same transaction, as MentionRepository.Replace does.
+	UpdateWithOutbox(comment *domain.Comment, event OutboxEventFunc, mentionEvent MentionEventFunc) (likesCount, commentsCount int, err error)
+	// DeleteWithOutbox removes the comment and its replies, decrements the counter and records its event in one transaction.
+	// It returns sql.ErrNoRows when the comment no longer exists.
+	DeleteWithOutbox(comment *domain.Comment, event OutboxEventFunc) (likesCount, commentsCount int, err error)
+	// Like and Unlike change userID's like on the comment, bump its counter and
+	// record the event in one transaction. Repeating either is a no-op that
+	// records no event.
+	Like(commentID, userID uint, event CommentLikeEventFunc) (likesCount int, err error)
+	Unlike(commentID, userID uint, event CommentLikeEventFunc) (likesCount int, err error)
+	// LikedByUser returns which of the given comments userID has liked
+	LikedByUser(userID uint, commentIDs []uint) (map[uint]bool, error)
 	IncrementPostCommentCount(postID uint) error
-	FindByPostID(postID uint) ([]*domain.Comment, error)
+	FindByID(id uint) (*domain.Comment, error)
+	// FindByPostID lists a post's top-level comments with their reply counts,
+	// oldest or newest first (domain.CommentSortOldest/Newest)
+	FindByPostID(postID uint, sort string, limit int, cursor *pagination.Cursor) ([]*domain.Comment, error)
+	// FindTopByPostID lists a post's top-level comments, most liked first
+	FindTopByPostID(postID uint, limit int, cursor *pagination.ScoreCursor) ([]*domain.Comment, error)
+	// FindReplies lists the replies to a top-level comment, oldest first
+	FindReplies(parentID uint, limit int, cursor *pagination.Cursor) ([]*domain.Comment, error)
 }
 
 type postgresLikeRepository struct {
@@ -39,8 +88,82 @@ func NewCommentRepository(db *sql.DB) CommentRepository {
 }
 
 func (r *postgresLikeRepository) Create(like *domain.Like) error {
-	query := `INSERT INTO likes (user_id, post_id) VALUES ($1, $2) RETURNING id, created_at`
-	return r.db.QueryRow(query, like.UserID, like.PostID).Scan(&like.ID, &like.CreatedAt)
+	if like.Reaction == "" {
+		like.Reaction = domain.ReactionLike
+	}
+	query := `INSERT INTO likes (user_id, post_id, reaction) VALUES ($1, $2, $3) RETURNING id, created_at`
+	return r.db.QueryRow(query, like.UserID, like.PostID, like.Reaction).Scan(&like.ID, &like.CreatedAt)
+}
+
+func (r *postgresLikeRepository) React(userID, postID uint, reaction string, event ReactionEventFunc) (domain.PostCounts, bool, error) {
+	return r.changeReaction(userID, postID, reaction, event)
+}
+
+func (r *postgresLikeRepository) Unreact(userID, postID uint, event ReactionEventFunc) (domain.PostCounts, bool, error) {
+	return r.changeReaction(userID, postID, "", event)
+}
+
+// changeReaction sets userID's reaction on postID ("" removes it) and, if
+// that changed it, moves the post's counters and records the event
+func (r *postgresLikeRepository) changeReaction(userID, postID uint, reaction string, event ReactionEventFunc) (domain.PostCounts, bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return domain.PostCounts{}, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Locking the post serializes reactions to it, so the counters read here
+	// are still current when they are written back
+	counts, err := lockPostCounts(tx, postID)
+	if err != nil {
+		return domain.PostCounts{}, false, err
+	}
+
+	var previous string
+	err = tx.QueryRow(`SELECT reaction FROM likes WHERE user_id = $1 AND post_id = $2`, userID, postID).Scan(&previous)
+	if err != nil && err != sql.ErrNoRows {
+		return domain.PostCounts{}, false, fmt.Errorf("failed to get reaction: %w", err)
+	}
+	if previous == reaction {
+		// Already in the requested state: report the counts without recording an event
+		return counts, false, nil
+	}
+
+	switch {
+	case reaction == "":
+		_, err = tx.Exec(`DELETE FROM likes WHERE user_id = $1 AND post_id = $2`, userID, postID)
+	case previous == "":
+		_, err = tx.Exec(`INSERT INTO likes (user_id, post_id, reaction) VALUES ($1, $2, $3)`, userID, postID, reaction)
+	default:
+		_, err = tx.Exec(`UPDATE likes SET reaction = $3 WHERE user_id = $1 AND post_id = $2`, userID, postID, reaction)
+	}
+	if err != nil {
+		return domain.PostCounts{}, false, fmt.Errorf("failed to change reaction: %w", err)
+	}
+
+	counts.MoveReaction(previous, reaction)
+	reactionCounts, err := json.Marshal(counts.ReactionCounts)
+	if err != nil {
+		return domain.PostCounts{}, false, err
+	}
+	_, err = tx.Exec(`UPDATE posts SET likes_count = $2, reaction_counts = $3 WHERE id = $1`,
+		postID, counts.LikesCount, reactionCounts)
+	if err != nil {
+		return domain.PostCounts{}, false, fmt.Errorf("failed to update post counters: %w", err)
+	}
+
+	msg, err := event(previous, counts)
+	if err != nil {
+		return domain.PostCounts{}, false, err
+	}
+	if err := recordOutbox(tx, msg); err != nil {
+		return domain.PostCounts{}, false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return domain.PostCounts{}, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return counts, true, nil
 }
 
 func (r *postgresLikeRepository) IncrementPostLikeCount(postID uint) error {
@@ -56,9 +179,9 @@ func (r *postgresLikeRepository) DecrementPostLikeCount(postID uint) error {
 }
 
 func (r *postgresLikeRepository) FindByUserAndPost(userID, postID uint) (*domain.Like, error) {
-	query := `SELECT id, user_id, post_id, created_at FROM likes WHERE user_id = $1 AND post_id = $2`
+	query := `SELECT id, user_id, post_id, reaction, created_at FROM likes WHERE user_id = $1 AND post_id = $2`
 	like := &domain.Like{}
-	err := r.db.QueryRow(query, userID, postID).Scan(&like.ID, &like.UserID, &like.PostID, &like.CreatedAt)
+	err := r.db.QueryRow(query, userID, postID).Scan(&like.ID, &like.UserID, &like.PostID, &like.Reaction, &like.CreatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil // Not found, but not an error
@@ -85,8 +208,145 @@ func (r *postgresLikeRepository) Delete(userID, postID uint) error {
 }
 
 func (r *postgresCommentRepository) Create(comment *domain.Comment) error {
-	query := `INSERT INTO comments (user_id, post_id, text) VALUES ($1, $2, $3) RETURNING id, created_at`
-	return r.db.QueryRow(query, comment.UserID, comment.PostID, comment.Text).Scan(&comment.ID, &comment.CreatedAt)
+	query := `INSERT INTO comments (user_id, post_id, parent_id, text) VALUES ($1, $2, $3, $4) RETURNING id, created_at`
+	return r.db.QueryRow(query, comment.UserID, comment.PostID, comment.ParentID, comment.Text).Scan(&comment.ID, &comment.CreatedAt)
+}
+
+func (r *postgresCommentRepository) CreateWithOutbox(comment *domain.Comment, event OutboxEventFunc) (int, int, error) {
+	return withPostCountsTx(r.db, event, func(tx *sql.Tx) (int, int, error) {
+		err := tx.QueryRow(`INSERT INTO comments (user_id, post_id, parent_id, text) VALUES ($1, $2, $3, $4) RETURNING id, created_at`,
+			comment.UserID, comment.PostID, comment.ParentID, comment.Text).Scan(&comment.ID, &comment.CreatedAt)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to create comment: %w", err)
+		}
+		return updatePostCounts(tx, `comments_count = comments_count + 1`, comment.PostID)
+	})
+}
+
+func (r *postgresCommentRepository) UpdateWithOutbox(comment *domain.Comment, event OutboxEventFunc, mentionEvent MentionEventFunc) (int, int, error) {
+	return withPostCountsTx(r.db, event, func(tx *sql.Tx) (int, int, error) {
+		err := tx.QueryRow(`UPDATE comments SET text = $2, edited_at = NOW() WHERE id = $1 RETURNING edited_at`,
+			comment.ID, comment.Text).Scan(&comment.EditedAt)
+		if err == sql.ErrNoRows {
+			return 0, 0, err
+		}
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to update comment: %w", err)
+		}
+		if mentionEvent != nil {
+			if err := replaceMentions(tx, comment.PostID, &comment.ID, comment.UserID, comment.Mentions, mentionEvent); err != nil {
+				return 0, 0, err
+			}
+		}
+		return postCounts(tx, comment.PostID)
+	})
+}
+
+func (r *postgresCommentRepository) DeleteWithOutbox(comment *domain.Comment, event OutboxEventFunc) (int, int, error) {
+	return withPostCountsTx(r.db, event, func(tx *sql.Tx) (int, int, error) {
+		// Locking the comment makes concurrent replies to it commit first, so
+		// the delete below sees (and counts) them
+		var id uint
+		err := tx.QueryRow(`SELECT id FROM comments WHERE id = $1 FOR UPDATE`, comment.ID).Scan(&id)
+		if err == sql.ErrNoRows {
+			return 0, 0, err
+		}
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to lock comment: %w", err)
+		}
+
+		result, err := tx.Exec(`DELETE FROM comments WHERE id = $1 OR parent_id = $1`, comment.ID)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to delete comment: %w", err)
+		}
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		return updatePostCounts(tx, fmt.Sprintf(`comments_count = GREATEST(comments_count - %d, 0)`, deleted), comment.PostID)
+	})
+}
+
+func (r *postgresCommentRepository) Like(commentID, userID uint, event CommentLikeEventFunc) (int, error) {
+	return r.changeLike(commentID, userID,
+		`INSERT INTO comment_likes (comment_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+		`likes_count = likes_count + 1`, event)
+}
+
+func (r *postgresCommentRepository) Unlike(commentID, userID uint, event CommentLikeEventFunc) (int, error) {
+	return r.changeLike(commentID, userID,
+		`DELETE FROM comment_likes WHERE comment_id = $1 AND user_id = $2`,
+		`likes_count = likes_count - 1`, event)
+}
+
+// changeLike runs change for userID and, if it changed a row, applies set to
+// the comment's counter and records the event
+func (r *postgresCommentRepository) changeLike(commentID, userID uint, change, set string, event CommentLikeEventFunc) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(change, commentID, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to change comment like: %w", err)
+	}
+	changed, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	var likesCount int
+	if changed == 0 {
+		// Already liked (or not): report the count without recording an event
+		err := tx.QueryRow(`SELECT likes_count FROM comments WHERE id = $1`, commentID).Scan(&likesCount)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get comment likes: %w", err)
+		}
+		return likesCount, nil
+	}
+
+	err = tx.QueryRow(`UPDATE comments SET `+set+` WHERE id = $1 RETURNING likes_count`, commentID).Scan(&likesCount)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update comment likes: %w", err)
+	}
+
+	msg, err := event(likesCount)
+	if err != nil {
+		return 0, err
+	}
+	if err := recordOutbox(tx, msg); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return likesCount, nil
+}
+
+func (r *postgresCommentRepository) LikedByUser(userID uint, commentIDs []uint) (map[uint]bool, error) {
+	liked := make(map[uint]bool)
+	if len(commentIDs) == 0 {
+		return liked, nil
+	}
+
+	rows, err := r.db.Query(`SELECT comment_id FROM comment_likes WHERE user_id = $1 AND comment_id = ANY($2)`,
+		userID, toInt64s(commentIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query comment likes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var commentID uint
+		if err := rows.Scan(&commentID); err != nil {
+			return nil, err
+		}
+		liked[commentID] = true
+	}
+	return liked, rows.Err()
 }
 
 func (r *postgresCommentRepository) IncrementPostCommentCount(postID uint) error {
@@ -96,7 +356,7 @@ func (r *postgresCommentRepository) IncrementPostCommentCount(postID uint) error
 }
 
 func (r *postgresLikeRepository) FindByPostID(postID uint) ([]*domain.Like, error) {
-	query := `SELECT id, user_id, post_id, created_at FROM likes WHERE post_id = $1 ORDER BY created_at DESC`
+	query := `SELECT id, user_id, post_id, reaction, created_at FROM likes WHERE post_id = $1 ORDER BY created_at DESC`
 	rows, err := r.db.Query(query, postID)
 	if err != nil {
 		return nil, err
@@ -106,7 +366,7 @@ func (r *postgresLikeRepository) FindByPostID(postID uint) ([]*domain.Like, erro
 	var likes []*domain.Like
 	for rows.Next() {
 		like := &domain.Like{}
-		err := rows.Scan(&like.ID, &like.UserID, &like.PostID, &like.CreatedAt)
+		err := rows.Scan(&like.ID, &like.UserID, &like.PostID, &like.Reaction, &like.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -115,28 +375,260 @@ func (r *postgresLikeRepository) FindByPostID(postID uint) ([]*domain.Like, erro
 	return likes, nil
 }
 
-func (r *postgresCommentRepository) FindByPostID(postID uint) ([]*domain.Comment, error) {
+func (r *postgresLikeRepository) FindLikers(postID, viewerID uint, limit int, cursor *pagination.Cursor) ([]*domain.Liker, error) {
+	var query string
+	var args []interface{}
+
+	if cursor == nil {
+		query = `
+			SELECT` + likerColumns + `
+			FROM likes l
+			JOIN users u ON l.user_id = u.id
+			WHERE l.post_id = $1
+			ORDER BY l.created_at DESC, l.user_id DESC
+			LIMIT $3`
+		args = []interface{}{postID, viewerID, limit}
+	} else {
+		query = `
+			SELECT` + likerColumns + `
+			FROM likes l
+			JOIN users u ON l.user_id = u.id
+			WHERE l.post_id = $1
+			  AND ((l.created_at < $4) OR (l.created_at = $4 AND l.user_id < $5))
+			ORDER BY l.created_at DESC, l.user_id DESC
+			LIMIT $3`
+		args = []interface{}{postID, viewerID, limit, cursor.Timestamp, cursor.ID}
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query likers: %w", err)
+	}
+	defer rows.Close()
+
+	var likers []*domain.Liker
+	for rows.Next() {
+		liker := &domain.Liker{}
+		if err := rows.Scan(&liker.ID, &liker.Username, &liker.Reaction, &liker.LikedAt, &liker.FollowedByMe); err != nil {
+			return nil, fmt.Errorf("failed to scan liker: %w", err)
+		}
+		likers = append(likers, liker)
+	}
+	return likers, rows.Err()
+}
+
+// likerColumns selects a liker and whether the viewer ($2) follows them
+const likerColumns = `
+	u.id, u.username, l.reaction, l.created_at,
+	EXISTS(SELECT 1 FROM follows f WHERE f.follower_id = $2 AND f.followee_id = u.id)`
+
+func (r *postgresCommentRepository) FindByID(id uint) (*domain.Comment, error) {
 	query := `
-		SELECT c.id, c.user_id, c.post_id, c.text, c.created_at, u.username
+		SELECT` + commentColumns + `
 		FROM comments c
 		JOIN users u ON c.user_id = u.id
-		WHERE c.post_id = $1 
-		ORDER BY c.created_at ASC
+		WHERE c.id = $1
 	`
-	rows, err := r.db.Query(query, postID)
+	comment, err := scanComment(r.db.QueryRow(query, id))
 	if err != nil {
-		return nil, err
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found, but not an error
+		}
+		return nil, fmt.Errorf("failed to find comment: %w", err)
+	}
+	return comment, nil
+}
+
+func (r *postgresCommentRepository) FindByPostID(postID uint, sort string, limit int, cursor *pagination.Cursor) ([]*domain.Comment, error) {
+	order, after := "ASC", ">"
+	if sort == domain.CommentSortNewest {
+		order, after = "DESC", "<"
+	}
+
+	if cursor == nil {
+		query := `
+			SELECT` + commentColumns + `
+			FROM comments c
+			JOIN users u ON c.user_id = u.id
+			WHERE c.post_id = $1 AND c.parent_id IS NULL
+			ORDER BY c.created_at ` + order + `, c.id ` + order + `
+			LIMIT $2
+		`
+		return r.queryComments(query, postID, limit)
+	}
+
+	query := `
+		SELECT` + commentColumns + `
+		FROM comments c
+		JOIN users u ON c.user_id = u.id
+		WHERE c.post_id = $1 AND c.parent_id IS NULL
+		  AND ((c.created_at ` + after + ` $3) OR (c.created_at = $3 AND c.id ` + after + ` $4))
+		ORDER BY c.created_at ` + order + `, c.id ` + order + `
+		LIMIT $2
+	`
+	return r.queryComments(query, postID, limit, cursor.Timestamp, cursor.ID)
+}
+
+func (r *postgresCommentRepository) FindTopByPostID(postID uint, limit int, cursor *pagination.ScoreCursor) ([]*domain.Comment, error) {
+	if cursor == nil {
+		query := `
+			SELECT` + commentColumns + `
+			FROM comments c
+			JOIN users u ON c.user_id = u.id
+			WHERE c.post_id = $1 AND c.parent_id IS NULL
+			ORDER BY c.likes_count DESC, c.id DESC
+			LIMIT $2
+		`
+		return r.queryComments(query, postID, limit)
+	}
+
+	query := `
+		SELECT` + commentColumns + `
+		FROM comments c
+		JOIN users u ON c.user_id = u.id
+		WHERE c.post_id = $1 AND c.parent_id IS NULL
+		  AND ((c.likes_count < $3) OR (c.likes_count = $3 AND c.id < $4))
+		ORDER BY c.likes_count DESC, c.id DESC
+		LIMIT $2
+	`
+	return r.queryComments(query, postID, limit, cursor.Score, cursor.ID)
+}
+
+func (r *postgresCommentRepository) FindReplies(parentID uint, limit int, cursor *pagination.Cursor) ([]*domain.Comment, error) {
+	if cursor == nil {
+		query := `
+			SELECT` + commentColumns + `
+			FROM comments c
+			JOIN users u ON c.user_id = u.id
+			WHERE c.parent_id = $1
+			ORDER BY c.created_at ASC, c.id ASC
+			LIMIT $2
+		`
+		return r.queryComments(query, parentID, limit)
+	}
+
+	query := `
+		SELECT` + commentColumns + `
+		FROM comments c
+		JOIN users u ON c.user_id = u.id
+		WHERE c.parent_id = $1
+		  AND ((c.created_at > $3) OR (c.created_at = $3 AND c.id > $4))
+		ORDER BY c.created_at ASC, c.id ASC
+		LIMIT $2
+	`
+	return r.queryComments(query, parentID, limit, cursor.Timestamp, cursor.ID)
+}
+
+// commentColumns selects a comment with its author and, for top-level
+// comments, the size of its thread
+const commentColumns = `
+	c.id, c.user_id, c.post_id, c.parent_id, c.text,
+	COALESCE((
+		SELECT json_agg(json_build_object('user_id', mn.user_id, 'username', mu.username, 'offset', mn.text_offset, 'length', mn.text_length) ORDER BY mn.text_offset)
+		FROM mentions mn JOIN users mu ON mu.id = mn.user_id
+		WHERE mn.comment_id = c.id
+	), '[]'),
+	u.username, (SELECT COUNT(*) FROM comments r WHERE r.parent_id = c.id), c.likes_count, c.created_at, c.edited_at`
+
+func (r *postgresCommentRepository) queryComments(query string, args ...interface{}) ([]*domain.Comment, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query comments: %w", err)
 	}
 	defer rows.Close()
 
 	var comments []*domain.Comment
 	for rows.Next() {
-		comment := &domain.Comment{}
-		err := rows.Scan(&comment.ID, &comment.UserID, &comment.PostID, &comment.Text, &comment.CreatedAt, &comment.Username)
+		comment, err := scanComment(rows)
 		if err != nil {
 			return nil, err
 		}
 		comments = append(comments, comment)
 	}
-	return comments, nil
+	return comments, rows.Err()
+}
+
+func scanComment(row rowScanner) (*domain.Comment, error) {
+	comment := &domain.Comment{}
+	var mentions []byte
+	err := row.Scan(
+		&comment.ID, &comment.UserID, &comment.PostID, &comment.ParentID, &comment.Text, &mentions,
+		&comment.Username, &comment.ReplyCount, &comment.LikesCount, &comment.CreatedAt, &comment.EditedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(mentions, &comment.Mentions); err != nil {
+		return nil, fmt.Errorf("failed to decode comment mentions: %w", err)
+	}
+	return comment, nil
+}
+
+// withPostCountsTx runs change, which returns the post's counters after it,
+// then records the event built from them, all in one transaction
+func withPostCountsTx(db *sql.DB, event OutboxEventFunc, change func(tx *sql.Tx) (int, int, error)) (int, int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	likesCount, commentsCount, err := change(tx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	msg, err := event(likesCount, commentsCount)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := recordOutbox(tx, msg); err != nil {
+		return 0, 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return likesCount, commentsCount, nil
+}
+
+// postCounts returns a post's counters
+func postCounts(tx *sql.Tx, postID uint) (int, int, error) {
+	var likesCount, commentsCount int
+	err := tx.QueryRow(`SELECT likes_count, comments_count FROM posts WHERE id = $1`, postID).
+		Scan(&likesCount, &commentsCount)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get post counters: %w", err)
+	}
+	return likesCount, commentsCount, nil
+}
+
+// lockPostCounts returns a post's counters, locking its row until the
+// transaction ends. It returns sql.ErrNoRows when the post does not exist.
+func lockPostCounts(tx *sql.Tx, postID uint) (domain.PostCounts, error) {
+	var counts domain.PostCounts
+	reactionCounts := []byte(`{}`)
+	err := tx.QueryRow(`SELECT likes_count, comments_count, reaction_counts FROM posts WHERE id = $1 FOR UPDATE`, postID).
+		Scan(&counts.LikesCount, &counts.CommentsCount, &reactionCounts)
+	if err == sql.ErrNoRows {
+		return counts, err
+	}
+	if err != nil {
+		return counts, fmt.Errorf("failed to get post counters: %w", err)
+	}
+	if err := json.Unmarshal(reactionCounts, &counts.ReactionCounts); err != nil {
+		return counts, fmt.Errorf("failed to decode reaction counts: %w", err)
+	}
+	return counts, nil
+}
+
+// updatePostCounts applies set to a post's counters and returns them
+func updatePostCounts(tx *sql.Tx, set string, postID uint) (int, int, error) {
+	var likesCount, commentsCount int
+	err := tx.QueryRow(`UPDATE posts SET `+set+` WHERE id = $1 RETURNING likes_count, comments_count`, postID).
+		Scan(&likesCount, &commentsCount)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to update post counters: %w", err)
+	}
+	return likesCount, commentsCount, nil
 }
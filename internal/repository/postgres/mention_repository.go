@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/rodolfodpk/instagrano/internal/domain"
+)
+
+// MentionEventFunc builds the outbox message telling a user they were
+// mentioned. It runs inside the transaction that saves the mentions.
+type MentionEventFunc func(mention domain.Mention) (*domain.OutboxMessage, error)
+
+type MentionRepository interface {
+	// Replace saves the mentions in a post's caption (commentID nil) or in one
+	// of its comments, written by authorID, in place of the previous ones. An
+	// event is recorded in the same transaction for each user who was not
+	// mentioned there before, other than the author.
+	Replace(postID uint, commentID *uint, authorID uint, mentions []domain.Mention, event MentionEventFunc) error
+}
+
+type postgresMentionRepository struct {
+	db *sql.DB
+}
+
+func NewMentionRepository(db *sql.DB) MentionRepository {
+	return &postgresMentionRepository{db: db}
+}
+
+func (r *postgresMentionRepository) Replace(postID uint, commentID *uint, authorID uint, mentions []domain.Mention, event MentionEventFunc) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := replaceMentions(tx, postID, commentID, authorID, mentions, event); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// replaceMentions does the work of Replace inside tx, so that edits can save
+// their mentions in the transaction that saves the new text
+func replaceMentions(tx *sql.Tx, postID uint, commentID *uint, authorID uint, mentions []domain.Mention, event MentionEventFunc) error {
+	rows, err := tx.Query(`
+		DELETE FROM mentions WHERE post_id = $1 AND comment_id IS NOT DISTINCT FROM $2
+		RETURNING user_id`, postID, commentID)
+	if err != nil {
+		return fmt.Errorf("failed to remove mentions: %w", err)
+	}
+	notified := map[uint]bool{authorID: true}
+	for rows.Next() {
+		var userID uint
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan mention: %w", err)
+		}
+		notified[userID] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to remove mentions: %w", err)
+	}
+
+	for _, mention := range mentions {
+		_, err := tx.Exec(`
+			INSERT INTO mentions (post_id, comment_id, user_id, mentioned_by, text_offset, text_length)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			postID, commentID, mention.UserID, authorID, mention.Offset, mention.Length)
+		if err != nil {
+			return fmt.Errorf("failed to create mention: %w", err)
+		}
+
+		if notified[mention.UserID] {
+			continue
+		}
+		notified[mention.UserID] = true
+		msg, err := event(mention)
+		if err != nil {
+			return err
+		}
+		if err := recordOutbox(tx, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
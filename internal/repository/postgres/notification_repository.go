@@ -0,0 +1,205 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/rodolfodpk/instagrano/internal/domain"
+	"github.com/rodolfodpk/instagrano/internal/pagination"
+)
+
+// NotificationEventFunc builds the outbox message for a new or updated
+// notification from the recipient's unread count after it. It runs inside the
+// notification's transaction.
+type NotificationEventFunc func(notification *domain.Notification, unreadCount int) (*domain.OutboxMessage, error)
+
+type NotificationRepository interface {
+	// Record notifies the author of postID that actorID interacted with it,
+	// folding the actor into the author's unread notification of that type
+	// on the post if there is one, and records the notification event in the
+	// same transaction. It returns nil when the actor is the author.
+	Record(notificationType string, postID, actorID uint, event NotificationEventFunc) (*domain.Notification, error)
+	FindByUserID(userID uint, limit int, cursor *pagination.Cursor) ([]*domain.Notification, error)
+	CountUnread(userID uint) (int, error)
+	MarkRead(id, userID uint) (bool, error)
+	MarkAllRead(userID uint) (int, error)
+}
+
+type postgresNotificationRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationRepository(db *sql.DB) NotificationRepository {
+	return &postgresNotificationRepository{db: db}
+}
+
+const notificationColumns = `
+	n.id, n.user_id, n.type, n.post_id, n.last_actor_id, u.username,
+	n.actor_count, n.read_at, n.created_at, n.updated_at`
+
+func (r *postgresNotificationRepository) Record(notificationType string, postID, actorID uint, event NotificationEventFunc) (*domain.Notification, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The no-op update returns (and locks) the existing unread notification
+	var notificationID uint
+	err = tx.QueryRow(`
+		INSERT INTO notifications (user_id, type, post_id, last_actor_id)
+		SELECT p.user_id, $1::VARCHAR, p.id, $3::INT FROM posts p
+		WHERE p.id = $2 AND p.user_id <> $3
+		ON CONFLICT (user_id, post_id, type) WHERE read_at IS NULL
+		DO UPDATE SET user_id = EXCLUDED.user_id
+		RETURNING id`, notificationType, postID, actorID).Scan(&notificationID)
+	if err == sql.ErrNoRows {
+		return nil, nil // Own post, or the post is gone
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to record notification: %w", err)
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO notification_actors (notification_id, actor_id) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`, notificationID, actorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record notification actor: %w", err)
+	}
+	newActors, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE notifications
+		SET last_actor_id = $2, actor_count = actor_count + $3, updated_at = NOW()
+		WHERE id = $1`, notificationID, actorID, newActors)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update notification: %w", err)
+	}
+
+	notification, err := scanNotification(tx.QueryRow(`
+		SELECT`+notificationColumns+`
+		FROM notifications n
+		JOIN users u ON n.last_actor_id = u.id
+		WHERE n.id = $1`, notificationID))
+	if err != nil {
+		return nil, err
+	}
+
+	var unreadCount int
+	err = tx.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL`, notification.UserID).Scan(&unreadCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+	msg, err := event(notification, unreadCount)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordOutbox(tx, msg); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return notification, nil
+}
+
+// FindByUserID lists a user's notifications, most recently updated first.
+// The cursor is on updated_at, so a notification folded into while paging
+// moves ahead of the cursor and is not returned by later pages.
+func (r *postgresNotificationRepository) FindByUserID(userID uint, limit int, cursor *pagination.Cursor) ([]*domain.Notification, error) {
+	var query string
+	var args []interface{}
+
+	if cursor == nil {
+		query = `
+			SELECT` + notificationColumns + `
+			FROM notifications n
+			JOIN users u ON n.last_actor_id = u.id
+			WHERE n.user_id = $1
+			ORDER BY n.updated_at DESC, n.id DESC
+			LIMIT $2`
+		args = []interface{}{userID, limit}
+	} else {
+		query = `
+			SELECT` + notificationColumns + `
+			FROM notifications n
+			JOIN users u ON n.last_actor_id = u.id
+			WHERE n.user_id = $1
+			  AND ((n.updated_at < $3) OR (n.updated_at = $3 AND n.id < $4))
+			ORDER BY n.updated_at DESC, n.id DESC
+			LIMIT $2`
+		args = []interface{}{userID, limit, cursor.Timestamp, cursor.ID}
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var notifications []*domain.Notification
+	for rows.Next() {
+		notification, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, notification)
+	}
+	return notifications, rows.Err()
+}
+
+func (r *postgresNotificationRepository) CountUnread(userID uint) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL`, userID).Scan(&count)
+	return count, err
+}
+
+// MarkRead marks one of userID's notifications read. It returns false when
+// the user has no such notification; marking it read again is a no-op.
+func (r *postgresNotificationRepository) MarkRead(id, userID uint) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE notifications SET read_at = COALESCE(read_at, NOW())
+		WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark notification read: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// MarkAllRead marks every unread notification of userID read and returns how many there were
+func (r *postgresNotificationRepository) MarkAllRead(userID uint) (int, error) {
+	result, err := r.db.Exec(`UPDATE notifications SET read_at = NOW() WHERE user_id = $1 AND read_at IS NULL`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(rowsAffected), nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanNotification(row rowScanner) (*domain.Notification, error) {
+	notification := &domain.Notification{}
+	err := row.Scan(
+		&notification.ID, &notification.UserID, &notification.Type, &notification.PostID,
+		&notification.ActorID, &notification.ActorUsername, &notification.ActorCount,
+		&notification.ReadAt, &notification.CreatedAt, &notification.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return notification, nil
+}
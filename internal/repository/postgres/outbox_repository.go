@@ -0,0 +1,125 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/rodolfodpk/instagrano/internal/domain"
+)
+
+// OutboxRepository reads and settles outbox messages. Messages are written by
+// the repositories making the change they describe, inside its transaction.
+type OutboxRepository interface {
+	// ClaimPending returns up to limit undelivered messages, oldest first, and
+	// hides them from other claims for the lease duration
+	ClaimPending(limit int, lease time.Duration) ([]*domain.OutboxMessage, error)
+	MarkDelivered(id int64) error
+	// MarkFailed records a failed attempt and makes the message available
+	// again after retryAfter, or dead-letters it when retryAfter is zero
+	MarkFailed(id int64, deliveryErr error, retryAfter time.Duration) error
+	// DeleteDelivered deletes up to limit messages delivered more than
+	// olderThan ago and returns how many were deleted
+	DeleteDelivered(olderThan time.Duration, limit int) (int, error)
+	CountPending() (int, error)
+	CountDead() (int, error)
+}
+
+type postgresOutboxRepository struct {
+	db *sql.DB
+}
+
+func NewOutboxRepository(db *sql.DB) OutboxRepository {
+	return &postgresOutboxRepository{db: db}
+}
+
+// recordOutbox writes msg as part of tx
+func recordOutbox(tx *sql.Tx, msg *domain.OutboxMessage) error {
+	err := tx.QueryRow(`
+		INSERT INTO outbox (event_type, payload) VALUES ($1, $2)
+		RETURNING id, created_at`, msg.EventType, string(msg.Payload)).Scan(&msg.ID, &msg.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record outbox message: %w", err)
+	}
+	return nil
+}
+
+// ClaimPending pushes available_at past the lease so concurrent relays skip
+// the claimed rows. A relay that dies mid-batch lets the lease run out and
+// the rows are claimed again, so messages may be delivered more than once.
+func (r *postgresOutboxRepository) ClaimPending(limit int, lease time.Duration) ([]*domain.OutboxMessage, error) {
+	rows, err := r.db.Query(`
+		UPDATE outbox SET available_at = NOW() + $2 * INTERVAL '1 millisecond'
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE delivered_at IS NULL AND dead_at IS NULL AND available_at <= NOW()
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, event_type, payload, attempts, created_at`, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []*domain.OutboxMessage
+	for rows.Next() {
+		msg := &domain.OutboxMessage{}
+		if err := rows.Scan(&msg.ID, &msg.EventType, &msg.Payload, &msg.Attempts, &msg.CreatedAt); err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// UPDATE ... RETURNING does not keep the subquery's order
+	sort.Slice(messages, func(i, j int) bool { return messages[i].ID < messages[j].ID })
+	return messages, nil
+}
+
+func (r *postgresOutboxRepository) MarkDelivered(id int64) error {
+	_, err := r.db.Exec(`UPDATE outbox SET delivered_at = NOW(), attempts = attempts + 1 WHERE id = $1`, id)
+	return err
+}
+
+func (r *postgresOutboxRepository) MarkFailed(id int64, deliveryErr error, retryAfter time.Duration) error {
+	_, err := r.db.Exec(`
+		UPDATE outbox SET attempts = attempts + 1, last_error = $2,
+			available_at = NOW() + $3 * INTERVAL '1 millisecond',
+			dead_at = CASE WHEN $3 = 0 THEN NOW() END
+		WHERE id = $1`, id, deliveryErr.Error(), retryAfter.Milliseconds())
+	return err
+}
+
+func (r *postgresOutboxRepository) DeleteDelivered(olderThan time.Duration, limit int) (int, error) {
+	result, err := r.db.Exec(`
+		DELETE FROM outbox WHERE id IN (
+			SELECT id FROM outbox
+			WHERE delivered_at < NOW() - $1 * INTERVAL '1 millisecond'
+			LIMIT $2
+		)`, olderThan.Milliseconds(), limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete delivered outbox messages: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(deleted), nil
+}
+
+func (r *postgresOutboxRepository) CountPending() (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM outbox WHERE delivered_at IS NULL AND dead_at IS NULL`).Scan(&count)
+	return count, err
+}
+
+func (r *postgresOutboxRepository) CountDead() (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM outbox WHERE dead_at IS NOT NULL`).Scan(&count)
+	return count, err
+}
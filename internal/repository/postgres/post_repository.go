@@ -2,18 +2,44 @@ package postgres
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/rodolfodpk/instagrano/internal/domain"
 	"github.com/rodolfodpk/instagrano/internal/pagination"
 )
 
+// PostEventFunc builds the outbox message for a post change. It runs inside
+// the change's transaction, after the post has been updated.
+type PostEventFunc func(post *domain.Post) (*domain.OutboxMessage, error)
+
 type PostRepository interface {
+	// Create saves the post with its media items and caption hashtags. A post
+	// without Media gets one item from its MediaType and MediaURL.
 	Create(post *domain.Post) error
+	// FindByID wraps sql.ErrNoRows when the post does not exist
 	FindByID(id uint) (*domain.Post, error)
 	GetByID(id uint) (*domain.Post, error)
 	GetFeed(limit, offset int) ([]*domain.Post, error)
 	GetFeedWithCursor(limit int, cursor *pagination.Cursor) ([]*domain.Post, error)
+	GetByUserIDsWithCursor(userIDs []uint, limit int, cursor *pagination.Cursor) ([]*domain.Post, error)
+	GetByHashtagWithCursor(tag string, limit int, cursor *pagination.Cursor) ([]*domain.Post, error)
+	FindByIDs(ids []uint) ([]*domain.Post, error)
+	FindIDsByUserID(userID uint) ([]uint, error)
+	// FindViewerStates returns what viewerID has done with each of the given
+	// posts, in one query. Unknown posts are left out.
+	FindViewerStates(viewerID uint, postIDs []uint) (map[uint]*domain.PostViewerState, error)
+	// UpdateWithOutbox saves the post's title, caption and caption hashtags,
+	// keeping the previous title and caption in its edit history, and records
+	// the event in the same transaction.
+	// It changes nothing and reports false if the post's updated_at is no
+	// longer version, and returns sql.ErrNoRows when the post no longer exists.
+	// When mentionEvent is not nil, the post's Mentions replace those of its
+	// previous caption in the same transaction, as MentionRepository.Replace
+	// does.
+	UpdateWithOutbox(post *domain.Post, version time.Time, event PostEventFunc, mentionEvent MentionEventFunc) (bool, error)
+	// Delete returns sql.ErrNoRows when the post does not exist
 	Delete(id uint) error
 }
 
@@ -26,59 +52,73 @@ func NewPostRepository(db *sql.DB) PostRepository {
 }
 
 func (r *postgresPostRepository) Create(post *domain.Post) error {
+	if len(post.Media) == 0 {
+		post.Media = []domain.PostMedia{{MediaType: post.MediaType, MediaURL: post.MediaURL}}
+	}
+	post.MediaType, post.MediaURL = post.Media[0].MediaType, post.Media[0].MediaURL
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
 		INSERT INTO posts (user_id, title, caption, media_type, media_url)
 		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id, created_at, updated_at`
-	return r.db.QueryRow(query, post.UserID, post.Title, post.Caption,
+	err = tx.QueryRow(query, post.UserID, post.Title, post.Caption,
 		post.MediaType, post.MediaURL).
 		Scan(&post.ID, &post.CreatedAt, &post.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	for i := range post.Media {
+		post.Media[i].Position = i
+		_, err := tx.Exec(`INSERT INTO post_media (post_id, position, media_type, media_url) VALUES ($1, $2, $3, $4)`,
+			post.ID, i, post.Media[i].MediaType, post.Media[i].MediaURL)
+		if err != nil {
+			return fmt.Errorf("failed to create post media: %w", err)
+		}
+	}
+
+	if err := saveHashtags(tx, post.ID, post.Caption); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
 }
 
 func (r *postgresPostRepository) FindByID(id uint) (*domain.Post, error) {
-	post := &domain.Post{}
 	query := `
-		SELECT p.id, p.user_id, u.username, p.title, p.caption, p.media_type, p.media_url,
-			   p.likes_count, p.comments_count, p.views_count, p.created_at, p.updated_at
+		SELECT` + postColumns + `
 		FROM posts p
 		JOIN users u ON p.user_id = u.id
 		WHERE p.id = $1`
-	err := r.db.QueryRow(query, id).Scan(
-		&post.ID, &post.UserID, &post.Username, &post.Title, &post.Caption, &post.MediaType,
-		&post.MediaURL, &post.LikesCount, &post.CommentsCount, &post.ViewsCount,
-		&post.CreatedAt, &post.UpdatedAt,
-	)
+	post, err := scanPost(r.db.QueryRow(query, id))
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("post with id %d not found", id)
+		return nil, fmt.Errorf("post with id %d not found: %w", id, err)
 	}
 	return post, err
 }
 
 func (r *postgresPostRepository) GetFeed(limit, offset int) ([]*domain.Post, error) {
 	query := `
-		SELECT id, user_id, title, caption, media_type, media_url,
-			   likes_count, comments_count, views_count, created_at, updated_at
-		FROM posts ORDER BY created_at DESC LIMIT $1 OFFSET $2`
+		SELECT` + postColumns + `
+		FROM posts p
+		JOIN users u ON p.user_id = u.id
+		ORDER BY p.created_at DESC LIMIT $1 OFFSET $2`
 	rows, err := r.db.Query(query, limit, offset)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var posts []*domain.Post
-	for rows.Next() {
-		post := &domain.Post{}
-		err := rows.Scan(
-			&post.ID, &post.UserID, &post.Username, &post.Title, &post.Caption, &post.MediaType,
-			&post.MediaURL, &post.LikesCount, &post.CommentsCount, &post.ViewsCount,
-			&post.CreatedAt, &post.UpdatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		posts = append(posts, post)
-	}
-	return posts, nil
+	return scanPosts(rows)
 }
 
 func (r *postgresPostRepository) GetFeedWithCursor(limit int, cursor *pagination.Cursor) ([]*domain.Post, error) {
@@ -88,8 +128,7 @@ func (r *postgresPostRepository) GetFeedWithCursor(limit int, cursor *pagination
 	if cursor == nil {
 		// First page - no cursor
 		query = `
-			SELECT p.id, p.user_id, u.username, p.title, p.caption, p.media_type, p.media_url,
-				   p.likes_count, p.comments_count, p.views_count, p.created_at, p.updated_at
+			SELECT` + postColumns + `
 			FROM posts p
 			JOIN users u ON p.user_id = u.id
 			ORDER BY p.created_at DESC, p.id DESC 
@@ -98,8 +137,7 @@ func (r *postgresPostRepository) GetFeedWithCursor(limit int, cursor *pagination
 	} else {
 		// Subsequent pages - use cursor
 		query = `
-			SELECT p.id, p.user_id, u.username, p.title, p.caption, p.media_type, p.media_url,
-				   p.likes_count, p.comments_count, p.views_count, p.created_at, p.updated_at
+			SELECT` + postColumns + `
 			FROM posts p
 			JOIN users u ON p.user_id = u.id
 			WHERE (p.created_at < $2) OR (p.created_at = $2 AND p.id < $3)
@@ -114,20 +152,238 @@ func (r *postgresPostRepository) GetFeedWithCursor(limit int, cursor *pagination
 	}
 	defer rows.Close()
 
-	var posts []*domain.Post
+	return scanPosts(rows)
+}
+
+// GetByUserIDsWithCursor returns posts authored by any of userIDs, newest first
+func (r *postgresPostRepository) GetByUserIDsWithCursor(userIDs []uint, limit int, cursor *pagination.Cursor) ([]*domain.Post, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	var query string
+	var args []interface{}
+
+	if cursor == nil {
+		query = `
+			SELECT` + postColumns + `
+			FROM posts p
+			JOIN users u ON p.user_id = u.id
+			WHERE p.user_id = ANY($2)
+			ORDER BY p.created_at DESC, p.id DESC
+			LIMIT $1`
+		args = []interface{}{limit, toInt64s(userIDs)}
+	} else {
+		query = `
+			SELECT` + postColumns + `
+			FROM posts p
+			JOIN users u ON p.user_id = u.id
+			WHERE p.user_id = ANY($2)
+			  AND ((p.created_at < $3) OR (p.created_at = $3 AND p.id < $4))
+			ORDER BY p.created_at DESC, p.id DESC
+			LIMIT $1`
+		args = []interface{}{limit, toInt64s(userIDs), cursor.Timestamp, cursor.ID}
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query posts by users: %w", err)
+	}
+	defer rows.Close()
+
+	return scanPosts(rows)
+}
+
+// GetByHashtagWithCursor returns posts whose caption has tag, newest first
+func (r *postgresPostRepository) GetByHashtagWithCursor(tag string, limit int, cursor *pagination.Cursor) ([]*domain.Post, error) {
+	var query string
+	var args []interface{}
+
+	if cursor == nil {
+		query = `
+			SELECT` + postColumns + `
+			FROM posts p
+			JOIN users u ON p.user_id = u.id
+			JOIN post_hashtags ph ON ph.post_id = p.id
+			JOIN hashtags h ON h.id = ph.hashtag_id
+			WHERE h.name = $2
+			ORDER BY p.created_at DESC, p.id DESC
+			LIMIT $1`
+		args = []interface{}{limit, tag}
+	} else {
+		query = `
+			SELECT` + postColumns + `
+			FROM posts p
+			JOIN users u ON p.user_id = u.id
+			JOIN post_hashtags ph ON ph.post_id = p.id
+			JOIN hashtags h ON h.id = ph.hashtag_id
+			WHERE h.name = $2
+			  AND ((p.created_at < $3) OR (p.created_at = $3 AND p.id < $4))
+			ORDER BY p.created_at DESC, p.id DESC
+			LIMIT $1`
+		args = []interface{}{limit, tag, cursor.Timestamp, cursor.ID}
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query posts by hashtag: %w", err)
+	}
+	defer rows.Close()
+
+	return scanPosts(rows)
+}
+
+// FindByIDs loads the given posts. Unknown IDs are skipped and order is not preserved.
+func (r *postgresPostRepository) FindByIDs(ids []uint) ([]*domain.Post, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		SELECT` + postColumns + `
+		FROM posts p
+		JOIN users u ON p.user_id = u.id
+		WHERE p.id = ANY($1)`
+	rows, err := r.db.Query(query, toInt64s(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query posts by ids: %w", err)
+	}
+	defer rows.Close()
+
+	return scanPosts(rows)
+}
+
+// FindIDsByUserID returns the IDs of every post by userID
+func (r *postgresPostRepository) FindIDsByUserID(userID uint) ([]uint, error) {
+	rows, err := r.db.Query(`SELECT id FROM posts WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query post ids: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uint
 	for rows.Next() {
-		post := &domain.Post{}
-		err := rows.Scan(
-			&post.ID, &post.UserID, &post.Username, &post.Title, &post.Caption, &post.MediaType,
-			&post.MediaURL, &post.LikesCount, &post.CommentsCount, &post.ViewsCount,
-			&post.CreatedAt, &post.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan post: %w", err)
+		var id uint
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan post id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *postgresPostRepository) FindViewerStates(viewerID uint, postIDs []uint) (map[uint]*domain.PostViewerState, error) {
+	states := make(map[uint]*domain.PostViewerState, len(postIDs))
+	if len(postIDs) == 0 {
+		return states, nil
+	}
+
+	query := `
+		SELECT p.id, COALESCE(l.reaction, ''),
+		       EXISTS(SELECT 1 FROM comments c WHERE c.user_id = $1 AND c.post_id = p.id),
+		       EXISTS(SELECT 1 FROM saves s WHERE s.user_id = $1 AND s.post_id = p.id)
+		FROM posts p
+		LEFT JOIN likes l ON l.post_id = p.id AND l.user_id = $1
+		WHERE p.id = ANY($2)`
+	rows, err := r.db.Query(query, viewerID, toInt64s(postIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query viewer states: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postID uint
+		state := &domain.PostViewerState{}
+		if err := rows.Scan(&postID, &state.Reaction, &state.Commented, &state.Saved); err != nil {
+			return nil, fmt.Errorf("failed to scan viewer state: %w", err)
+		}
+		states[postID] = state
+	}
+	return states, rows.Err()
+}
+
+func (r *postgresPostRepository) UpdateWithOutbox(post *domain.Post, version time.Time, event PostEventFunc, mentionEvent MentionEventFunc) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var title, caption string
+	var updatedAt time.Time
+	err = tx.QueryRow(`SELECT title, COALESCE(caption, ''), updated_at FROM posts WHERE id = $1 FOR UPDATE`, post.ID).
+		Scan(&title, &caption, &updatedAt)
+	if err == sql.ErrNoRows {
+		return false, err
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to lock post: %w", err)
+	}
+	if !updatedAt.Equal(version) {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(`INSERT INTO post_edits (post_id, title, caption) VALUES ($1, $2, $3)`, post.ID, title, caption); err != nil {
+		return false, fmt.Errorf("failed to record post edit: %w", err)
+	}
+	err = tx.QueryRow(`UPDATE posts SET title = $2, caption = $3, updated_at = NOW() WHERE id = $1 RETURNING updated_at`,
+		post.ID, post.Title, post.Caption).Scan(&post.UpdatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to update post: %w", err)
+	}
+	if err := saveHashtags(tx, post.ID, post.Caption); err != nil {
+		return false, err
+	}
+	if mentionEvent != nil {
+		if err := replaceMentions(tx, post.ID, nil, post.UserID, post.Mentions, mentionEvent); err != nil {
+			return false, err
 		}
-		posts = append(posts, post)
 	}
-	return posts, nil
+
+	msg, err := event(post)
+	if err != nil {
+		return false, err
+	}
+	if err := recordOutbox(tx, msg); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// saveHashtags links the post to the hashtags in its caption, creating any
+// new ones, and unlinks the hashtags the caption no longer has
+func saveHashtags(tx *sql.Tx, postID uint, caption string) error {
+	tags := domain.ParseHashtags(caption)
+	if tags == nil {
+		tags = []string{}
+	}
+
+	_, err := tx.Exec(`
+		DELETE FROM post_hashtags
+		WHERE post_id = $1
+		  AND hashtag_id NOT IN (SELECT id FROM hashtags WHERE name = ANY($2))`, postID, tags)
+	if err != nil {
+		return fmt.Errorf("failed to remove post hashtags: %w", err)
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(`INSERT INTO hashtags (name) SELECT unnest($1::text[]) ON CONFLICT (name) DO NOTHING`, tags); err != nil {
+		return fmt.Errorf("failed to create hashtags: %w", err)
+	}
+	_, err = tx.Exec(`
+		INSERT INTO post_hashtags (post_id, hashtag_id)
+		SELECT $1, id FROM hashtags WHERE name = ANY($2)
+		ON CONFLICT (post_id, hashtag_id) DO NOTHING`, postID, tags)
+	if err != nil {
+		return fmt.Errorf("failed to link post hashtags: %w", err)
+	}
+	return nil
 }
 
 // GetByID gets a post by ID (alias for FindByID for consistency)
@@ -149,8 +405,67 @@ func (r *postgresPostRepository) Delete(id uint) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("post not found")
+		return sql.ErrNoRows
 	}
 
 	return nil
 }
+
+// postColumns selects a post with its author's username and like list
+// setting, and its media items as a JSON array
+const postColumns = `
+	p.id, p.user_id, u.username, u.hide_likers, p.title, p.caption, p.media_type, p.media_url,
+	COALESCE((
+		SELECT json_agg(json_build_object('position', m.position, 'media_type', m.media_type, 'media_url', m.media_url) ORDER BY m.position)
+		FROM post_media m WHERE m.post_id = p.id
+	), '[]'),
+	COALESCE((
+		SELECT json_agg(json_build_object('user_id', mn.user_id, 'username', mu.username, 'offset', mn.text_offset, 'length', mn.text_length) ORDER BY mn.text_offset)
+		FROM mentions mn JOIN users mu ON mu.id = mn.user_id
+		WHERE mn.post_id = p.id AND mn.comment_id IS NULL
+	), '[]'),
+	p.likes_count, p.reaction_counts, p.comments_count, p.views_count, p.saves_count, p.created_at, p.updated_at`
+
+func scanPosts(rows *sql.Rows) ([]*domain.Post, error) {
+	var posts []*domain.Post
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan post: %w", err)
+		}
+		posts = append(posts, post)
+	}
+	return posts, rows.Err()
+}
+
+func scanPost(row rowScanner) (*domain.Post, error) {
+	post := &domain.Post{}
+	var media, mentions, reactionCounts []byte
+	err := row.Scan(
+		&post.ID, &post.UserID, &post.Username, &post.HideLikers, &post.Title, &post.Caption, &post.MediaType,
+		&post.MediaURL, &media, &mentions, &post.LikesCount, &reactionCounts, &post.CommentsCount, &post.ViewsCount,
+		&post.SavesCount, &post.CreatedAt, &post.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(media, &post.Media); err != nil {
+		return nil, fmt.Errorf("failed to decode post media: %w", err)
+	}
+	if err := json.Unmarshal(mentions, &post.Mentions); err != nil {
+		return nil, fmt.Errorf("failed to decode post mentions: %w", err)
+	}
+	if err := json.Unmarshal(reactionCounts, &post.ReactionCounts); err != nil {
+		return nil, fmt.Errorf("failed to decode reaction counts: %w", err)
+	}
+	return post, nil
+}
+
+// toInt64s converts IDs for use with ANY($n) array parameters
+func toInt64s(ids []uint) []int64 {
+	result := make([]int64, len(ids))
+	for i, id := range ids {
+		result[i] = int64(id)
+	}
+	return result
+}
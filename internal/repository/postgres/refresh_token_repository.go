@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/rodolfodpk/instagrano/internal/domain"
+)
+
+type RefreshTokenRepository interface {
+	Create(token *domain.RefreshToken) error
+	FindByHash(tokenHash string) (*domain.RefreshToken, error)
+	Rotate(oldID uint, newToken *domain.RefreshToken) (bool, error)
+	RevokeFamily(familyID string) error
+}
+
+type postgresRefreshTokenRepository struct {
+	db *sql.DB
+}
+
+func NewRefreshTokenRepository(db *sql.DB) RefreshTokenRepository {
+	return &postgresRefreshTokenRepository{db: db}
+}
+
+func (r *postgresRefreshTokenRepository) Create(token *domain.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (user_id, token_hash, family_id, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`
+	return r.db.QueryRow(query, token.UserID, token.TokenHash, token.FamilyID, token.ExpiresAt).
+		Scan(&token.ID, &token.CreatedAt)
+}
+
+func (r *postgresRefreshTokenRepository) FindByHash(tokenHash string) (*domain.RefreshToken, error) {
+	token := &domain.RefreshToken{}
+	query := `
+		SELECT id, user_id, token_hash, family_id, expires_at, revoked_at, replaced_by, created_at
+		FROM refresh_tokens WHERE token_hash = $1`
+	var replacedBy sql.NullInt64
+	err := r.db.QueryRow(query, tokenHash).Scan(
+		&token.ID, &token.UserID, &token.TokenHash, &token.FamilyID,
+		&token.ExpiresAt, &token.RevokedAt, &replacedBy, &token.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found, but not an error
+		}
+		return nil, err
+	}
+	if replacedBy.Valid {
+		id := uint(replacedBy.Int64)
+		token.ReplacedBy = &id
+	}
+	return token, nil
+}
+
+// Rotate atomically revokes the old token and stores its replacement. It returns
+// false when the old token was already revoked, which signals token reuse.
+func (r *postgresRefreshTokenRepository) Rotate(oldID uint, newToken *domain.RefreshToken) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE refresh_tokens SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`, oldID)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	insert := `
+		INSERT INTO refresh_tokens (user_id, token_hash, family_id, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`
+	if err := tx.QueryRow(insert, newToken.UserID, newToken.TokenHash, newToken.FamilyID, newToken.ExpiresAt).
+		Scan(&newToken.ID, &newToken.CreatedAt); err != nil {
+		return false, fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	if _, err := tx.Exec(`UPDATE refresh_tokens SET replaced_by = $1 WHERE id = $2`, newToken.ID, oldID); err != nil {
+		return false, fmt.Errorf("failed to link rotated refresh token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// RevokeFamily revokes every token that descends from the same login
+func (r *postgresRefreshTokenRepository) RevokeFamily(familyID string) error {
+	query := `UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id = $1 AND revoked_at IS NULL`
+	_, err := r.db.Exec(query, familyID)
+	return err
+}
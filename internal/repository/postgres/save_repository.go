@@ -0,0 +1,278 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rodolfodpk/instagrano/internal/domain"
+	"github.com/rodolfodpk/instagrano/internal/pagination"
+)
+
+// Constraints whose violations the save repository reports, for callers to
+// tell rows removed by a concurrent request apart (see ViolatedConstraint)
+const (
+	ConstraintSavePost       = "saves_post_id_fkey"
+	ConstraintSaveCollection = "saves_collection_id_fkey"
+	ConstraintCollectionName = "collections_user_id_name_key"
+)
+
+type SaveRepository interface {
+	// Save saves the post for userID in collectionID, or in no collection
+	// when nil, moving it there if it was already saved, and bumps the post's
+	// save count in the same transaction. It returns false when the post was
+	// already saved, and violates ConstraintSavePost or ConstraintSaveCollection
+	// when the post or collection does not exist.
+	Save(userID, postID uint, collectionID *uint) (bool, error)
+	// Unsave removes the post from userID's saves and decrements the post's
+	// save count in one transaction. It returns false when it was not saved.
+	Unsave(userID, postID uint) (bool, error)
+	// FindSaved lists userID's saved posts, or those in collectionID when it
+	// is not nil, most recently saved first
+	FindSaved(userID uint, collectionID *uint, limit int, cursor *pagination.Cursor) ([]*domain.SavedPost, error)
+
+	// CreateCollection returns sql.ErrNoRows when the user already has a
+	// collection with that name
+	CreateCollection(collection *domain.Collection) error
+	FindCollection(id uint) (*domain.Collection, error)
+	FindCollections(userID uint) ([]*domain.Collection, error)
+	// RenameCollection returns false when another collection of the same
+	// user has that name, and sql.ErrNoRows when the collection does not
+	// exist. A collection given that name concurrently violates
+	// ConstraintCollectionName instead.
+	RenameCollection(id uint, name string) (bool, error)
+	// DeleteCollection deletes the collection. Its posts stay saved.
+	DeleteCollection(id uint) error
+}
+
+type postgresSaveRepository struct {
+	db *sql.DB
+}
+
+func NewSaveRepository(db *sql.DB) SaveRepository {
+	return &postgresSaveRepository{db: db}
+}
+
+func (r *postgresSaveRepository) Save(userID, postID uint, collectionID *uint) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO saves (user_id, post_id, collection_id) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, post_id) DO NOTHING`, userID, postID, collectionID)
+	if err != nil {
+		return false, fmt.Errorf("failed to create save: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	created := rowsAffected > 0
+	if created {
+		if _, err := tx.Exec(`UPDATE posts SET saves_count = saves_count + 1 WHERE id = $1`, postID); err != nil {
+			return false, fmt.Errorf("failed to update saves count: %w", err)
+		}
+	} else {
+		_, err := tx.Exec(`UPDATE saves SET collection_id = $3 WHERE user_id = $1 AND post_id = $2`,
+			userID, postID, collectionID)
+		if err != nil {
+			return false, fmt.Errorf("failed to move save: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return created, nil
+}
+
+func (r *postgresSaveRepository) Unsave(userID, postID uint) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM saves WHERE user_id = $1 AND post_id = $2`, userID, postID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete save: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(`UPDATE posts SET saves_count = saves_count - 1 WHERE id = $1`, postID); err != nil {
+		return false, fmt.Errorf("failed to update saves count: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+func (r *postgresSaveRepository) FindSaved(userID uint, collectionID *uint, limit int, cursor *pagination.Cursor) ([]*domain.SavedPost, error) {
+	conditions := []string{"s.user_id = $2"}
+	args := []interface{}{limit, userID}
+	if collectionID != nil {
+		args = append(args, *collectionID)
+		conditions = append(conditions, fmt.Sprintf("s.collection_id = $%d", len(args)))
+	}
+	if cursor != nil {
+		args = append(args, cursor.Timestamp, cursor.ID)
+		conditions = append(conditions, fmt.Sprintf("((s.created_at < $%d) OR (s.created_at = $%d AND s.post_id < $%d))",
+			len(args)-1, len(args)-1, len(args)))
+	}
+
+	query := `
+		SELECT` + postColumns + `, s.collection_id, s.created_at
+		FROM saves s
+		JOIN posts p ON s.post_id = p.id
+		JOIN users u ON p.user_id = u.id
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY s.created_at DESC, s.post_id DESC
+		LIMIT $1`
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query saved posts: %w", err)
+	}
+	defer rows.Close()
+
+	var saved []*domain.SavedPost
+	for rows.Next() {
+		save := &domain.SavedPost{}
+		var collectionID sql.NullInt64
+		post, err := scanPost(withColumns(rows, &collectionID, &save.SavedAt))
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan saved post: %w", err)
+		}
+		save.Post = post
+		if collectionID.Valid {
+			id := uint(collectionID.Int64)
+			save.CollectionID = &id
+		}
+		saved = append(saved, save)
+	}
+	return saved, rows.Err()
+}
+
+func (r *postgresSaveRepository) CreateCollection(collection *domain.Collection) error {
+	query := `
+		INSERT INTO collections (user_id, name) VALUES ($1, $2)
+		ON CONFLICT (user_id, name) DO NOTHING
+		RETURNING id, created_at`
+	return r.db.QueryRow(query, collection.UserID, collection.Name).Scan(&collection.ID, &collection.CreatedAt)
+}
+
+func (r *postgresSaveRepository) FindCollection(id uint) (*domain.Collection, error) {
+	query := `SELECT` + collectionColumns + ` FROM collections c WHERE c.id = $1`
+	return scanCollection(r.db.QueryRow(query, id))
+}
+
+// FindCollections lists userID's collections in the order they were created
+func (r *postgresSaveRepository) FindCollections(userID uint) ([]*domain.Collection, error) {
+	query := `SELECT` + collectionColumns + ` FROM collections c WHERE c.user_id = $1 ORDER BY c.id`
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query collections: %w", err)
+	}
+	defer rows.Close()
+
+	var collections []*domain.Collection
+	for rows.Next() {
+		collection, err := scanCollection(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan collection: %w", err)
+		}
+		collections = append(collections, collection)
+	}
+	return collections, rows.Err()
+}
+
+func (r *postgresSaveRepository) RenameCollection(id uint, name string) (bool, error) {
+	query := `
+		UPDATE collections c SET name = $2
+		WHERE c.id = $1 AND NOT EXISTS (
+			SELECT 1 FROM collections o WHERE o.user_id = c.user_id AND o.name = $2 AND o.id <> c.id
+		)`
+	result, err := r.db.Exec(query, id, name)
+	if err != nil {
+		return false, fmt.Errorf("failed to rename collection: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected > 0 {
+		return true, nil
+	}
+
+	// Nothing renamed: tell a taken name apart from a deleted collection
+	var exists bool
+	if err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM collections WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check collection: %w", err)
+	}
+	if !exists {
+		return false, sql.ErrNoRows
+	}
+	return false, nil
+}
+
+func (r *postgresSaveRepository) DeleteCollection(id uint) error {
+	if _, err := r.db.Exec(`DELETE FROM collections WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete collection: %w", err)
+	}
+	return nil
+}
+
+// ViolatedConstraint returns the name of the unique or foreign key
+// constraint err violates, or "" if it violates none
+func ViolatedConstraint(err error) string {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return ""
+	}
+	switch pgErr.Code {
+	case "23503", "23505": // foreign_key_violation, unique_violation
+		return pgErr.ConstraintName
+	}
+	return ""
+}
+
+// collectionColumns selects a collection with the number of posts saved in it
+const collectionColumns = `
+	c.id, c.user_id, c.name, (SELECT COUNT(*) FROM saves s WHERE s.collection_id = c.id), c.created_at`
+
+func scanCollection(row rowScanner) (*domain.Collection, error) {
+	collection := &domain.Collection{}
+	err := row.Scan(&collection.ID, &collection.UserID, &collection.Name, &collection.PostsCount, &collection.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
+
+// extraColumns scans the columns selected after postColumns
+type extraColumns struct {
+	row  rowScanner
+	dest []interface{}
+}
+
+// withColumns lets scanPost read rows with more columns after postColumns
+func withColumns(row rowScanner, dest ...interface{}) rowScanner {
+	return extraColumns{row: row, dest: dest}
+}
+
+func (e extraColumns) Scan(dest ...interface{}) error {
+	return e.row.Scan(append(dest, e.dest...)...)
+}
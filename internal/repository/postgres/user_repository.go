@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"database/sql"
+	"fmt"
 
 	"github.com/rodolfodpk/instagrano/internal/domain"
 )
@@ -10,6 +11,11 @@ type UserRepository interface {
 	Create(user *domain.User) error
 	FindByEmail(email string) (*domain.User, error)
 	FindByID(id uint) (*domain.User, error)
+	// FindByUsernames loads the users with the given usernames. Unknown
+	// usernames are skipped and order is not preserved.
+	FindByUsernames(usernames []string) ([]*domain.User, error)
+	// UpdateSettings saves the user's preferences
+	UpdateSettings(user *domain.User) error
 }
 
 type postgresUserRepository struct {
@@ -31,20 +37,52 @@ func (r *postgresUserRepository) Create(user *domain.User) error {
 
 func (r *postgresUserRepository) FindByEmail(email string) (*domain.User, error) {
 	user := &domain.User{}
-	query := `SELECT id, username, email, password, created_at, updated_at FROM users WHERE email = $1`
+	query := `SELECT id, username, email, password, followers_count, following_count, hide_likers, created_at, updated_at FROM users WHERE email = $1`
 	err := r.db.QueryRow(query, email).Scan(
 		&user.ID, &user.Username, &user.Email, &user.Password,
-		&user.CreatedAt, &user.UpdatedAt,
+		&user.FollowersCount, &user.FollowingCount, &user.HideLikers, &user.CreatedAt, &user.UpdatedAt,
 	)
 	return user, err
 }
 
 func (r *postgresUserRepository) FindByID(id uint) (*domain.User, error) {
 	user := &domain.User{}
-	query := `SELECT id, username, email, password, created_at, updated_at FROM users WHERE id = $1`
+	query := `SELECT id, username, email, password, followers_count, following_count, hide_likers, created_at, updated_at FROM users WHERE id = $1`
 	err := r.db.QueryRow(query, id).Scan(
 		&user.ID, &user.Username, &user.Email, &user.Password,
-		&user.CreatedAt, &user.UpdatedAt,
+		&user.FollowersCount, &user.FollowingCount, &user.HideLikers, &user.CreatedAt, &user.UpdatedAt,
 	)
 	return user, err
 }
+
+func (r *postgresUserRepository) FindByUsernames(usernames []string) ([]*domain.User, error) {
+	if len(usernames) == 0 {
+		return nil, nil
+	}
+
+	query := `SELECT id, username, email, password, followers_count, following_count, hide_likers, created_at, updated_at FROM users WHERE username = ANY($1)`
+	rows, err := r.db.Query(query, usernames)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users by username: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*domain.User
+	for rows.Next() {
+		user := &domain.User{}
+		err := rows.Scan(
+			&user.ID, &user.Username, &user.Email, &user.Password,
+			&user.FollowersCount, &user.FollowingCount, &user.HideLikers, &user.CreatedAt, &user.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func (r *postgresUserRepository) UpdateSettings(user *domain.User) error {
+	query := `UPDATE users SET hide_likers = $2, updated_at = NOW() WHERE id = $1 RETURNING updated_at`
+	return r.db.QueryRow(query, user.ID, user.HideLikers).Scan(&user.UpdatedAt)
+}
@@ -0,0 +1,235 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rodolfodpk/instagrano/internal/domain"
+)
+
+type WebhookRepository interface {
+	Create(webhook *domain.Webhook) error
+	FindByID(id uint) (*domain.Webhook, error)
+	FindByUserID(userID uint) ([]*domain.Webhook, error)
+	Delete(id uint) error
+
+	// EnqueueDeliveries queues an event for every webhook subscribed to it
+	// and returns how many deliveries were queued. Events already queued for
+	// a webhook are skipped.
+	EnqueueDeliveries(eventID, eventType string, postID, triggeredByUserID uint, payload []byte) (int, error)
+	// ClaimDueDeliveries returns up to limit pending deliveries that are due,
+	// oldest first, with their webhook, and hides them from other claims for
+	// the lease duration
+	ClaimDueDeliveries(limit int, lease time.Duration) ([]*domain.WebhookDelivery, error)
+	MarkDelivered(id int64, responseStatus int) error
+	// MarkFailed records a failed attempt. The delivery is retried after
+	// retryAfter, or given up on when retryAfter is zero.
+	MarkFailed(id int64, responseStatus int, deliveryErr error, retryAfter time.Duration) error
+	FindDeliveries(webhookID uint, limit int) ([]*domain.WebhookDelivery, error)
+}
+
+type postgresWebhookRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) WebhookRepository {
+	return &postgresWebhookRepository{db: db}
+}
+
+func (r *postgresWebhookRepository) Create(webhook *domain.Webhook) error {
+	query := `
+		INSERT INTO webhooks (user_id, url, secret, scope, event_types)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`
+	return r.db.QueryRow(query, webhook.UserID, webhook.URL, webhook.Secret, webhook.Scope,
+		strings.Join(webhook.EventTypes, ",")).Scan(&webhook.ID, &webhook.CreatedAt)
+}
+
+func (r *postgresWebhookRepository) FindByID(id uint) (*domain.Webhook, error) {
+	webhook := &domain.Webhook{}
+	var eventTypes string
+	query := `SELECT id, user_id, url, secret, scope, event_types, created_at FROM webhooks WHERE id = $1`
+	err := r.db.QueryRow(query, id).Scan(
+		&webhook.ID, &webhook.UserID, &webhook.URL, &webhook.Secret,
+		&webhook.Scope, &eventTypes, &webhook.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	webhook.EventTypes = strings.Split(eventTypes, ",")
+	return webhook, nil
+}
+
+func (r *postgresWebhookRepository) FindByUserID(userID uint) ([]*domain.Webhook, error) {
+	query := `
+		SELECT id, user_id, url, secret, scope, event_types, created_at
+		FROM webhooks WHERE user_id = $1
+		ORDER BY id`
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var webhooks []*domain.Webhook
+	for rows.Next() {
+		webhook := &domain.Webhook{}
+		var eventTypes string
+		if err := rows.Scan(&webhook.ID, &webhook.UserID, &webhook.URL, &webhook.Secret,
+			&webhook.Scope, &eventTypes, &webhook.CreatedAt); err != nil {
+			return nil, err
+		}
+		webhook.EventTypes = strings.Split(eventTypes, ",")
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+// Delete removes a webhook along with its deliveries
+func (r *postgresWebhookRepository) Delete(id uint) error {
+	_, err := r.db.Exec(`DELETE FROM webhooks WHERE id = $1`, id)
+	return err
+}
+
+// EnqueueDeliveries matches user-scoped webhooks against the post's author as
+// well as the user who triggered the event. Deleted posts no longer have an
+// author, but only their author can delete them.
+func (r *postgresWebhookRepository) EnqueueDeliveries(eventID, eventType string, postID, triggeredByUserID uint, payload []byte) (int, error) {
+	query := `
+		INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload)
+		SELECT w.id, $1::VARCHAR, $2::VARCHAR, $5::JSONB
+		FROM webhooks w
+		WHERE $2::VARCHAR = ANY(string_to_array(w.event_types, ','))
+		  AND (w.scope = 'app'
+		       OR w.user_id = $4
+		       OR w.user_id = (SELECT user_id FROM posts WHERE id = $3))
+		ON CONFLICT (webhook_id, event_id) DO NOTHING`
+	result, err := r.db.Exec(query, eventID, eventType, postID, triggeredByUserID, string(payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue webhook deliveries: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(rowsAffected), nil
+}
+
+// ClaimDueDeliveries pushes next_attempt_at past the lease so concurrent
+// workers skip the claimed rows, like OutboxRepository.ClaimPending
+func (r *postgresWebhookRepository) ClaimDueDeliveries(limit int, lease time.Duration) ([]*domain.WebhookDelivery, error) {
+	query := `
+		WITH claimed AS (
+			UPDATE webhook_deliveries SET next_attempt_at = NOW() + $2 * INTERVAL '1 millisecond'
+			WHERE id IN (
+				SELECT id FROM webhook_deliveries
+				WHERE status = 'pending' AND next_attempt_at <= NOW()
+				ORDER BY id
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, webhook_id, event_id, event_type, payload, status, attempts, next_attempt_at, created_at
+		)
+		SELECT c.id, c.webhook_id, c.event_id, c.event_type, c.payload, c.status, c.attempts, c.next_attempt_at, c.created_at,
+		       w.user_id, w.url, w.secret, w.scope, w.event_types, w.created_at
+		FROM claimed c
+		JOIN webhooks w ON c.webhook_id = w.id`
+	rows, err := r.db.Query(query, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*domain.WebhookDelivery
+	for rows.Next() {
+		delivery := &domain.WebhookDelivery{Webhook: &domain.Webhook{}}
+		var eventTypes string
+		if err := rows.Scan(
+			&delivery.ID, &delivery.WebhookID, &delivery.EventID, &delivery.EventType, &delivery.Payload,
+			&delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &delivery.CreatedAt,
+			&delivery.Webhook.UserID, &delivery.Webhook.URL, &delivery.Webhook.Secret,
+			&delivery.Webhook.Scope, &eventTypes, &delivery.Webhook.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		delivery.Webhook.ID = delivery.WebhookID
+		delivery.Webhook.EventTypes = strings.Split(eventTypes, ",")
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID < deliveries[j].ID })
+	return deliveries, nil
+}
+
+func (r *postgresWebhookRepository) MarkDelivered(id int64, responseStatus int) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'delivered', attempts = attempts + 1, response_status = $2,
+		    last_error = NULL, delivered_at = NOW()
+		WHERE id = $1`
+	_, err := r.db.Exec(query, id, responseStatus)
+	return err
+}
+
+func (r *postgresWebhookRepository) MarkFailed(id int64, responseStatus int, deliveryErr error, retryAfter time.Duration) error {
+	status := domain.WebhookDeliveryPending
+	if retryAfter == 0 {
+		status = domain.WebhookDeliveryFailed
+	}
+	var responseStatusValue interface{}
+	if responseStatus != 0 {
+		responseStatusValue = responseStatus
+	}
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = attempts + 1, response_status = $3, last_error = $4,
+		    next_attempt_at = NOW() + $5 * INTERVAL '1 millisecond'
+		WHERE id = $1`
+	_, err := r.db.Exec(query, id, status, responseStatusValue, deliveryErr.Error(), retryAfter.Milliseconds())
+	return err
+}
+
+// FindDeliveries lists a webhook's most recent deliveries, newest first
+func (r *postgresWebhookRepository) FindDeliveries(webhookID uint, limit int) ([]*domain.WebhookDelivery, error) {
+	query := `
+		SELECT id, webhook_id, event_id, event_type, status, attempts, response_status,
+		       last_error, next_attempt_at, delivered_at, created_at
+		FROM webhook_deliveries
+		WHERE webhook_id = $1
+		ORDER BY id DESC
+		LIMIT $2`
+	rows, err := r.db.Query(query, webhookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*domain.WebhookDelivery
+	for rows.Next() {
+		delivery := &domain.WebhookDelivery{}
+		var responseStatus sql.NullInt64
+		var lastError sql.NullString
+		if err := rows.Scan(
+			&delivery.ID, &delivery.WebhookID, &delivery.EventID, &delivery.EventType,
+			&delivery.Status, &delivery.Attempts, &responseStatus, &lastError,
+			&delivery.NextAttemptAt, &delivery.DeliveredAt, &delivery.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if responseStatus.Valid {
+			status := int(responseStatus.Int64)
+			delivery.ResponseStatus = &status
+		}
+		if lastError.Valid {
+			delivery.LastError = &lastError.String
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
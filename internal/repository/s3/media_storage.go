@@ -20,6 +20,7 @@ type MediaStorage interface {
 	Upload(file io.Reader, filename string, contentType string) (string, error)
 	UploadFromURL(url string) (string, string, error) // NEW: returns (key, contentType, error)
 	GetURL(key string) string
+	Delete(key string) error
 	CreateBucketIfNotExists() error
 }
 
@@ -68,7 +69,8 @@ func NewMediaStorage(endpoint, region, bucket string, webclientConfig webclient.
 }
 
 func (s *localStackS3Storage) Upload(file io.Reader, filename string, contentType string) (string, error) {
-	key := fmt.Sprintf("posts/%d-%s", time.Now().Unix(), filename)
+	// Nanoseconds keep concurrent uploads of the same filename apart
+	key := fmt.Sprintf("posts/%d-%s", time.Now().UnixNano(), filename)
 	s.logger.Info("uploading file to s3",
 		zap.String("bucket", s.bucket),
 		zap.String("key", key),
@@ -109,6 +111,22 @@ func (s *localStackS3Storage) GetURL(key string) string {
 	return fmt.Sprintf("%s/%s/%s", s.endpoint, s.bucket, key)
 }
 
+// Delete removes an uploaded object
+func (s *localStackS3Storage) Delete(key string) error {
+	_, err := s.s3Client.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		s.logger.Error("s3 delete failed",
+			zap.String("key", key),
+			zap.Error(err),
+		)
+		return err
+	}
+	return nil
+}
+
 // UploadFromURL downloads media from a URL and uploads it to S3
 func (s *localStackS3Storage) UploadFromURL(url string) (string, string, error) {
 	s.logger.Info("downloading media from URL", zap.String("url", url))
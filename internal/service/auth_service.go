@@ -1,29 +1,68 @@
 package service
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/rodolfodpk/instagrano/internal/cache"
 	"github.com/rodolfodpk/instagrano/internal/domain"
 	"github.com/rodolfodpk/instagrano/internal/repository/postgres"
 	"golang.org/x/crypto/bcrypt"
 )
 
 var (
-	ErrInvalidInput       = errors.New("invalid input")
-	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrInvalidInput        = errors.New("invalid input")
+	ErrInvalidCredentials  = errors.New("invalid credentials")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
 )
 
+const (
+	revokedTokenKeyPrefix   = "revoked:jti:"
+	revokedSessionKeyPrefix = "revoked:sid:"
+)
+
+// TokenPair is the result of a login or refresh: a short-lived access token
+// plus the refresh token that can be exchanged for the next pair.
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    time.Time
+}
+
+// AccessClaims are the claims carried by an access token
+type AccessClaims struct {
+	UserID    uint
+	Username  string
+	TokenID   string
+	SessionID string
+	ExpiresAt time.Time
+}
+
 type AuthService struct {
-	userRepo  postgres.UserRepository
-	jwtSecret string
+	userRepo         postgres.UserRepository
+	refreshTokenRepo postgres.RefreshTokenRepository
+	cache            cache.Cache
+	jwtSecret        string
+	accessTokenTTL   time.Duration
+	refreshTokenTTL  time.Duration
 }
 
-func NewAuthService(userRepo postgres.UserRepository, jwtSecret string) *AuthService {
+func NewAuthService(userRepo postgres.UserRepository, refreshTokenRepo postgres.RefreshTokenRepository, cache cache.Cache, jwtSecret string, accessTokenTTL, refreshTokenTTL time.Duration) *AuthService {
 	return &AuthService{
-		userRepo:  userRepo,
-		jwtSecret: jwtSecret,
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		cache:            cache,
+		jwtSecret:        jwtSecret,
+		accessTokenTTL:   accessTokenTTL,
+		refreshTokenTTL:  refreshTokenTTL,
 	}
 }
 
@@ -50,30 +89,251 @@ func (s *AuthService) Register(username, email, password string) (*domain.User,
 	return user, nil
 }
 
-func (s *AuthService) Login(email, password string) (*domain.User, string, error) {
+func (s *AuthService) Login(email, password string) (*domain.User, *TokenPair, error) {
 	user, err := s.userRepo.FindByEmail(email)
 	if err != nil {
-		return nil, "", ErrInvalidCredentials
+		return nil, nil, ErrInvalidCredentials
 	}
 
 	if err := user.ValidatePassword(password); err != nil {
-		return nil, "", ErrInvalidCredentials
+		return nil, nil, ErrInvalidCredentials
+	}
+
+	// Every login starts a new refresh token family (session)
+	familyID, err := randomToken(16)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tokens, err := s.issueTokenPair(user, familyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, tokens, nil
+}
+
+// Refresh exchanges a refresh token for a new token pair. The presented token
+// is rotated; presenting an already rotated token revokes the whole family.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*domain.User, *TokenPair, error) {
+	if refreshToken == "" {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	stored, err := s.refreshTokenRepo.FindByHash(hashToken(refreshToken))
+	if err != nil {
+		return nil, nil, err
+	}
+	if stored == nil || stored.IsExpired() {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	if stored.IsRevoked() {
+		// A rotated token came back: assume it was stolen and kill the session
+		if err := s.revokeSession(ctx, stored.FamilyID); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrRefreshTokenReused
+	}
+
+	user, err := s.userRepo.FindByID(stored.UserID)
+	if err != nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	rawToken, err := randomToken(32)
+	if err != nil {
+		return nil, nil, err
+	}
+	next := &domain.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(rawToken),
+		FamilyID:  stored.FamilyID,
+		ExpiresAt: time.Now().Add(s.refreshTokenTTL),
+	}
+
+	rotated, err := s.refreshTokenRepo.Rotate(stored.ID, next)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !rotated {
+		// Lost a race with another refresh of the same token
+		if err := s.revokeSession(ctx, stored.FamilyID); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrRefreshTokenReused
+	}
+
+	accessToken, expiresAt, err := s.generateAccessToken(user.ID, user.Username, stored.FamilyID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: rawToken,
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+// Logout revokes the presented access token and the session it belongs to
+func (s *AuthService) Logout(ctx context.Context, claims *AccessClaims) error {
+	ttl := time.Until(claims.ExpiresAt)
+	if ttl > 0 {
+		if err := s.cache.Set(ctx, revokedTokenKeyPrefix+claims.TokenID, []byte("1"), ttl); err != nil {
+			return fmt.Errorf("failed to revoke access token: %w", err)
+		}
+	}
+
+	if claims.SessionID != "" {
+		return s.revokeSession(ctx, claims.SessionID)
+	}
+	return nil
+}
+
+// IsTokenRevoked reports whether an access token, or the session it belongs to, was revoked
+func (s *AuthService) IsTokenRevoked(ctx context.Context, tokenID, sessionID string) (bool, error) {
+	keys := []string{revokedTokenKeyPrefix + tokenID}
+	if sessionID != "" {
+		keys = append(keys, revokedSessionKeyPrefix+sessionID)
+	}
+
+	count, err := s.cache.Exists(ctx, keys...)
+	if err != nil {
+		return false, err
 	}
+	return count > 0, nil
+}
 
-	token, err := s.GenerateJWT(user.ID, user.Username)
-	return user, token, err
+// ParseAccessToken validates an access token signature and expiry and returns its claims
+func (s *AuthService) ParseAccessToken(tokenString string) (*AccessClaims, error) {
+	return ParseAccessToken(tokenString, s.jwtSecret)
 }
 
 func (s *AuthService) GenerateJWT(userID uint, username string) (string, error) {
+	token, _, err := s.generateAccessToken(userID, username, "")
+	return token, err
+}
+
+func (s *AuthService) GetUserByID(userID uint) (*domain.User, error) {
+	return s.userRepo.FindByID(userID)
+}
+
+func (s *AuthService) issueTokenPair(user *domain.User, familyID string) (*TokenPair, error) {
+	rawToken, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	refresh := &domain.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(rawToken),
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(s.refreshTokenTTL),
+	}
+	if err := s.refreshTokenRepo.Create(refresh); err != nil {
+		return nil, err
+	}
+
+	accessToken, expiresAt, err := s.generateAccessToken(user.ID, user.Username, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: rawToken,
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+func (s *AuthService) generateAccessToken(userID uint, username, sessionID string) (string, time.Time, error) {
+	tokenID, err := randomToken(16)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.accessTokenTTL)
 	claims := jwt.MapClaims{
 		"user_id":  userID,
 		"username": username,
-		"exp":      time.Now().Add(24 * time.Hour).Unix(),
+		"jti":      tokenID,
+		"iat":      now.Unix(),
+		"exp":      expiresAt.Unix(),
+	}
+	if sessionID != "" {
+		claims["sid"] = sessionID
 	}
+
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.jwtSecret))
+	signed, err := token.SignedString([]byte(s.jwtSecret))
+	return signed, expiresAt, err
 }
 
-func (s *AuthService) GetUserByID(userID uint) (*domain.User, error) {
-	return s.userRepo.FindByID(userID)
+// revokeSession revokes a refresh token family and every access token issued from it
+func (s *AuthService) revokeSession(ctx context.Context, familyID string) error {
+	if err := s.refreshTokenRepo.RevokeFamily(familyID); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	if err := s.cache.Set(ctx, revokedSessionKeyPrefix+familyID, []byte("1"), s.accessTokenTTL); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return nil
+}
+
+// ParseAccessToken validates an HS256 access token and extracts its claims
+func ParseAccessToken(tokenString, jwtSecret string) (*AccessClaims, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(jwtSecret), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	userIDFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("invalid user_id in token")
+	}
+	tokenID, ok := claims["jti"].(string)
+	if !ok || tokenID == "" {
+		return nil, fmt.Errorf("missing jti in token")
+	}
+
+	expiresAt, err := claims.GetExpirationTime()
+	if err != nil || expiresAt == nil {
+		return nil, fmt.Errorf("invalid exp in token")
+	}
+
+	username, _ := claims["username"].(string)
+	sessionID, _ := claims["sid"].(string)
+
+	return &AccessClaims{
+		UserID:    uint(userIDFloat),
+		Username:  username,
+		TokenID:   tokenID,
+		SessionID: sessionID,
+		ExpiresAt: expiresAt.Time,
+	}, nil
+}
+
+// randomToken returns n random bytes encoded as URL-safe base64
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the hex SHA-256 of a refresh token for storage
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sort"
 	"time"
@@ -14,27 +15,52 @@ import (
 	"go.uber.org/zap"
 )
 
+var ErrCursorExpired = errors.New("cursor expired")
+
 type FeedService struct {
-	postRepo postgres.PostRepository
-	cache    cache.Cache
-	cacheTTL time.Duration
-	logger   *zap.Logger
+	postRepo       postgres.PostRepository
+	cache          cache.Cache
+	cacheTTL       time.Duration
+	rankWindow     int
+	rankSessionTTL time.Duration
+	defaultRanker  Ranker
+	weights        RankingWeights
+	logger         *zap.Logger
 }
 
-func NewFeedService(postRepo postgres.PostRepository, cache cache.Cache, cacheTTL time.Duration) *FeedService {
+// NewFeedService creates a feed service. rankWindow is how many recent posts
+// are ranked per session and rankSessionTTL how long a session can be paged.
+// defaultRanker is used when a request does not pick one; rankers picked per
+// request are built with weights.
+func NewFeedService(postRepo postgres.PostRepository, cache cache.Cache, cacheTTL time.Duration, rankWindow int, rankSessionTTL time.Duration, defaultRanker Ranker, weights RankingWeights) *FeedService {
 	logger, _ := zap.NewProduction()
 	return &FeedService{
-		postRepo: postRepo,
-		cache:    cache,
-		cacheTTL: cacheTTL,
-		logger:   logger,
+		postRepo:       postRepo,
+		cache:          cache,
+		cacheTTL:       cacheTTL,
+		rankWindow:     rankWindow,
+		rankSessionTTL: rankSessionTTL,
+		defaultRanker:  defaultRanker,
+		weights:        weights,
+		logger:         logger,
 	}
 }
 
-// GetFeedWithCursor implements cursor-based pagination with caching
+// GetFeedWithCursor returns a page of the feed ranked by the default ranker
 func (s *FeedService) GetFeedWithCursor(limit int, cursor string) (*pagination.FeedResult, error) {
+	return s.GetRankedFeed(limit, cursor, "")
+}
+
+// GetRankedFeed implements cursor-based pagination with caching. rank selects
+// a built-in ranker by name; empty means the default ranker.
+func (s *FeedService) GetRankedFeed(limit int, cursor, rank string) (*pagination.FeedResult, error) {
+	ranker, err := s.ranker(rank)
+	if err != nil {
+		return nil, err
+	}
+
 	start := time.Now()
-	cacheKey := fmt.Sprintf("feed:cursor:%s:limit:%d", cursor, limit)
+	cacheKey := feedCacheKey(ranker.Name(), cursor, limit)
 	ctx := context.Background()
 
 	s.logger.Info("getting feed with cursor",
@@ -60,7 +86,7 @@ func (s *FeedService) GetFeedWithCursor(limit int, cursor string) (*pagination.F
 		zap.String("cache_key", cacheKey),
 	)
 
-	result, err := s.getFeedFromDatabase(limit, cursor)
+	result, err := s.getFeedFromDatabase(limit, cursor, ranker, "", s.postRepo.GetFeedWithCursor)
 	if err != nil {
 		return nil, err
 	}
@@ -89,41 +115,211 @@ func (s *FeedService) GetFeedWithCursor(limit int, cursor string) (*pagination.F
 	return result, nil
 }
 
-// getFeedFromDatabase fetches feed from the database (extracted for caching logic)
-func (s *FeedService) getFeedFromDatabase(limit int, cursor string) (*pagination.FeedResult, error) {
-	// Decode cursor if provided
-	var cursorObj *pagination.Cursor
-	var err error
-	if cursor != "" {
-		cursorObj, err = pagination.DecodeCursor(cursor)
+// GetHashtagFeed returns a page of the posts with tag, ranked like the feed.
+// Hashtag pages are read from the database rather than the feed cache.
+func (s *FeedService) GetHashtagFeed(tag string, limit int, cursor, rank string) (*pagination.FeedResult, error) {
+	tag, ok := domain.NormalizeHashtag(tag)
+	if !ok {
+		return nil, ErrInvalidHashtag
+	}
+	ranker, err := s.ranker(rank)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getFeedFromDatabase(limit, cursor, ranker, "#"+tag, func(limit int, cursor *pagination.Cursor) ([]*domain.Post, error) {
+		return s.postRepo.GetByHashtagWithCursor(tag, limit, cursor)
+	})
+}
+
+// ranker returns the built-in ranker named rank; empty means the default ranker
+func (s *FeedService) ranker(rank string) (Ranker, error) {
+	if rank == "" || rank == s.defaultRanker.Name() {
+		return s.defaultRanker, nil
+	}
+	return NewRanker(rank, s.weights)
+}
+
+// postSource loads up to limit posts older than cursor (nil for the newest),
+// newest first
+type postSource func(limit int, cursor *pagination.Cursor) ([]*domain.Post, error)
+
+// rankSession is a snapshot of the ranked feed order taken when the first
+// page is requested. Later pages are served from it by offset.
+type rankSession struct {
+	// Source names the posts ranked: empty for the feed, "#tag" for a
+	// hashtag feed. Cursors are only valid for the source they came from.
+	Source  string    `json:"source,omitempty"`
+	PostIDs []uint    `json:"post_ids"`
+	Scores  []float64 `json:"scores"`
+	// Tail is the chronological cursor for posts older than the ranking
+	// window; empty when the window covered every post
+	Tail string `json:"tail,omitempty"`
+}
+
+// getFeedFromDatabase fetches feed from the database (extracted for caching logic).
+// The first page snapshots the ranked order of the most recent rankWindow posts
+// into a ranking session; rank cursors page through that snapshot, and once it
+// is exhausted older posts follow in chronological order. Posts come from source,
+// which sourceName identifies in the session.
+func (s *FeedService) getFeedFromDatabase(limit int, cursor string, ranker Ranker, sourceName string, source postSource) (*pagination.FeedResult, error) {
+	if cursor == "" {
+		return s.startRankSession(limit, ranker, sourceName, source)
+	}
+
+	if pagination.IsRankCursor(cursor) {
+		rankCursor, err := pagination.DecodeRankCursor(cursor)
 		if err != nil {
 			s.logger.Error("failed to decode cursor", zap.Error(err))
-			return nil, err
+			return nil, ErrInvalidCursor
 		}
+		return s.getRankSessionPage(limit, rankCursor, sourceName)
+	}
+
+	cursorObj, err := pagination.DecodeCursor(cursor)
+	if err != nil {
+		s.logger.Error("failed to decode cursor", zap.Error(err))
+		return nil, ErrInvalidCursor
 	}
+	return s.getChronologicalPage(limit, cursorObj, ranker, source)
+}
 
-	// Get posts with cursor-based pagination
-	posts, err := s.postRepo.GetFeedWithCursor(limit+1, cursorObj) // +1 to check if there are more
+// startRankSession ranks the most recent posts, stores the order and returns the first page
+func (s *FeedService) startRankSession(limit int, ranker Ranker, sourceName string, source postSource) (*pagination.FeedResult, error) {
+	posts, err := source(s.rankWindow+1, nil) // +1 to check for posts beyond the window
 	if err != nil {
 		s.logger.Error("failed to get feed from repository", zap.Error(err))
 		return nil, err
 	}
 
-	// Calculate scores and sort
-	for _, post := range posts {
-		post.Score = post.CalculateScore()
+	session := &rankSession{Source: sourceName}
+	if len(posts) > s.rankWindow {
+		posts = posts[:s.rankWindow]
+		oldest := posts[len(posts)-1]
+		session.Tail = (&pagination.Cursor{Timestamp: oldest.CreatedAt, ID: oldest.ID}).Encode()
 	}
 
+	// Score once against a single clock so the whole session shares the same snapshot
+	now := time.Now()
+	for _, post := range posts {
+		post.Score = ranker.Score(post, now)
+	}
 	sort.Slice(posts, func(i, j int) bool {
+		if posts[i].Score == posts[j].Score {
+			return posts[i].ID > posts[j].ID
+		}
 		return posts[i].Score > posts[j].Score
 	})
 
+	session.PostIDs = make([]uint, len(posts))
+	session.Scores = make([]float64, len(posts))
+	for i, post := range posts {
+		session.PostIDs[i] = post.ID
+		session.Scores[i] = post.Score
+	}
+
+	// Only sessions that span more than one page need to be stored
+	sessionID := ""
+	if len(posts) > limit {
+		sessionID, err = randomToken(16)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(session)
+		if err != nil {
+			return nil, err
+		}
+		// The first page may be served from cache for up to cacheTTL, so the
+		// session outlives it to leave the client rankSessionTTL to keep paging
+		if err := s.cache.Set(context.Background(), rankSessionKey(sessionID), data, s.rankSessionTTL+s.cacheTTL); err != nil {
+			return nil, fmt.Errorf("failed to store ranking session: %w", err)
+		}
+		posts = posts[:limit]
+	}
+
+	return s.rankSessionResult(posts, session, sessionID, len(posts)), nil
+}
+
+// getRankSessionPage returns the page of a stored ranking session starting at the
+// cursor offset. Sessions of another source are rejected as invalid cursors.
+func (s *FeedService) getRankSessionPage(limit int, cursor *pagination.RankCursor, sourceName string) (*pagination.FeedResult, error) {
+	data, err := s.cache.Get(context.Background(), rankSessionKey(cursor.SessionID))
+	if err != nil {
+		return nil, ErrCursorExpired
+	}
+	var session rankSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, ErrCursorExpired
+	}
+
+	if session.Source != sourceName || cursor.Offset >= len(session.PostIDs) {
+		return nil, ErrInvalidCursor
+	}
+	end := cursor.Offset + limit
+	if end > len(session.PostIDs) {
+		end = len(session.PostIDs)
+	}
+	pageIDs := session.PostIDs[cursor.Offset:end]
+
+	found, err := s.postRepo.FindByIDs(pageIDs)
+	if err != nil {
+		s.logger.Error("failed to load ranked posts", zap.Error(err))
+		return nil, err
+	}
+	byID := make(map[uint]*domain.Post, len(found))
+	for _, post := range found {
+		byID[post.ID] = post
+	}
+
+	// Keep snapshot order and scores; posts deleted since the snapshot are skipped
+	posts := make([]*domain.Post, 0, len(pageIDs))
+	for i, id := range pageIDs {
+		if post, ok := byID[id]; ok {
+			post.Score = session.Scores[cursor.Offset+i]
+			posts = append(posts, post)
+		}
+	}
+
+	return s.rankSessionResult(posts, &session, cursor.SessionID, end), nil
+}
+
+// rankSessionResult builds a page of a ranking session. next is the offset of the
+// first post not yet returned.
+func (s *FeedService) rankSessionResult(posts []*domain.Post, session *rankSession, sessionID string, next int) *pagination.FeedResult {
+	var nextCursor string
+	switch {
+	case next < len(session.PostIDs):
+		nextCursor = (&pagination.RankCursor{SessionID: sessionID, Offset: next}).Encode()
+	case session.Tail != "":
+		nextCursor = session.Tail
+	}
+
+	return &pagination.FeedResult{
+		Posts:      convertPostsToInterface(posts),
+		NextCursor: nextCursor,
+		HasMore:    nextCursor != "",
+	}
+}
+
+// getChronologicalPage returns posts older than the cursor, newest first
+func (s *FeedService) getChronologicalPage(limit int, cursor *pagination.Cursor, ranker Ranker, source postSource) (*pagination.FeedResult, error) {
+	posts, err := source(limit+1, cursor) // +1 to check if there are more
+	if err != nil {
+		s.logger.Error("failed to get feed from repository", zap.Error(err))
+		return nil, err
+	}
+
 	// Check if there are more posts
 	hasMore := len(posts) > limit
 	if hasMore {
 		posts = posts[:limit] // Remove the extra post
 	}
 
+	now := time.Now()
+	for _, post := range posts {
+		post.Score = ranker.Score(post, now)
+	}
+
 	// Generate next cursor
 	var nextCursor string
 	if hasMore && len(posts) > 0 {
@@ -142,6 +338,42 @@ func (s *FeedService) getFeedFromDatabase(limit int, cursor string) (*pagination
 	}, nil
 }
 
+// GetUserPosts returns a page of userID's posts, newest first, for their
+// profile. Profiles are read from the database rather than the feed cache.
+func (s *FeedService) GetUserPosts(userID uint, limit int, cursor string) (*pagination.FeedResult, error) {
+	cursorObj, err := pagination.DecodeCursor(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	posts, err := s.postRepo.GetByUserIDsWithCursor([]uint{userID}, limit+1, cursorObj) // +1 to check if there are more
+	if err != nil {
+		return nil, err
+	}
+
+	hasMore := len(posts) > limit
+	var nextCursor string
+	if hasMore {
+		posts = posts[:limit]
+		lastPost := posts[len(posts)-1]
+		nextCursor = (&pagination.Cursor{Timestamp: lastPost.CreatedAt, ID: lastPost.ID}).Encode()
+	}
+
+	return &pagination.FeedResult{
+		Posts:      convertPostsToInterface(posts),
+		NextCursor: nextCursor,
+		HasMore:    hasMore,
+	}, nil
+}
+
+func feedCacheKey(ranker, cursor string, limit int) string {
+	return fmt.Sprintf("feed:%s:cursor:%s:limit:%d", ranker, cursor, limit)
+}
+
+func rankSessionKey(sessionID string) string {
+	return "feed:rank:" + sessionID
+}
+
 // convertPostsToInterface converts []*domain.Post to []interface{}
 func convertPostsToInterface(posts []*domain.Post) []interface{} {
 	result := make([]interface{}, len(posts))
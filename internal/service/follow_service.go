@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/rodolfodpk/instagrano/internal/domain"
+	"github.com/rodolfodpk/instagrano/internal/events"
+	"github.com/rodolfodpk/instagrano/internal/pagination"
+	"github.com/rodolfodpk/instagrano/internal/repository/postgres"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrUserNotFound     = errors.New("user not found")
+	ErrCannotFollowSelf = errors.New("cannot follow yourself")
+	ErrInvalidCursor    = errors.New("invalid cursor")
+)
+
+type FollowService struct {
+	followRepo      postgres.FollowRepository
+	userRepo        postgres.UserRepository
+	timelineService *TimelineService
+	logger          *zap.Logger
+}
+
+// NewFollowService creates a follow service. timelineService may be nil, in
+// which case following and unfollowing leave home timelines as they are.
+func NewFollowService(followRepo postgres.FollowRepository, userRepo postgres.UserRepository, timelineService *TimelineService, logger *zap.Logger) *FollowService {
+	return &FollowService{
+		followRepo:      followRepo,
+		userRepo:        userRepo,
+		timelineService: timelineService,
+		logger:          logger,
+	}
+}
+
+// Follow makes followerID follow followeeID. Following twice is a no-op.
+func (s *FollowService) Follow(followerID, followeeID uint, followerUsername string) (*domain.User, error) {
+	if followerID == followeeID {
+		return nil, ErrCannotFollowSelf
+	}
+
+	if _, err := s.GetUser(followeeID); err != nil {
+		return nil, err
+	}
+
+	// The event is recorded in the outbox only when a new follow is created
+	created, err := s.followRepo.Follow(followerID, followeeID, func(followersCount int) (*domain.OutboxMessage, error) {
+		return newOutboxMessage(events.NewUserFollowedEvent(followerID, followeeID, followerUsername, followersCount))
+	})
+	if err != nil {
+		return nil, err
+	}
+	if created && s.timelineService != nil {
+		if err := s.timelineService.AddFollowee(context.Background(), followerID, followeeID); err != nil {
+			s.logger.Error("failed to backfill timeline",
+				zap.Uint("follower_id", followerID),
+				zap.Uint("followee_id", followeeID),
+				zap.Error(err))
+		}
+	}
+
+	// Reload to get the updated counters
+	return s.GetUser(followeeID)
+}
+
+// Unfollow removes the follow edge. Unfollowing a user not followed is a no-op.
+func (s *FollowService) Unfollow(followerID, followeeID uint) (*domain.User, error) {
+	removed, err := s.followRepo.Unfollow(followerID, followeeID)
+	if err != nil {
+		return nil, err
+	}
+	if removed && s.timelineService != nil {
+		if err := s.timelineService.RemoveFollowee(context.Background(), followerID, followeeID); err != nil {
+			s.logger.Error("failed to remove followee from timeline",
+				zap.Uint("follower_id", followerID),
+				zap.Uint("followee_id", followeeID),
+				zap.Error(err))
+		}
+	}
+	return s.GetUser(followeeID)
+}
+
+// IsFollowing reports whether followerID follows followeeID
+func (s *FollowService) IsFollowing(followerID, followeeID uint) (bool, error) {
+	return s.followRepo.IsFollowing(followerID, followeeID)
+}
+
+// GetUser returns a user with follower/following counts
+func (s *FollowService) GetUser(userID uint) (*domain.User, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetFollowers returns a page of users following userID and the cursor for the next page
+func (s *FollowService) GetFollowers(userID uint, limit int, cursor string) ([]*domain.FollowUser, string, error) {
+	return s.listPage(userID, limit, cursor, s.followRepo.FindFollowers)
+}
+
+// GetFollowing returns a page of users followed by userID and the cursor for the next page
+func (s *FollowService) GetFollowing(userID uint, limit int, cursor string) ([]*domain.FollowUser, string, error) {
+	return s.listPage(userID, limit, cursor, s.followRepo.FindFollowing)
+}
+
+func (s *FollowService) listPage(userID uint, limit int, cursor string,
+	find func(uint, int, *pagination.Cursor) ([]*domain.FollowUser, error)) ([]*domain.FollowUser, string, error) {
+	if _, err := s.GetUser(userID); err != nil {
+		return nil, "", err
+	}
+
+	cursorObj, err := pagination.DecodeCursor(cursor)
+	if err != nil {
+		return nil, "", ErrInvalidCursor
+	}
+
+	users, err := find(userID, limit+1, cursorObj) // +1 to check if there are more
+	if err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(users) > limit {
+		users = users[:limit]
+		last := users[len(users)-1]
+		nextCursor = (&pagination.Cursor{Timestamp: last.FollowedAt, ID: last.ID}).Encode()
+	}
+
+	return users, nextCursor, nil
+}
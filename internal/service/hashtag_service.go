@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rodolfodpk/instagrano/internal/cache"
+	"github.com/rodolfodpk/instagrano/internal/domain"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInvalidHashtag        = errors.New("invalid hashtag")
+	ErrUnknownTrendingWindow = errors.New("unknown trending window")
+)
+
+// Trending windows
+const (
+	TrendingHour = "1h"
+	TrendingDay  = "24h"
+	TrendingWeek = "7d"
+)
+
+// TrendingWindows lists the supported trending windows
+var TrendingWindows = []string{TrendingHour, TrendingDay, TrendingWeek}
+
+// trendingWindow is a sliding window made of the current bucket and the
+// buckets-1 before it
+type trendingWindow struct {
+	bucket  time.Duration
+	buckets int
+}
+
+var trendingWindows = map[string]trendingWindow{
+	TrendingHour: {bucket: time.Minute, buckets: 60},
+	TrendingDay:  {bucket: time.Hour, buckets: 24},
+	TrendingWeek: {bucket: time.Hour, buckets: 7 * 24},
+}
+
+// HashtagService keeps trending hashtags.
+//
+// Every use of a hashtag in a new or edited caption is counted in Redis
+// sorted sets, one per minute and one per hour, that expire once no window
+// needs them. A window is the sum of its buckets, so it slides by a minute
+// for the hourly window and by an hour for the longer ones.
+type HashtagService struct {
+	cache  cache.Cache
+	logger *zap.Logger
+}
+
+func NewHashtagService(cache cache.Cache, logger *zap.Logger) *HashtagService {
+	return &HashtagService{
+		cache:  cache,
+		logger: logger,
+	}
+}
+
+// RecordHashtags counts one use of each tag at the given time
+func (s *HashtagService) RecordHashtags(ctx context.Context, tags []string, at time.Time) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	// Each bucket size is kept for as long as its longest window needs it
+	ttls := make(map[time.Duration]time.Duration)
+	for _, window := range trendingWindows {
+		if ttl := time.Duration(window.buckets+1) * window.bucket; ttl > ttls[window.bucket] {
+			ttls[window.bucket] = ttl
+		}
+	}
+
+	for bucket, ttl := range ttls {
+		key := trendingKey(bucket, at.Truncate(bucket))
+		if err := s.cache.ZIncrByMany(ctx, []string{key}, tags, 1, ttl); err != nil {
+			return fmt.Errorf("failed to record hashtags: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetTrending returns up to limit hashtags used in the most posts within the
+// window, most used first
+func (s *HashtagService) GetTrending(window string, limit int) ([]domain.TrendingHashtag, error) {
+	w, ok := trendingWindows[window]
+	if !ok {
+		return nil, ErrUnknownTrendingWindow
+	}
+
+	current := time.Now().Truncate(w.bucket)
+	keys := make([]string, w.buckets)
+	for i := range keys {
+		keys[i] = trendingKey(w.bucket, current.Add(-time.Duration(i)*w.bucket))
+	}
+
+	members, err := s.cache.ZUnionTop(context.Background(), keys, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	hashtags := make([]domain.TrendingHashtag, len(members))
+	for i, m := range members {
+		hashtags[i] = domain.TrendingHashtag{Name: m.Member, PostsCount: int(m.Score)}
+	}
+	return hashtags, nil
+}
+
+// trendingKey names the bucket of the given size starting at start
+func trendingKey(bucket time.Duration, start time.Time) string {
+	return fmt.Sprintf("hashtags:trending:%d:%d", int64(bucket.Seconds()), start.Unix())
+}
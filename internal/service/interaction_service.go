@@ -2,175 +2,410 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/rodolfodpk/instagrano/internal/cache"
 	"github.com/rodolfodpk/instagrano/internal/domain"
 	"github.com/rodolfodpk/instagrano/internal/events"
+	"github.com/rodolfodpk/instagrano/internal/pagination"
 	"github.com/rodolfodpk/instagrano/internal/repository/postgres"
 	"go.uber.org/zap"
 )
 
+var (
+	ErrCommentNotFound  = errors.New("comment not found")
+	ErrCommentForbidden = errors.New("not allowed to change this comment")
+	ErrInvalidSort      = errors.New("invalid sort")
+	ErrInvalidReaction  = errors.New("invalid reaction")
+	ErrPostNotFound     = errors.New("post not found")
+	ErrLikesHidden      = errors.New("likes are hidden")
+)
+
+// InteractionService records reactions (likes among them) and comments. Their events are written to
+// the outbox in the same transaction and published by the OutboxRelay; the
+// post's author is notified through the NotificationService.
 type InteractionService struct {
-	likeRepo       postgres.LikeRepository
-	commentRepo    postgres.CommentRepository
-	postRepo       postgres.PostRepository
-	cache          cache.Cache
-	eventPublisher *events.Publisher
-	logger         *zap.Logger
+	likeRepo            postgres.LikeRepository
+	commentRepo         postgres.CommentRepository
+	postRepo            postgres.PostRepository
+	cache               cache.Cache
+	notificationService *NotificationService
+	mentionService      *MentionService
+	logger              *zap.Logger
 }
 
-func NewInteractionService(likeRepo postgres.LikeRepository, commentRepo postgres.CommentRepository, postRepo postgres.PostRepository, cache cache.Cache, eventPublisher *events.Publisher, logger *zap.Logger) *InteractionService {
+// NewInteractionService creates an interaction service. notificationService
+// and mentionService may be nil, in which case authors are not notified and
+// comments have no mentions.
+func NewInteractionService(likeRepo postgres.LikeRepository, commentRepo postgres.CommentRepository, postRepo postgres.PostRepository, cache cache.Cache, notificationService *NotificationService, mentionService *MentionService, logger *zap.Logger) *InteractionService {
 	return &InteractionService{
-		likeRepo:       likeRepo,
-		commentRepo:    commentRepo,
-		postRepo:       postRepo,
-		cache:          cache,
-		eventPublisher: eventPublisher,
-		logger:         logger,
+		likeRepo:            likeRepo,
+		commentRepo:         commentRepo,
+		postRepo:            postRepo,
+		cache:               cache,
+		notificationService: notificationService,
+		mentionService:      mentionService,
+		logger:              logger,
 	}
 }
 
+// LikePost toggles userID's like on the post, removing any other reaction
+// instead if it has one. Prefer AddLike and RemoveLike, which are safe to retry.
 func (s *InteractionService) LikePost(userID, postID uint) (int, int, error) {
-	// Check if user already liked this post
+	// Check if user already reacted to this post
 	existingLike, err := s.likeRepo.FindByUserAndPost(userID, postID)
 	if err != nil {
 		return 0, 0, err
 	}
 
 	if existingLike != nil {
-		// User already liked - unlike the post
-		return s.unlikePost(userID, postID)
-	} else {
-		// User hasn't liked - like the post
-		return s.likePost(userID, postID)
+		return s.RemoveLike(userID, postID)
 	}
+	return s.AddLike(userID, postID)
+}
+
+// AddLike likes the post for userID and returns the post's counts. Liking it
+// again changes nothing and publishes nothing.
+func (s *InteractionService) AddLike(userID, postID uint) (int, int, error) {
+	counts, err := s.changeReaction(userID, postID, domain.ReactionLike)
+	return counts.LikesCount, counts.CommentsCount, err
+}
+
+// RemoveLike removes userID's like, or other reaction, from the post and
+// returns the post's counts. Removing a like that is not there changes nothing.
+func (s *InteractionService) RemoveLike(userID, postID uint) (int, int, error) {
+	counts, err := s.changeReaction(userID, postID, "")
+	return counts.LikesCount, counts.CommentsCount, err
 }
 
-func (s *InteractionService) likePost(userID, postID uint) (int, int, error) {
-	like := &domain.Like{
-		UserID: userID,
-		PostID: postID,
+// React sets userID's reaction on the post, replacing any other one, and
+// returns the post's counts. Reacting the same way again changes nothing and
+// publishes nothing.
+func (s *InteractionService) React(userID, postID uint, reaction string) (domain.PostCounts, error) {
+	if !domain.IsReactionType(reaction) {
+		return domain.PostCounts{}, ErrInvalidReaction
 	}
+	return s.changeReaction(userID, postID, reaction)
+}
 
-	if err := s.likeRepo.Create(like); err != nil {
-		return 0, 0, err
+// RemoveReaction removes userID's reaction from the post and returns the
+// post's counts. Removing a reaction that is not there changes nothing.
+func (s *InteractionService) RemoveReaction(userID, postID uint) (domain.PostCounts, error) {
+	return s.changeReaction(userID, postID, "")
+}
+
+// changeReaction sets userID's reaction on the post, "" removing it
+func (s *InteractionService) changeReaction(userID, postID uint, reaction string) (domain.PostCounts, error) {
+	// Reactions of every type, and their removal, reuse the post_liked event
+	// type; removals are flagged in its data
+	var previous string
+	event := func(prev string, counts domain.PostCounts) (*domain.OutboxMessage, error) {
+		previous = prev
+		return newOutboxMessage(events.NewPostReactedEvent(postID, userID, reaction, prev,
+			counts.LikesCount, counts.CommentsCount, counts.ReactionCounts))
 	}
 
-	if err := s.likeRepo.IncrementPostLikeCount(postID); err != nil {
-		return 0, 0, err
+	var counts domain.PostCounts
+	var changed bool
+	var err error
+	if reaction == "" {
+		counts, changed, err = s.likeRepo.Unreact(userID, postID, event)
+	} else {
+		counts, changed, err = s.likeRepo.React(userID, postID, reaction, event)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.PostCounts{}, ErrPostNotFound
+	}
+	if err != nil {
+		return domain.PostCounts{}, err
 	}
 
-	// Invalidate post cache
-	cacheKey := fmt.Sprintf("post:%d", postID)
-	ctx := context.Background()
-	s.cache.Delete(ctx, cacheKey)
+	if changed {
+		s.invalidatePostCaches(postID)
+		// Switching from one reaction to another does not notify the author again
+		if reaction != "" && previous == "" {
+			s.notifyAuthor(domain.NotificationTypeLike, postID, userID)
+		}
+	}
+	return counts, nil
+}
 
-	// Invalidate feed cache to ensure updated like counts appear
-	s.invalidateFeedCache()
+// ViewerStates returns whether userID reacted to, commented on and saved each
+// of the given posts
+func (s *InteractionService) ViewerStates(userID uint, postIDs []uint) (map[uint]*domain.PostViewerState, error) {
+	return s.postRepo.FindViewerStates(userID, postIDs)
+}
 
-	// Get updated counts
-	post, err := s.postRepo.FindByID(postID)
+func (s *InteractionService) CommentPost(userID, postID uint, text string, username string) (int, int, error) {
+	return s.createComment(&domain.Comment{
+		UserID:   userID,
+		PostID:   postID,
+		Text:     text,
+		Username: username,
+	})
+}
+
+// ReplyToComment adds a reply to a comment on postID. Threads are one level
+// deep: replying to a reply adds to the thread of its top-level comment.
+func (s *InteractionService) ReplyToComment(userID, postID, parentID uint, text string, username string) (int, int, error) {
+	parent, err := s.findComment(postID, parentID)
 	if err != nil {
 		return 0, 0, err
 	}
-
-	// Publish like event for real-time updates
-	if err := s.eventPublisher.PublishPostLiked(ctx, postID, userID, post.LikesCount, post.CommentsCount); err != nil {
-		s.logger.Error("failed to publish post liked event",
-			zap.Error(err),
-			zap.Uint("post_id", postID),
-			zap.Uint("user_id", userID))
+	if parent.ParentID != nil {
+		parentID = *parent.ParentID
 	}
 
-	return post.LikesCount, post.CommentsCount, nil
+	return s.createComment(&domain.Comment{
+		UserID:   userID,
+		PostID:   postID,
+		ParentID: &parentID,
+		Text:     text,
+		Username: username,
+	})
 }
 
-func (s *InteractionService) unlikePost(userID, postID uint) (int, int, error) {
-	if err := s.likeRepo.Delete(userID, postID); err != nil {
+func (s *InteractionService) createComment(comment *domain.Comment) (int, int, error) {
+	// The comment's ID and timestamp are set before the event is built
+	likesCount, commentsCount, err := s.commentRepo.CreateWithOutbox(comment, func(likesCount, commentsCount int) (*domain.OutboxMessage, error) {
+		return newOutboxMessage(events.NewPostCommentedEvent(comment.PostID, comment.UserID, likesCount, commentsCount, toEventComment(comment)))
+	})
+	if err != nil {
 		return 0, 0, err
 	}
 
-	if err := s.likeRepo.DecrementPostLikeCount(postID); err != nil {
-		return 0, 0, err
+	s.saveMentions(comment)
+	s.invalidatePostCaches(comment.PostID)
+	s.notifyAuthor(domain.NotificationTypeComment, comment.PostID, comment.UserID)
+	return likesCount, commentsCount, nil
+}
+
+// UpdateComment replaces the text of a comment on postID. Only its author may edit it.
+func (s *InteractionService) UpdateComment(userID, postID, commentID uint, text string) (*domain.Comment, error) {
+	comment, err := s.findComment(postID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.UserID != userID {
+		return nil, ErrCommentForbidden
 	}
 
-	// Invalidate post cache
-	cacheKey := fmt.Sprintf("post:%d", postID)
-	ctx := context.Background()
-	s.cache.Delete(ctx, cacheKey)
+	comment.Text = text
+	// Mentions are saved with the edit, so they never disagree with the text
+	comment.Mentions = s.resolveMentions(comment)
+	var mentions postgres.MentionEventFunc
+	if s.mentionService != nil {
+		mentions = mentionEvent(postID, &comment.ID, userID, comment.Text)
+	}
+	_, _, err = s.commentRepo.UpdateWithOutbox(comment, func(likesCount, commentsCount int) (*domain.OutboxMessage, error) {
+		return newOutboxMessage(events.NewCommentUpdatedEvent(postID, userID, likesCount, commentsCount, toEventComment(comment)))
+	}, mentions)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCommentNotFound // Deleted since it was loaded
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	// Invalidate feed cache to ensure updated like counts appear
-	s.invalidateFeedCache()
+	s.invalidatePostCaches(postID)
+	return comment, nil
+}
 
-	// Get updated counts
-	post, err := s.postRepo.FindByID(postID)
+// DeleteComment deletes a comment on postID, and its replies if it is a
+// top-level comment. The comment's author and the post's author may delete it.
+func (s *InteractionService) DeleteComment(userID, postID, commentID uint) (int, int, error) {
+	comment, err := s.findComment(postID, commentID)
 	if err != nil {
 		return 0, 0, err
 	}
+	if comment.UserID != userID {
+		post, err := s.postRepo.FindByID(postID)
+		if err != nil {
+			return 0, 0, err
+		}
+		if post.UserID != userID {
+			return 0, 0, ErrCommentForbidden
+		}
+	}
 
-	// Publish unlike event for real-time updates (we can reuse the same event type)
-	if err := s.eventPublisher.PublishPostLiked(ctx, postID, userID, post.LikesCount, post.CommentsCount); err != nil {
-		s.logger.Error("failed to publish post unliked event",
-			zap.Error(err),
-			zap.Uint("post_id", postID),
-			zap.Uint("user_id", userID))
+	likesCount, commentsCount, err := s.commentRepo.DeleteWithOutbox(comment, func(likesCount, commentsCount int) (*domain.OutboxMessage, error) {
+		deleted := toEventComment(comment)
+		deleted.Text = "" // Moderated text is not redistributed
+		return newOutboxMessage(events.NewCommentDeletedEvent(postID, userID, likesCount, commentsCount, deleted))
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, 0, ErrCommentNotFound // Deleted since it was loaded
+	}
+	if err != nil {
+		return 0, 0, err
 	}
 
-	return post.LikesCount, post.CommentsCount, nil
+	s.invalidatePostCaches(postID)
+	return likesCount, commentsCount, nil
 }
 
-func (s *InteractionService) CommentPost(userID, postID uint, text string, username string) (int, int, error) {
-	comment := &domain.Comment{
-		UserID: userID,
-		PostID: postID,
-		Text:   text,
+// LikeComment adds userID's like to a comment on postID and returns its like
+// count. Liking it again is a no-op.
+func (s *InteractionService) LikeComment(userID, postID, commentID uint) (int, error) {
+	if _, err := s.findComment(postID, commentID); err != nil {
+		return 0, err
 	}
+	return s.commentRepo.Like(commentID, userID, func(likesCount int) (*domain.OutboxMessage, error) {
+		return newOutboxMessage(events.NewCommentLikedEvent(postID, commentID, userID, likesCount))
+	})
+}
 
-	if err := s.commentRepo.Create(comment); err != nil {
-		return 0, 0, err
+// UnlikeComment removes userID's like from a comment on postID and returns its
+// like count. Unliking a comment that is not liked is a no-op.
+func (s *InteractionService) UnlikeComment(userID, postID, commentID uint) (int, error) {
+	if _, err := s.findComment(postID, commentID); err != nil {
+		return 0, err
 	}
+	// Unlikes reuse the comment_liked event type with the updated count
+	return s.commentRepo.Unlike(commentID, userID, func(likesCount int) (*domain.OutboxMessage, error) {
+		return newOutboxMessage(events.NewCommentLikedEvent(postID, commentID, userID, likesCount))
+	})
+}
 
-	if err := s.commentRepo.IncrementPostCommentCount(postID); err != nil {
-		return 0, 0, err
+// findComment returns the comment, or ErrCommentNotFound unless it is on postID
+func (s *InteractionService) findComment(postID, commentID uint) (*domain.Comment, error) {
+	comment, err := s.commentRepo.FindByID(commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment == nil || comment.PostID != postID {
+		return nil, ErrCommentNotFound
 	}
+	return comment, nil
+}
 
-	// Invalidate post cache
-	cacheKey := fmt.Sprintf("post:%d", postID)
-	ctx := context.Background()
-	s.cache.Delete(ctx, cacheKey)
+// GetComments returns a page of the top-level comments for a post in the
+// given sort order (oldest by default), with their reply counts and whether
+// viewerID liked them, and the cursor for the next page. Top comments are
+// paged by their like count at the time, so a comment can move between pages
+// while it gains likes.
+func (s *InteractionService) GetComments(postID, viewerID uint, sort string, limit int, cursor string) ([]*domain.Comment, string, error) {
+	var comments []*domain.Comment
+	var err error
+
+	switch sort {
+	case "", domain.CommentSortOldest, domain.CommentSortNewest:
+		cursorObj, decodeErr := pagination.DecodeCursor(cursor)
+		if decodeErr != nil {
+			return nil, "", ErrInvalidCursor
+		}
+		comments, err = s.commentRepo.FindByPostID(postID, sort, limit+1, cursorObj) // +1 to check if there are more
+	case domain.CommentSortTop:
+		cursorObj, decodeErr := pagination.DecodeScoreCursor(cursor)
+		if decodeErr != nil {
+			return nil, "", ErrInvalidCursor
+		}
+		comments, err = s.commentRepo.FindTopByPostID(postID, limit+1, cursorObj)
+	default:
+		return nil, "", ErrInvalidSort
+	}
+	if err != nil {
+		return nil, "", err
+	}
 
-	// Invalidate feed cache to ensure updated comment counts appear
-	s.invalidateFeedCache()
+	var nextCursor string
+	if len(comments) > limit {
+		comments = comments[:limit]
+		last := comments[len(comments)-1]
+		if sort == domain.CommentSortTop {
+			nextCursor = (&pagination.ScoreCursor{Score: last.LikesCount, ID: last.ID}).Encode()
+		} else {
+			nextCursor = (&pagination.Cursor{Timestamp: last.CreatedAt, ID: last.ID}).Encode()
+		}
+	}
+
+	if err := s.markLikedByViewer(comments, viewerID); err != nil {
+		return nil, "", err
+	}
+	return comments, nextCursor, nil
+}
 
-	// Get updated counts
-	post, err := s.postRepo.FindByID(postID)
+// GetReplies returns a page of the replies to a comment on postID, oldest
+// first, and the cursor for the next page
+func (s *InteractionService) GetReplies(postID, commentID, viewerID uint, limit int, cursor string) ([]*domain.Comment, string, error) {
+	cursorObj, err := pagination.DecodeCursor(cursor)
 	if err != nil {
-		return 0, 0, err
+		return nil, "", ErrInvalidCursor
 	}
 
-	// Publish comment event with full comment data for real-time updates
-	eventComment := &events.Comment{
-		ID:        comment.ID,
-		Text:      comment.Text,
-		Username:  username,
-		UserID:    userID,
-		CreatedAt: comment.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	if _, err := s.findComment(postID, commentID); err != nil {
+		return nil, "", err
 	}
 
-	if err := s.eventPublisher.PublishPostCommented(ctx, postID, userID, post.LikesCount, post.CommentsCount, eventComment); err != nil {
-		s.logger.Error("failed to publish post commented event",
-			zap.Error(err),
-			zap.Uint("post_id", postID),
-			zap.Uint("user_id", userID))
+	replies, err := s.commentRepo.FindReplies(commentID, limit+1, cursorObj) // +1 to check if there are more
+	if err != nil {
+		return nil, "", err
 	}
 
-	return post.LikesCount, post.CommentsCount, nil
+	var nextCursor string
+	if len(replies) > limit {
+		replies = replies[:limit]
+		last := replies[len(replies)-1]
+		nextCursor = (&pagination.Cursor{Timestamp: last.CreatedAt, ID: last.ID}).Encode()
+	}
+
+	if err := s.markLikedByViewer(replies, viewerID); err != nil {
+		return nil, "", err
+	}
+	return replies, nextCursor, nil
 }
 
-// GetComments retrieves all comments for a post
-func (s *InteractionService) GetComments(postID uint) ([]*domain.Comment, error) {
-	return s.commentRepo.FindByPostID(postID)
+// markLikedByViewer sets LikedByMe on the comments viewerID has liked
+func (s *InteractionService) markLikedByViewer(comments []*domain.Comment, viewerID uint) error {
+	ids := make([]uint, len(comments))
+	for i, comment := range comments {
+		ids[i] = comment.ID
+	}
+
+	liked, err := s.commentRepo.LikedByUser(viewerID, ids)
+	if err != nil {
+		return err
+	}
+	for _, comment := range comments {
+		comment.LikedByMe = liked[comment.ID]
+	}
+	return nil
+}
+
+// GetLikers returns a page of the users who reacted to a post, most recent
+// first, marking those viewerID follows, and the cursor for the next page.
+// Only the author sees the list when they have chosen to hide like counts.
+func (s *InteractionService) GetLikers(postID, viewerID uint, limit int, cursor string) ([]*domain.Liker, string, error) {
+	cursorObj, err := pagination.DecodeCursor(cursor)
+	if err != nil {
+		return nil, "", ErrInvalidCursor
+	}
+
+	posts, err := s.postRepo.FindByIDs([]uint{postID})
+	if err != nil {
+		return nil, "", err
+	}
+	if len(posts) == 0 {
+		return nil, "", ErrPostNotFound
+	}
+	if posts[0].HideLikers && posts[0].UserID != viewerID {
+		return nil, "", ErrLikesHidden
+	}
+
+	likers, err := s.likeRepo.FindLikers(postID, viewerID, limit+1, cursorObj) // +1 to check if there are more
+	if err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(likers) > limit {
+		likers = likers[:limit]
+		last := likers[len(likers)-1]
+		nextCursor = (&pagination.Cursor{Timestamp: last.LikedAt, ID: last.ID}).Encode()
+	}
+	return likers, nextCursor, nil
 }
 
 // GetPost retrieves a post by ID
@@ -178,17 +413,92 @@ func (s *InteractionService) GetPost(postID uint) (*domain.Post, error) {
 	return s.postRepo.FindByID(postID)
 }
 
+// notifyAuthor tells the post's author about the interaction. The interaction
+// has already been saved, so a failure is logged rather than returned.
+func (s *InteractionService) notifyAuthor(notificationType string, postID, actorID uint) {
+	if s.notificationService == nil {
+		return
+	}
+	if _, err := s.notificationService.Notify(notificationType, postID, actorID); err != nil {
+		s.logger.Error("failed to notify post author",
+			zap.Error(err),
+			zap.String("type", notificationType),
+			zap.Uint("post_id", postID),
+			zap.Uint("actor_id", actorID))
+	}
+}
+
+// saveMentions stores the mentions in the comment's text and sets them on the
+// comment (best effort - the comment is already stored)
+func (s *InteractionService) saveMentions(comment *domain.Comment) {
+	mentions := s.resolveMentions(comment)
+	comment.Mentions = []domain.Mention{}
+	if s.mentionService == nil {
+		return
+	}
+	if err := s.mentionService.SaveMentions(comment.PostID, &comment.ID, comment.UserID, comment.Text, mentions); err != nil {
+		s.logger.Error("failed to save mentions",
+			zap.Uint("comment_id", comment.ID),
+			zap.Error(err))
+		return
+	}
+	comment.Mentions = mentions
+}
+
+// resolveMentions returns the mentions in the comment's text (best effort - a
+// comment without them is still valid)
+func (s *InteractionService) resolveMentions(comment *domain.Comment) []domain.Mention {
+	if s.mentionService == nil {
+		return []domain.Mention{}
+	}
+	mentions, err := s.mentionService.Resolve(comment.Text)
+	if err != nil {
+		s.logger.Error("failed to resolve mentions",
+			zap.Uint("comment_id", comment.ID),
+			zap.Error(err))
+		return []domain.Mention{}
+	}
+	return mentions
+}
+
+// toEventComment converts a saved comment to its event payload
+func toEventComment(comment *domain.Comment) *events.Comment {
+	eventComment := &events.Comment{
+		ID:        comment.ID,
+		ParentID:  comment.ParentID,
+		Text:      comment.Text,
+		Username:  comment.Username,
+		UserID:    comment.UserID,
+		CreatedAt: comment.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if comment.EditedAt != nil {
+		eventComment.EditedAt = comment.EditedAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+	return eventComment
+}
+
+// invalidatePostCaches drops the cached post and feed so updated counts appear
+func (s *InteractionService) invalidatePostCaches(postID uint) {
+	cacheKey := fmt.Sprintf("post:%d", postID)
+	s.cache.Delete(context.Background(), cacheKey)
+	s.invalidateFeedCache()
+}
+
 // invalidateFeedCache clears feed cache entries
 func (s *InteractionService) invalidateFeedCache() {
 	ctx := context.Background()
 
-	// Clear the most common feed cache key (empty cursor, default limit)
+	// Clear the most common feed cache key (empty cursor, default limit) of every ranker
 	// This is a simple approach - in production you might want to clear all feed patterns
-	cacheKey := "feed:cursor::limit:5" // Default feed cache key
-	if err := s.cache.Delete(ctx, cacheKey); err != nil {
-		// Log error but don't fail the operation
-		fmt.Printf("failed to clear feed cache: %v\n", err)
-	} else {
-		fmt.Printf("cleared feed cache: %s\n", cacheKey)
+	for _, ranker := range RankerNames {
+		cacheKey := feedCacheKey(ranker, "", 5) // Default feed cache key
+		if err := s.cache.Delete(ctx, cacheKey); err != nil {
+			// Log error but don't fail the operation
+			s.logger.Warn("failed to clear feed cache",
+				zap.String("cache_key", cacheKey),
+				zap.Error(err))
+		} else {
+			s.logger.Info("cleared feed cache", zap.String("cache_key", cacheKey))
+		}
 	}
 }
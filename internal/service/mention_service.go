@@ -0,0 +1,79 @@
+package service
+
+import (
+	"github.com/rodolfodpk/instagrano/internal/domain"
+	"github.com/rodolfodpk/instagrano/internal/events"
+	"github.com/rodolfodpk/instagrano/internal/repository/postgres"
+	"go.uber.org/zap"
+)
+
+// MentionService resolves @username mentions in captions and comments and
+// tells the mentioned users through a user_mentioned event, written to the
+// outbox with the mentions
+type MentionService struct {
+	userRepo    postgres.UserRepository
+	mentionRepo postgres.MentionRepository
+	logger      *zap.Logger
+}
+
+func NewMentionService(userRepo postgres.UserRepository, mentionRepo postgres.MentionRepository, logger *zap.Logger) *MentionService {
+	return &MentionService{
+		userRepo:    userRepo,
+		mentionRepo: mentionRepo,
+		logger:      logger,
+	}
+}
+
+// Resolve returns the mentions in text of existing users. Usernames are
+// matched exactly; the others are not mentions.
+func (s *MentionService) Resolve(text string) ([]domain.Mention, error) {
+	parsed := domain.ParseMentions(text)
+	if len(parsed) == 0 {
+		return []domain.Mention{}, nil
+	}
+
+	usernames := make([]string, len(parsed))
+	for i, mention := range parsed {
+		usernames[i] = mention.Username
+	}
+	users, err := s.userRepo.FindByUsernames(usernames)
+	if err != nil {
+		return nil, err
+	}
+	userIDs := make(map[string]uint, len(users))
+	for _, user := range users {
+		userIDs[user.Username] = user.ID
+	}
+
+	mentions := make([]domain.Mention, 0, len(parsed))
+	for _, mention := range parsed {
+		if userID, ok := userIDs[mention.Username]; ok {
+			mention.UserID = userID
+			mentions = append(mentions, mention)
+		}
+	}
+	return mentions, nil
+}
+
+// SaveMentions stores mentions, as resolved from text, a post's caption
+// (commentID nil) or one of its comments written by authorID, in place of the
+// previous ones. Users newly mentioned there, other than the author, get a
+// user_mentioned event.
+func (s *MentionService) SaveMentions(postID uint, commentID *uint, authorID uint, text string, mentions []domain.Mention) error {
+	err := s.mentionRepo.Replace(postID, commentID, authorID, mentions, mentionEvent(postID, commentID, authorID, text))
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("mentions saved",
+		zap.Uint("post_id", postID),
+		zap.Int("mentions", len(mentions)))
+	return nil
+}
+
+// mentionEvent builds the user_mentioned event for a user mentioned in text
+func mentionEvent(postID uint, commentID *uint, authorID uint, text string) postgres.MentionEventFunc {
+	return func(mention domain.Mention) (*domain.OutboxMessage, error) {
+		return newOutboxMessage(events.NewUserMentionedEvent(postID, commentID, authorID, mention.UserID, text))
+	}
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/rodolfodpk/instagrano/internal/domain"
+	"github.com/rodolfodpk/instagrano/internal/events"
+	"github.com/rodolfodpk/instagrano/internal/pagination"
+	"github.com/rodolfodpk/instagrano/internal/repository/postgres"
+	"go.uber.org/zap"
+)
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// NotificationService keeps each user's inbox of likes and comments on their
+// posts and pushes changes to the owner's WebSocket/SSE connections
+type NotificationService struct {
+	notificationRepo postgres.NotificationRepository
+	logger           *zap.Logger
+}
+
+func NewNotificationService(notificationRepo postgres.NotificationRepository, logger *zap.Logger) *NotificationService {
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+		logger:           logger,
+	}
+}
+
+// Notify records that actorID liked or commented on postID for the post's
+// author. Interactions with one's own posts are not notified. The
+// notification event is recorded in the outbox with the notification.
+func (s *NotificationService) Notify(notificationType string, postID, actorID uint) (*domain.Notification, error) {
+	return s.notificationRepo.Record(notificationType, postID, actorID, func(notification *domain.Notification, unreadCount int) (*domain.OutboxMessage, error) {
+		return newOutboxMessage(events.NewNotificationEvent(notification.UserID, postID, actorID, events.NotificationData{
+			Notification: notification,
+			Message:      notification.Message(),
+			UnreadCount:  unreadCount,
+		}))
+	})
+}
+
+// List returns a page of userID's notifications, most recently updated first,
+// and the cursor for the next page. A notification updated while paging moves
+// to the front and is not repeated on later pages; its notification event
+// carries the update.
+func (s *NotificationService) List(userID uint, limit int, cursor string) ([]*domain.Notification, string, error) {
+	cursorObj, err := pagination.DecodeCursor(cursor)
+	if err != nil {
+		return nil, "", ErrInvalidCursor
+	}
+
+	notifications, err := s.notificationRepo.FindByUserID(userID, limit+1, cursorObj) // +1 to check if there are more
+	if err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(notifications) > limit {
+		notifications = notifications[:limit]
+		last := notifications[len(notifications)-1]
+		nextCursor = (&pagination.Cursor{Timestamp: last.UpdatedAt, ID: last.ID}).Encode()
+	}
+
+	return notifications, nextCursor, nil
+}
+
+// UnreadCount returns how many of userID's notifications are unread
+func (s *NotificationService) UnreadCount(userID uint) (int, error) {
+	return s.notificationRepo.CountUnread(userID)
+}
+
+// MarkRead marks one of userID's notifications read
+func (s *NotificationService) MarkRead(userID, notificationID uint) error {
+	found, err := s.notificationRepo.MarkRead(notificationID, userID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+// MarkAllRead marks all of userID's notifications read and returns how many were unread
+func (s *NotificationService) MarkAllRead(userID uint) (int, error) {
+	return s.notificationRepo.MarkAllRead(userID)
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/rodolfodpk/instagrano/internal/domain"
+	"github.com/rodolfodpk/instagrano/internal/events"
+	"github.com/rodolfodpk/instagrano/internal/repository/postgres"
+	"go.uber.org/zap"
+)
+
+const (
+	// outboxLease is how long a claimed message is hidden from other relays
+	outboxLease = 30 * time.Second
+	// outboxMaxBackoff caps the delay between delivery attempts
+	outboxMaxBackoff = 5 * time.Minute
+	// outboxPruneInterval is how often delivered messages past retention are deleted
+	outboxPruneInterval = time.Minute
+	// outboxPruneBatch is how many delivered messages are deleted at a time
+	outboxPruneBatch = 1000
+)
+
+// OutboxRelay publishes events recorded in the outbox table to the event bus.
+// A message is marked delivered only after it was published, so every event
+// is delivered at least once; a crash between the two publishes it again.
+// A message that fails maxAttempts times, or cannot be decoded at all, is
+// dead-lettered and kept for inspection. Delivered messages are deleted once
+// they are older than retention.
+type OutboxRelay struct {
+	outboxRepo     postgres.OutboxRepository
+	eventPublisher *events.Publisher
+	batchSize      int
+	maxAttempts    int
+	pollInterval   time.Duration
+	retention      time.Duration
+	logger         *zap.Logger
+}
+
+func NewOutboxRelay(outboxRepo postgres.OutboxRepository, eventPublisher *events.Publisher, batchSize, maxAttempts int, pollInterval, retention time.Duration, logger *zap.Logger) *OutboxRelay {
+	return &OutboxRelay{
+		outboxRepo:     outboxRepo,
+		eventPublisher: eventPublisher,
+		batchSize:      batchSize,
+		maxAttempts:    maxAttempts,
+		pollInterval:   pollInterval,
+		retention:      retention,
+		logger:         logger,
+	}
+}
+
+// Run relays pending messages every poll interval, and prunes delivered
+// ones every minute, until ctx is done
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+	pruneTicker := time.NewTicker(outboxPruneInterval)
+	defer pruneTicker.Stop()
+
+	for {
+		// Drain full batches without waiting for the next tick
+		for {
+			relayed, err := r.RelayPending(ctx)
+			if err != nil {
+				r.logger.Error("failed to relay outbox messages", zap.Error(err))
+				break
+			}
+			if relayed < r.batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-pruneTicker.C:
+			if _, err := r.PruneDelivered(); err != nil {
+				r.logger.Error("failed to prune outbox messages", zap.Error(err))
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// RelayPending publishes one batch of pending messages and returns how many
+// were claimed. Messages that fail to publish are retried with backoff until
+// they run out of attempts.
+func (r *OutboxRelay) RelayPending(ctx context.Context) (int, error) {
+	messages, err := r.outboxRepo.ClaimPending(r.batchSize, outboxLease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, msg := range messages {
+		var event events.Event
+		if err := json.Unmarshal(msg.Payload, &event); err != nil {
+			// Retrying cannot fix the payload
+			if err := r.fail(msg, fmt.Errorf("invalid outbox payload: %w", err), 0); err != nil {
+				return len(messages), err
+			}
+			continue
+		}
+
+		if err := r.eventPublisher.Publish(ctx, event); err != nil {
+			var retryAfter time.Duration
+			if msg.Attempts+1 < r.maxAttempts {
+				retryAfter = retryBackoff(msg.Attempts, time.Second, outboxMaxBackoff)
+			}
+			if err := r.fail(msg, err, retryAfter); err != nil {
+				return len(messages), err
+			}
+			continue
+		}
+
+		if err := r.outboxRepo.MarkDelivered(msg.ID); err != nil {
+			return len(messages), err
+		}
+	}
+	return len(messages), nil
+}
+
+// PruneDelivered deletes the messages delivered longer ago than the
+// retention and returns how many were deleted
+func (r *OutboxRelay) PruneDelivered() (int, error) {
+	total := 0
+	for {
+		deleted, err := r.outboxRepo.DeleteDelivered(r.retention, outboxPruneBatch)
+		total += deleted
+		if err != nil || deleted < outboxPruneBatch {
+			return total, err
+		}
+	}
+}
+
+// fail records a failed attempt, retrying after retryAfter or dead-lettering
+// the message when it is zero
+func (r *OutboxRelay) fail(msg *domain.OutboxMessage, deliveryErr error, retryAfter time.Duration) error {
+	fields := []zap.Field{
+		zap.Error(deliveryErr),
+		zap.Int64("outbox_id", msg.ID),
+		zap.String("event_type", msg.EventType),
+		zap.Int("attempts", msg.Attempts+1),
+	}
+	if retryAfter == 0 {
+		r.logger.Error("dead-lettering outbox message", fields...)
+	} else {
+		r.logger.Warn("failed to publish outbox message", append(fields, zap.Duration("retry_after", retryAfter))...)
+	}
+	return r.outboxRepo.MarkFailed(msg.ID, deliveryErr, retryAfter)
+}
+
+// retryBackoff doubles the retry delay from base with every attempt already
+// made, up to maxBackoff
+func retryBackoff(attempts int, base, maxBackoff time.Duration) time.Duration {
+	if attempts >= 16 {
+		return maxBackoff
+	}
+	backoff := base << attempts
+	if backoff > maxBackoff {
+		return maxBackoff
+	}
+	return backoff
+}
+
+// newOutboxMessage encodes an event for the outbox, stamped with the time of
+// the change rather than the time it is relayed
+func newOutboxMessage(event events.Event) (*domain.OutboxMessage, error) {
+	event.Timestamp = time.Now().Unix()
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode outbox event: %w", err)
+	}
+	return &domain.OutboxMessage{
+		EventType: string(event.Type),
+		Payload:   payload,
+	}, nil
+}
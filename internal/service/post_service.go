@@ -2,75 +2,101 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/rodolfodpk/instagrano/internal/cache"
 	"github.com/rodolfodpk/instagrano/internal/domain"
+	"github.com/rodolfodpk/instagrano/internal/events"
 	"github.com/rodolfodpk/instagrano/internal/repository/postgres"
 	"github.com/rodolfodpk/instagrano/internal/repository/s3"
 	"go.uber.org/zap"
 )
 
+var (
+	ErrPostForbidden = errors.New("not allowed to change this post")
+	ErrPostConflict  = errors.New("post was changed since it was read")
+	ErrTooManyMedia  = fmt.Errorf("a post can have at most %d media items", domain.MaxPostMedia)
+)
+
 type PostService struct {
-	postRepo     postgres.PostRepository
-	mediaStorage s3.MediaStorage
-	cache        cache.Cache
-	cacheTTL     time.Duration
-	logger       *zap.Logger
+	postRepo        postgres.PostRepository
+	mediaStorage    s3.MediaStorage
+	cache           cache.Cache
+	cacheTTL        time.Duration
+	timelineService *TimelineService
+	hashtagService  *HashtagService
+	mentionService  *MentionService
+	logger          *zap.Logger
 }
 
-func NewPostService(postRepo postgres.PostRepository, mediaStorage s3.MediaStorage, cache cache.Cache, cacheTTL time.Duration) *PostService {
+// NewPostService creates a post service. timelineService may be nil, in which
+// case posts are not fanned out to follower timelines, and so may
+// hashtagService, in which case hashtags are not counted towards trending,
+// and mentionService, in which case captions have no mentions.
+func NewPostService(postRepo postgres.PostRepository, mediaStorage s3.MediaStorage, cache cache.Cache, cacheTTL time.Duration, timelineService *TimelineService, hashtagService *HashtagService, mentionService *MentionService) *PostService {
 	logger, _ := zap.NewProduction()
 	return &PostService{
-		postRepo:     postRepo,
-		mediaStorage: mediaStorage,
-		cache:        cache,
-		cacheTTL:     cacheTTL,
-		logger:       logger,
+		postRepo:        postRepo,
+		mediaStorage:    mediaStorage,
+		cache:           cache,
+		cacheTTL:        cacheTTL,
+		timelineService: timelineService,
+		hashtagService:  hashtagService,
+		mentionService:  mentionService,
+		logger:          logger,
 	}
 }
 
+// MediaUpload is a media file for a new post
+type MediaUpload struct {
+	File      io.Reader
+	Filename  string
+	MediaType domain.MediaType
+}
+
 func (s *PostService) CreatePost(userID uint, title, caption string, mediaType domain.MediaType, file io.Reader, filename string) (*domain.Post, error) {
-	if title == "" {
-		return nil, ErrInvalidInput
-	}
+	return s.CreateCarouselPost(userID, title, caption, []MediaUpload{{File: file, Filename: filename, MediaType: mediaType}})
+}
 
-	// Determine content type based on media type
-	contentType := "image/jpeg"
-	if mediaType == domain.MediaTypeVideo {
-		contentType = "video/mp4"
+// CreateCarouselPost creates a post with up to domain.MaxPostMedia media
+// files, in order. The files are uploaded to S3 concurrently; if any upload
+// fails, the others are deleted again and no post is created.
+func (s *PostService) CreateCarouselPost(userID uint, title, caption string, uploads []MediaUpload) (*domain.Post, error) {
+	if title == "" || len(uploads) == 0 {
+		return nil, ErrInvalidInput
 	}
-
-	// Upload file to S3
-	key, err := s.mediaStorage.Upload(file, filename, contentType)
-	if err != nil {
-		return nil, fmt.Errorf("failed to upload file to S3: %w", err)
+	if len(uploads) > domain.MaxPostMedia {
+		return nil, ErrTooManyMedia
 	}
 
-	// Generate URL from S3 key
-	mediaURL := s.mediaStorage.GetURL(key)
+	media, keys, err := s.uploadMedia(len(uploads), func(i int) (string, domain.MediaType, error) {
+		upload := uploads[i]
 
-	post := &domain.Post{
-		UserID:    userID,
-		Title:     title,
-		Caption:   caption,
-		MediaType: mediaType,
-		MediaURL:  mediaURL,
-	}
+		// Determine content type based on media type
+		contentType := "image/jpeg"
+		if upload.MediaType == domain.MediaTypeVideo {
+			contentType = "video/mp4"
+		}
 
-	if err := s.postRepo.Create(post); err != nil {
+		key, err := s.mediaStorage.Upload(upload.File, upload.Filename, contentType)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to upload file to S3: %w", err)
+		}
+		return key, upload.MediaType, nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	// Invalidate feed cache to ensure new post appears
-	s.invalidateFeedCache()
-
-	return post, nil
+	return s.createPost(userID, title, caption, media, keys)
 }
 
 func (s *PostService) GetPost(postID uint) (*domain.Post, error) {
@@ -109,65 +135,302 @@ func (s *PostService) GetPost(postID uint) (*domain.Post, error) {
 
 // CreatePostFromURL creates a post by downloading media from a URL
 func (s *PostService) CreatePostFromURL(userID uint, title, caption, mediaURL string) (*domain.Post, error) {
+	return s.CreatePostFromURLs(userID, title, caption, []string{mediaURL})
+}
+
+// CreatePostFromURLs creates a carousel post by downloading media from up to
+// domain.MaxPostMedia URLs, in order. Like CreateCarouselPost, the media is
+// uploaded concurrently and deleted again if any URL fails.
+func (s *PostService) CreatePostFromURLs(userID uint, title, caption string, mediaURLs []string) (*domain.Post, error) {
 	if title == "" {
 		return nil, ErrInvalidInput
 	}
+	if len(mediaURLs) > domain.MaxPostMedia {
+		return nil, ErrTooManyMedia
+	}
 
-	if mediaURL == "" {
+	if len(mediaURLs) == 0 {
 		return nil, fmt.Errorf("media URL is required")
 	}
+	for _, mediaURL := range mediaURLs {
+		if mediaURL == "" {
+			return nil, fmt.Errorf("media URL is required")
+		}
 
-	// Validate URL format
-	if _, err := url.Parse(mediaURL); err != nil {
-		return nil, fmt.Errorf("invalid URL format: %w", err)
+		// Validate URL format
+		if _, err := url.Parse(mediaURL); err != nil {
+			return nil, fmt.Errorf("invalid URL format: %w", err)
+		}
 	}
 
-	// Download from URL and upload to S3
-	key, contentType, err := s.mediaStorage.UploadFromURL(mediaURL)
+	media, keys, err := s.uploadMedia(len(mediaURLs), func(i int) (string, domain.MediaType, error) {
+		// Download from URL and upload to S3
+		key, contentType, err := s.mediaStorage.UploadFromURL(mediaURLs[i])
+		if err != nil {
+			return "", "", fmt.Errorf("failed to process media URL: %w", err)
+		}
+
+		// Determine media type from content type
+		mediaType := domain.MediaTypeImage
+		if strings.HasPrefix(contentType, "video/") {
+			mediaType = domain.MediaTypeVideo
+		}
+		return key, mediaType, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to process media URL: %w", err)
+		return nil, err
 	}
 
-	// Determine media type from content type
-	mediaType := domain.MediaTypeImage
-	if strings.HasPrefix(contentType, "video/") {
-		mediaType = domain.MediaTypeVideo
+	return s.createPost(userID, title, caption, media, keys)
+}
+
+// uploadMedia runs upload for items 0 to n-1 concurrently and returns the
+// media items in order with their S3 keys. If any upload fails, the objects
+// already uploaded are deleted and the first failure is returned.
+func (s *PostService) uploadMedia(n int, upload func(i int) (string, domain.MediaType, error)) ([]domain.PostMedia, []string, error) {
+	media := make([]domain.PostMedia, n)
+	keys := make([]string, n)
+	errs := make([]error, n)
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key, mediaType, err := upload(i)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			keys[i] = key
+			media[i] = domain.PostMedia{Position: i, MediaType: mediaType, MediaURL: s.mediaStorage.GetURL(key)}
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			s.deleteMedia(keys)
+			return nil, nil, err
+		}
 	}
+	return media, keys, nil
+}
 
-	// Generate S3 URL
-	s3URL := s.mediaStorage.GetURL(key)
+// deleteMedia removes uploaded objects of a post that was not created (best
+// effort - a leftover object is only wasted space)
+func (s *PostService) deleteMedia(keys []string) {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := s.mediaStorage.Delete(key); err != nil {
+			s.logger.Warn("failed to delete uploaded media",
+				zap.String("key", key),
+				zap.Error(err))
+		}
+	}
+}
 
+// createPost saves a post with its uploaded media, deleting the media again
+// if the post cannot be saved
+func (s *PostService) createPost(userID uint, title, caption string, media []domain.PostMedia, keys []string) (*domain.Post, error) {
 	post := &domain.Post{
-		UserID:    userID,
-		Title:     title,
-		Caption:   caption,
-		MediaType: mediaType,
-		MediaURL:  s3URL,
+		UserID:  userID,
+		Title:   title,
+		Caption: caption,
+		Media:   media,
 	}
 
 	if err := s.postRepo.Create(post); err != nil {
+		s.deleteMedia(keys)
 		return nil, err
 	}
 
+	post.Mentions = s.resolveMentions(post.Caption)
+	s.saveMentions(post)
+
 	// Invalidate feed cache to ensure new post appears
 	s.invalidateFeedCache()
+	s.fanOutPost(post)
+	s.recordHashtags(domain.ParseHashtags(post.Caption), post.CreatedAt)
 
 	return post, nil
 }
 
+// UpdatePost changes the title and/or caption of userID's post, provided it
+// is still at version (the updated_at the caller read). The previous title and
+// caption are kept in the post's edit history.
+func (s *PostService) UpdatePost(postID, userID uint, title, caption *string, version time.Time) (*domain.Post, error) {
+	if title == nil && caption == nil {
+		return nil, ErrInvalidInput
+	}
+
+	post, err := s.postRepo.FindByID(postID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPostNotFound
+		}
+		return nil, err
+	}
+	if post.UserID != userID {
+		return nil, ErrPostForbidden
+	}
+
+	oldHashtags := domain.ParseHashtags(post.Caption)
+	if title != nil {
+		post.Title = strings.TrimSpace(*title)
+	}
+	if caption != nil {
+		post.Caption = *caption
+	}
+	if post.Title == "" {
+		return nil, ErrInvalidInput
+	}
+	post.Mentions = s.resolveMentions(post.Caption)
+
+	// Mentions are saved with the edit, so they never disagree with the caption
+	var mentions postgres.MentionEventFunc
+	if s.mentionService != nil {
+		mentions = mentionEvent(post.ID, nil, userID, post.Caption)
+	}
+	updated, err := s.postRepo.UpdateWithOutbox(post, version, func(post *domain.Post) (*domain.OutboxMessage, error) {
+		return newOutboxMessage(events.NewPostUpdatedEvent(post.ID, userID, post))
+	}, mentions)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPostNotFound // Deleted since it was loaded
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrPostConflict
+	}
+
+	s.cache.Delete(context.Background(), fmt.Sprintf("post:%d", postID))
+	s.invalidateFeedPages()
+	s.recordHashtags(addedHashtags(oldHashtags, domain.ParseHashtags(post.Caption)), post.UpdatedAt)
+
+	s.logger.Info("post updated",
+		zap.Uint("post_id", postID),
+		zap.Uint("user_id", userID))
+	return post, nil
+}
+
+// invalidateFeedPages clears every cached feed page. An edited post can be on
+// any page, unlike a new one, which is only on the first.
+func (s *PostService) invalidateFeedPages() {
+	ctx := context.Background()
+
+	keys, err := s.cache.Keys(ctx, "feed:*:cursor:*")
+	if err != nil {
+		s.logger.Warn("failed to list feed cache keys", zap.Error(err))
+		return
+	}
+	for _, key := range keys {
+		if err := s.cache.Delete(ctx, key); err != nil {
+			s.logger.Warn("failed to clear feed cache",
+				zap.String("cache_key", key),
+				zap.Error(err))
+		}
+	}
+}
+
 // invalidateFeedCache clears feed cache entries
 func (s *PostService) invalidateFeedCache() {
 	ctx := context.Background()
 
-	// Clear the most common feed cache key (empty cursor, default limit)
+	// Clear the most common feed cache key (empty cursor, default limit) of every ranker
 	// This is a simple approach - in production you might want to clear all feed patterns
-	cacheKey := "feed:cursor::limit:5" // Default feed cache key
-	if err := s.cache.Delete(ctx, cacheKey); err != nil {
-		s.logger.Warn("failed to clear feed cache",
-			zap.String("cache_key", cacheKey),
+	for _, ranker := range RankerNames {
+		cacheKey := feedCacheKey(ranker, "", 5) // Default feed cache key
+		if err := s.cache.Delete(ctx, cacheKey); err != nil {
+			s.logger.Warn("failed to clear feed cache",
+				zap.String("cache_key", cacheKey),
+				zap.Error(err))
+		} else {
+			s.logger.Info("cleared feed cache", zap.String("cache_key", cacheKey))
+		}
+	}
+}
+
+// fanOutPost pushes a new post to follower timelines (best effort - the post
+// is already stored)
+func (s *PostService) fanOutPost(post *domain.Post) {
+	if s.timelineService == nil {
+		return
+	}
+	if err := s.timelineService.FanOutPost(context.Background(), post); err != nil {
+		s.logger.Error("failed to fan out post",
+			zap.Uint("post_id", post.ID),
+			zap.Error(err))
+	}
+}
+
+// recordHashtags counts hashtags towards trending (best effort - the post is
+// already stored)
+func (s *PostService) recordHashtags(tags []string, at time.Time) {
+	if s.hashtagService == nil {
+		return
+	}
+	if err := s.hashtagService.RecordHashtags(context.Background(), tags, at); err != nil {
+		s.logger.Error("failed to record hashtags",
+			zap.Strings("hashtags", tags),
+			zap.Error(err))
+	}
+}
+
+// resolveMentions returns the mentions in a caption (best effort - a caption
+// without them is still valid)
+func (s *PostService) resolveMentions(caption string) []domain.Mention {
+	if s.mentionService == nil {
+		return []domain.Mention{}
+	}
+	mentions, err := s.mentionService.Resolve(caption)
+	if err != nil {
+		s.logger.Error("failed to resolve mentions", zap.Error(err))
+		return []domain.Mention{}
+	}
+	return mentions
+}
+
+// saveMentions stores the post's mentions (best effort - the post is already
+// stored)
+func (s *PostService) saveMentions(post *domain.Post) {
+	if s.mentionService == nil {
+		return
+	}
+	if err := s.mentionService.SaveMentions(post.ID, nil, post.UserID, post.Caption, post.Mentions); err != nil {
+		s.logger.Error("failed to save mentions",
+			zap.Uint("post_id", post.ID),
+			zap.Error(err))
+	}
+}
+
+// addedHashtags returns the hashtags in after that are not in before
+func addedHashtags(before, after []string) []string {
+	seen := make(map[string]bool, len(before))
+	for _, tag := range before {
+		seen[tag] = true
+	}
+	var added []string
+	for _, tag := range after {
+		if !seen[tag] {
+			added = append(added, tag)
+		}
+	}
+	return added
+}
+
+// removeFromTimelines drops a deleted post from materialized timelines
+func (s *PostService) removeFromTimelines(post *domain.Post) {
+	if s.timelineService == nil {
+		return
+	}
+	if err := s.timelineService.RemovePost(context.Background(), post); err != nil {
+		s.logger.Error("failed to remove post from timelines",
+			zap.Uint("post_id", post.ID),
 			zap.Error(err))
-	} else {
-		s.logger.Info("cleared feed cache", zap.String("cache_key", cacheKey))
 	}
 }
 
@@ -176,7 +439,10 @@ func (s *PostService) DeletePost(postID, userID uint) error {
 	// First, get the post to check ownership
 	post, err := s.postRepo.GetByID(postID)
 	if err != nil {
-		return fmt.Errorf("post not found")
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrPostNotFound
+		}
+		return err
 	}
 
 	// Check if the user is the author of the post
@@ -186,11 +452,15 @@ func (s *PostService) DeletePost(postID, userID uint) error {
 
 	// Delete the post (this will cascade delete likes and comments due to foreign key constraints)
 	if err := s.postRepo.Delete(postID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrPostNotFound // Deleted by a concurrent request
+		}
 		return fmt.Errorf("failed to delete post: %w", err)
 	}
 
 	// Invalidate feed cache to ensure deleted post disappears
 	s.invalidateFeedCache()
+	s.removeFromTimelines(post)
 
 	s.logger.Info("post deleted successfully",
 		zap.Uint("post_id", postID),
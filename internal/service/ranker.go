@@ -0,0 +1,143 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/rodolfodpk/instagrano/internal/domain"
+)
+
+var ErrUnknownRanker = errors.New("unknown ranker")
+
+// Built-in ranker names, selectable with FEED_RANKER or ?rank=
+const (
+	RankChronological = "chronological"
+	RankDecay         = "decay"
+	RankGravity       = "gravity"
+	RankWilson        = "wilson"
+)
+
+// RankerNames lists the built-in rankers
+var RankerNames = []string{RankChronological, RankDecay, RankGravity, RankWilson}
+
+// Ranker scores posts for the ranked feed. Higher scores rank first; ties are
+// broken by post ID. now is shared by every post ranked in the same snapshot.
+type Ranker interface {
+	Name() string
+	Score(post *domain.Post, now time.Time) float64
+}
+
+// RankingWeights tunes the built-in rankers
+type RankingWeights struct {
+	LikeWeight    float64
+	CommentWeight float64
+	ViewWeight    float64
+	SaveWeight    float64
+	DecayRate     float64 // per hour, for the decay ranker
+	Gravity       float64 // age exponent, for the gravity ranker
+	WilsonZ       float64 // confidence z-score, for the wilson ranker
+	// ReactionWeights overrides LikeWeight per reaction type
+	ReactionWeights map[string]float64
+}
+
+// engagement is the weighted sum of a post's reactions, comments, views and
+// saves
+func (w RankingWeights) engagement(post *domain.Post) float64 {
+	return w.reactions(post) +
+		float64(post.CommentsCount)*w.CommentWeight +
+		float64(post.ViewsCount)*w.ViewWeight +
+		float64(post.SavesCount)*w.SaveWeight
+}
+
+// reactions weights each reaction type by its ReactionWeights entry, or by
+// LikeWeight when it has none. Reactions counted in LikesCount but missing
+// from ReactionCounts are weighted as likes.
+func (w RankingWeights) reactions(post *domain.Post) float64 {
+	var score float64
+	counted := 0
+	for reaction, count := range post.ReactionCounts {
+		weight, ok := w.ReactionWeights[reaction]
+		if !ok {
+			weight = w.LikeWeight
+		}
+		score += float64(count) * weight
+		counted += count
+	}
+	if rest := post.LikesCount - counted; rest > 0 {
+		score += float64(rest) * w.LikeWeight
+	}
+	return score
+}
+
+// NewRanker returns the built-in ranker with the given name
+func NewRanker(name string, weights RankingWeights) (Ranker, error) {
+	switch name {
+	case RankChronological:
+		return &ChronologicalRanker{}, nil
+	case RankDecay:
+		return &DecayRanker{weights: weights}, nil
+	case RankGravity:
+		return &GravityRanker{weights: weights}, nil
+	case RankWilson:
+		return &WilsonRanker{weights: weights}, nil
+	default:
+		return nil, ErrUnknownRanker
+	}
+}
+
+// ChronologicalRanker ranks newest posts first
+type ChronologicalRanker struct{}
+
+func (r *ChronologicalRanker) Name() string { return RankChronological }
+
+// Score is the creation time in Unix seconds, keeping microseconds
+func (r *ChronologicalRanker) Score(post *domain.Post, now time.Time) float64 {
+	return float64(post.CreatedAt.UnixMicro()) / 1e6
+}
+
+// DecayRanker weights engagement by an exponential decay on age in hours
+type DecayRanker struct {
+	weights RankingWeights
+}
+
+func (r *DecayRanker) Name() string { return RankDecay }
+
+func (r *DecayRanker) Score(post *domain.Post, now time.Time) float64 {
+	ageHours := now.Sub(post.CreatedAt).Hours()
+	return r.weights.engagement(post) * math.Exp(-r.weights.DecayRate*ageHours)
+}
+
+// GravityRanker is the Hacker News formula: engagement / (ageHours + 2)^gravity
+type GravityRanker struct {
+	weights RankingWeights
+}
+
+func (r *GravityRanker) Name() string { return RankGravity }
+
+func (r *GravityRanker) Score(post *domain.Post, now time.Time) float64 {
+	ageHours := math.Max(now.Sub(post.CreatedAt).Hours(), 0)
+	return r.weights.engagement(post) / math.Pow(ageHours+2, r.weights.Gravity)
+}
+
+// WilsonRanker ranks by the lower bound of the Wilson score interval of the
+// share of views that led to a like, comment or save. It ignores age and favours
+// posts whose engagement rate is high with confidence.
+type WilsonRanker struct {
+	weights RankingWeights
+}
+
+func (r *WilsonRanker) Name() string { return RankWilson }
+
+func (r *WilsonRanker) Score(post *domain.Post, now time.Time) float64 {
+	positive := float64(post.LikesCount + post.CommentsCount + post.SavesCount)
+	// Views are not always tracked, so never let them undercount engagement
+	n := math.Max(float64(post.ViewsCount), positive)
+	if n == 0 {
+		return 0
+	}
+
+	z := r.weights.WilsonZ
+	p := positive / n
+	return (p + z*z/(2*n) - z*math.Sqrt((p*(1-p)+z*z/(4*n))/n)) / (1 + z*z/n)
+}
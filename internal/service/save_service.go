@@ -0,0 +1,203 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rodolfodpk/instagrano/internal/domain"
+	"github.com/rodolfodpk/instagrano/internal/pagination"
+	"github.com/rodolfodpk/instagrano/internal/repository/postgres"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrCollectionNotFound    = errors.New("collection not found")
+	ErrCollectionNameTaken   = errors.New("collection name already in use")
+	ErrInvalidCollectionName = errors.New("invalid collection name")
+)
+
+// SaveService bookmarks posts for a user, optionally filed into named
+// collections. Saves are private: nothing is published and only the save
+// count the rankers use is kept on the post.
+type SaveService struct {
+	saveRepo postgres.SaveRepository
+	postRepo postgres.PostRepository
+	logger   *zap.Logger
+}
+
+func NewSaveService(saveRepo postgres.SaveRepository, postRepo postgres.PostRepository, logger *zap.Logger) *SaveService {
+	return &SaveService{
+		saveRepo: saveRepo,
+		postRepo: postRepo,
+		logger:   logger,
+	}
+}
+
+// SavePost saves the post for userID, in one of their collections when
+// collectionID is not nil. Saving a saved post again moves it to that
+// collection, or out of any collection when collectionID is nil.
+func (s *SaveService) SavePost(userID, postID uint, collectionID *uint) error {
+	if collectionID != nil {
+		if _, err := s.getOwned(userID, *collectionID); err != nil {
+			return err
+		}
+	}
+	if _, err := s.postRepo.FindByID(postID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrPostNotFound
+		}
+		return err
+	}
+
+	created, err := s.saveRepo.Save(userID, postID, collectionID)
+	if err != nil {
+		// The post or collection was deleted since it was checked
+		switch postgres.ViolatedConstraint(err) {
+		case postgres.ConstraintSavePost:
+			return ErrPostNotFound
+		case postgres.ConstraintSaveCollection:
+			return ErrCollectionNotFound
+		}
+		return err
+	}
+	if created {
+		s.logger.Info("post saved", zap.Uint("user_id", userID), zap.Uint("post_id", postID))
+	}
+	return nil
+}
+
+// UnsavePost removes the post from userID's saves and from its collection.
+// Unsaving a post not saved is a no-op.
+func (s *SaveService) UnsavePost(userID, postID uint) error {
+	_, err := s.saveRepo.Unsave(userID, postID)
+	return err
+}
+
+// GetSavedPosts returns a page of every post userID saved, most recently
+// saved first, and the cursor for the next page
+func (s *SaveService) GetSavedPosts(userID uint, limit int, cursor string) ([]*domain.Post, string, error) {
+	return s.savedPage(userID, nil, limit, cursor)
+}
+
+// GetCollectionPosts returns a page of the posts in one of userID's
+// collections, most recently saved first, and the cursor for the next page
+func (s *SaveService) GetCollectionPosts(userID, collectionID uint, limit int, cursor string) ([]*domain.Post, string, error) {
+	if _, err := s.getOwned(userID, collectionID); err != nil {
+		return nil, "", err
+	}
+	return s.savedPage(userID, &collectionID, limit, cursor)
+}
+
+func (s *SaveService) savedPage(userID uint, collectionID *uint, limit int, cursor string) ([]*domain.Post, string, error) {
+	cursorObj, err := pagination.DecodeCursor(cursor)
+	if err != nil {
+		return nil, "", ErrInvalidCursor
+	}
+
+	saved, err := s.saveRepo.FindSaved(userID, collectionID, limit+1, cursorObj) // +1 to check if there are more
+	if err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(saved) > limit {
+		saved = saved[:limit]
+		last := saved[len(saved)-1]
+		nextCursor = (&pagination.Cursor{Timestamp: last.SavedAt, ID: last.Post.ID}).Encode()
+	}
+
+	posts := make([]*domain.Post, len(saved))
+	for i, save := range saved {
+		posts[i] = save.Post
+	}
+	return posts, nextCursor, nil
+}
+
+// CreateCollection creates a collection for userID. Names are trimmed and
+// must be unique among the user's collections.
+func (s *SaveService) CreateCollection(userID uint, name string) (*domain.Collection, error) {
+	name, err := normalizeCollectionName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	collection := &domain.Collection{UserID: userID, Name: name}
+	if err := s.saveRepo.CreateCollection(collection); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCollectionNameTaken
+		}
+		return nil, err
+	}
+	return collection, nil
+}
+
+// ListCollections returns userID's collections in the order they were created
+func (s *SaveService) ListCollections(userID uint) ([]*domain.Collection, error) {
+	return s.saveRepo.FindCollections(userID)
+}
+
+// RenameCollection renames one of userID's collections
+func (s *SaveService) RenameCollection(userID, collectionID uint, name string) (*domain.Collection, error) {
+	name, err := normalizeCollectionName(name)
+	if err != nil {
+		return nil, err
+	}
+	collection, err := s.getOwned(userID, collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	renamed, err := s.saveRepo.RenameCollection(collectionID, name)
+	if postgres.ViolatedConstraint(err) == postgres.ConstraintCollectionName {
+		return nil, ErrCollectionNameTaken // Taken by a concurrent request
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCollectionNotFound // Deleted since it was checked
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !renamed {
+		return nil, ErrCollectionNameTaken
+	}
+	collection.Name = name
+	return collection, nil
+}
+
+// DeleteCollection deletes one of userID's collections. Its posts stay saved.
+func (s *SaveService) DeleteCollection(userID, collectionID uint) error {
+	if _, err := s.getOwned(userID, collectionID); err != nil {
+		return err
+	}
+	return s.saveRepo.DeleteCollection(collectionID)
+}
+
+// getOwned loads a collection, hiding collections of other users as not found
+func (s *SaveService) getOwned(userID, collectionID uint) (*domain.Collection, error) {
+	collection, err := s.saveRepo.FindCollection(collectionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCollectionNotFound
+		}
+		return nil, err
+	}
+	if collection.UserID != userID {
+		return nil, ErrCollectionNotFound
+	}
+	return collection, nil
+}
+
+// normalizeCollectionName trims the name and checks its length
+func normalizeCollectionName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("%w: name is required", ErrInvalidCollectionName)
+	}
+	if utf8.RuneCountInString(name) > domain.MaxCollectionNameLength {
+		return "", fmt.Errorf("%w: name must be at most %d characters", ErrInvalidCollectionName, domain.MaxCollectionNameLength)
+	}
+	return name, nil
+}
@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/rodolfodpk/instagrano/internal/cache"
+	"github.com/rodolfodpk/instagrano/internal/domain"
+	"github.com/rodolfodpk/instagrano/internal/pagination"
+	"github.com/rodolfodpk/instagrano/internal/repository/postgres"
+	"go.uber.org/zap"
+)
+
+// timelineBackfillPosts is how many of an account's latest posts are added to
+// a timeline when the account is followed
+const timelineBackfillPosts = 100
+
+// TimelineService maintains per-user home timelines of followed accounts.
+//
+// Posts are fanned out on write into a Redis sorted set per follower, scored
+// by creation time in microseconds. Authors with more followers than the
+// celebrity threshold are skipped at write time; their posts are merged in
+// from Postgres when a follower reads the timeline.
+type TimelineService struct {
+	followRepo         postgres.FollowRepository
+	userRepo           postgres.UserRepository
+	postRepo           postgres.PostRepository
+	cache              cache.Cache
+	celebrityThreshold int
+	maxLength          int64
+	logger             *zap.Logger
+}
+
+func NewTimelineService(followRepo postgres.FollowRepository, userRepo postgres.UserRepository, postRepo postgres.PostRepository, cache cache.Cache, celebrityThreshold, maxLength int, logger *zap.Logger) *TimelineService {
+	return &TimelineService{
+		followRepo:         followRepo,
+		userRepo:           userRepo,
+		postRepo:           postRepo,
+		cache:              cache,
+		celebrityThreshold: celebrityThreshold,
+		maxLength:          int64(maxLength),
+		logger:             logger,
+	}
+}
+
+// FanOutPost adds a new post to its author's timeline and, unless the author
+// is above the celebrity threshold, to the timeline of every follower
+func (s *TimelineService) FanOutPost(ctx context.Context, post *domain.Post) error {
+	author, err := s.userRepo.FindByID(post.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to load post author: %w", err)
+	}
+
+	keys := []string{timelineKey(post.UserID)}
+	if author.FollowersCount <= s.celebrityThreshold {
+		followerIDs, err := s.followRepo.FindFollowerIDs(post.UserID)
+		if err != nil {
+			return err
+		}
+		for _, followerID := range followerIDs {
+			keys = append(keys, timelineKey(followerID))
+		}
+	}
+
+	if err := s.cache.ZAddToMany(ctx, keys, timelineScore(post), timelineMember(post.ID), s.maxLength); err != nil {
+		return fmt.Errorf("failed to fan out post: %w", err)
+	}
+
+	s.logger.Info("post fanned out",
+		zap.Uint("post_id", post.ID),
+		zap.Uint("user_id", post.UserID),
+		zap.Int("timelines", len(keys)))
+	return nil
+}
+
+// RemovePost removes a post from its author's and every follower's timeline.
+// Followers are always visited, since the author may have crossed the
+// celebrity threshold after the post was fanned out.
+func (s *TimelineService) RemovePost(ctx context.Context, post *domain.Post) error {
+	followerIDs, err := s.followRepo.FindFollowerIDs(post.UserID)
+	if err != nil {
+		return err
+	}
+
+	keys := []string{timelineKey(post.UserID)}
+	for _, followerID := range followerIDs {
+		keys = append(keys, timelineKey(followerID))
+	}
+
+	if err := s.cache.ZRemFromMany(ctx, keys, timelineMember(post.ID)); err != nil {
+		return fmt.Errorf("failed to remove post from timelines: %w", err)
+	}
+	return nil
+}
+
+// AddFollowee backfills followerID's timeline with the most recent posts of a
+// newly followed account. Posts of accounts above the celebrity threshold are
+// merged in when reading, so they are not copied.
+func (s *TimelineService) AddFollowee(ctx context.Context, followerID, followeeID uint) error {
+	followee, err := s.userRepo.FindByID(followeeID)
+	if err != nil {
+		return fmt.Errorf("failed to load followee: %w", err)
+	}
+	if followee.FollowersCount > s.celebrityThreshold {
+		return nil
+	}
+
+	posts, err := s.postRepo.GetByUserIDsWithCursor([]uint{followeeID}, timelineBackfillPosts, nil)
+	if err != nil {
+		return err
+	}
+	members := make([]cache.ZMember, len(posts))
+	for i, post := range posts {
+		members[i] = cache.ZMember{Member: timelineMember(post.ID), Score: timelineScore(post)}
+	}
+	if err := s.cache.ZAddMembers(ctx, timelineKey(followerID), members, s.maxLength); err != nil {
+		return fmt.Errorf("failed to backfill timeline: %w", err)
+	}
+	return nil
+}
+
+// RemoveFollowee removes every post of an unfollowed account from
+// followerID's timeline
+func (s *TimelineService) RemoveFollowee(ctx context.Context, followerID, followeeID uint) error {
+	postIDs, err := s.postRepo.FindIDsByUserID(followeeID)
+	if err != nil {
+		return err
+	}
+	members := make([]string, len(postIDs))
+	for i, id := range postIDs {
+		members[i] = timelineMember(id)
+	}
+	if err := s.cache.ZRemMembers(ctx, timelineKey(followerID), members); err != nil {
+		return fmt.Errorf("failed to remove followee from timeline: %w", err)
+	}
+	return nil
+}
+
+// GetFeed returns a page of userID's home timeline, newest first. It uses the
+// same cursor format as the global feed.
+func (s *TimelineService) GetFeed(userID uint, limit int, cursor string) (*pagination.FeedResult, error) {
+	ctx := context.Background()
+
+	cursorObj, err := pagination.DecodeCursor(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	postIDs, err := s.readTimeline(ctx, userID, limit+1, cursorObj) // +1 to check if there are more
+	if err != nil {
+		return nil, err
+	}
+
+	posts, err := s.postRepo.FindByIDs(postIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fan-out-on-read for followed accounts that were skipped at write time
+	celebrityIDs, err := s.followRepo.FindFollowingIDsWithMinFollowers(userID, s.celebrityThreshold)
+	if err != nil {
+		return nil, err
+	}
+	if len(celebrityIDs) > 0 {
+		celebrityPosts, err := s.postRepo.GetByUserIDsWithCursor(celebrityIDs, limit+1, cursorObj)
+		if err != nil {
+			return nil, err
+		}
+		posts = mergePosts(posts, celebrityPosts)
+	}
+
+	sort.Slice(posts, func(i, j int) bool {
+		if posts[i].CreatedAt.Equal(posts[j].CreatedAt) {
+			return posts[i].ID > posts[j].ID
+		}
+		return posts[i].CreatedAt.After(posts[j].CreatedAt)
+	})
+
+	hasMore := len(posts) > limit
+	if hasMore {
+		posts = posts[:limit]
+	}
+
+	var nextCursor string
+	if hasMore && len(posts) > 0 {
+		lastPost := posts[len(posts)-1]
+		nextCursor = (&pagination.Cursor{Timestamp: lastPost.CreatedAt, ID: lastPost.ID}).Encode()
+	}
+
+	return &pagination.FeedResult{
+		Posts:      convertPostsToInterface(posts),
+		NextCursor: nextCursor,
+		HasMore:    hasMore,
+	}, nil
+}
+
+// readTimeline returns up to count post IDs from the timeline strictly after cursor
+func (s *TimelineService) readTimeline(ctx context.Context, userID uint, count int, cursor *pagination.Cursor) ([]uint, error) {
+	key := timelineKey(userID)
+	max := math.Inf(1)
+	if cursor != nil {
+		max = float64(cursor.Timestamp.UnixMicro())
+	}
+
+	var ids []uint
+	var offset int64
+	for len(ids) < count {
+		members, err := s.cache.ZRevRangeByScore(ctx, key, max, offset, int64(count))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, m := range members {
+			id, err := strconv.ParseUint(m.Member, 10, 32)
+			if err != nil {
+				s.logger.Warn("invalid timeline member", zap.String("key", key), zap.String("member", m.Member))
+				continue
+			}
+			// Members at the cursor's timestamp are only kept when they sort after it
+			if cursor != nil && m.Score == max && uint(id) >= cursor.ID {
+				continue
+			}
+			ids = append(ids, uint(id))
+		}
+
+		if len(members) < count {
+			break
+		}
+		offset += int64(len(members))
+	}
+
+	if len(ids) > count {
+		ids = ids[:count]
+	}
+	return ids, nil
+}
+
+// mergePosts appends extra posts that are not already present
+func mergePosts(posts, extra []*domain.Post) []*domain.Post {
+	seen := make(map[uint]bool, len(posts))
+	for _, post := range posts {
+		seen[post.ID] = true
+	}
+	for _, post := range extra {
+		if !seen[post.ID] {
+			seen[post.ID] = true
+			posts = append(posts, post)
+		}
+	}
+	return posts
+}
+
+func timelineKey(userID uint) string {
+	return fmt.Sprintf("timeline:%d", userID)
+}
+
+// timelineMember zero-pads the post ID so members sharing a score sort
+// lexicographically in the same order as their numeric IDs
+func timelineMember(postID uint) string {
+	return fmt.Sprintf("%010d", postID)
+}
+
+func timelineScore(post *domain.Post) float64 {
+	return float64(post.CreatedAt.UnixMicro())
+}
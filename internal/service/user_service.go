@@ -0,0 +1,41 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/rodolfodpk/instagrano/internal/domain"
+	"github.com/rodolfodpk/instagrano/internal/repository/postgres"
+	"go.uber.org/zap"
+)
+
+// UserService manages a user's own account settings
+type UserService struct {
+	userRepo postgres.UserRepository
+	logger   *zap.Logger
+}
+
+func NewUserService(userRepo postgres.UserRepository, logger *zap.Logger) *UserService {
+	return &UserService{
+		userRepo: userRepo,
+		logger:   logger,
+	}
+}
+
+// UpdateSettings changes userID's settings and returns the updated user.
+// hideLikers hides the likers list of the user's posts from everyone else.
+func (s *UserService) UpdateSettings(userID uint, hideLikers bool) (*domain.User, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	user.HideLikers = hideLikers
+	if err := s.userRepo.UpdateSettings(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
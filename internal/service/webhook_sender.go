@@ -0,0 +1,146 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/rodolfodpk/instagrano/internal/domain"
+)
+
+// Headers sent with every webhook request
+const (
+	WebhookEventHeader     = "X-Instagrano-Event"
+	WebhookDeliveryHeader  = "X-Instagrano-Delivery"
+	WebhookTimestampHeader = "X-Instagrano-Timestamp"
+	WebhookSignatureHeader = "X-Instagrano-Signature"
+)
+
+// webhookResponseLimit is how much of a response body is read before the
+// connection is released
+const webhookResponseLimit = 64 << 10
+
+// errWebhookAddressBlocked is returned for webhook hosts that are not
+// publicly routable
+var errWebhookAddressBlocked = errors.New("webhook address is not publicly routable")
+
+// WebhookSender POSTs signed payloads to webhook URLs
+type WebhookSender struct {
+	client       *http.Client
+	allowPrivate bool
+}
+
+// NewWebhookSender creates a sender that gives up on requests after timeout.
+// Redirects are not followed; they count as failed deliveries. Unless
+// allowPrivate is set, it refuses to connect to loopback, private,
+// link-local and unspecified addresses, checked on every dial so a host
+// re-resolving to such an address after registration is still blocked.
+func NewWebhookSender(timeout time.Duration, allowPrivate bool) *WebhookSender {
+	dialer := &net.Dialer{Timeout: timeout}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !allowPrivate {
+		dialer.Control = blockPrivateAddresses
+		// A proxy would be dialed instead of the webhook host
+		transport.Proxy = nil
+	}
+	transport.DialContext = dialer.DialContext
+
+	return &WebhookSender{
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		allowPrivate: allowPrivate,
+	}
+}
+
+// CheckHost resolves a webhook host and rejects it when any of its addresses
+// is one the sender refuses to connect to
+func (s *WebhookSender) CheckHost(ctx context.Context, host string) error {
+	if s.allowPrivate {
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if isPrivateAddress(addr.IP) {
+			return fmt.Errorf("%w: %s resolves to %s", errWebhookAddressBlocked, host, addr.IP)
+		}
+	}
+	return nil
+}
+
+// blockPrivateAddresses is a dialer Control hook; it sees the resolved
+// address about to be connected to
+func blockPrivateAddresses(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || isPrivateAddress(ip) {
+		return fmt.Errorf("%w: %s", errWebhookAddressBlocked, host)
+	}
+	return nil
+}
+
+func isPrivateAddress(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsUnspecified()
+}
+
+// Send posts payload to the webhook and returns the response status, or zero
+// when no response was received. Any status other than 2xx is an error.
+func (s *WebhookSender) Send(ctx context.Context, webhook *domain.Webhook, eventType, deliveryID string, payload []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Instagrano-Webhooks/1.0")
+	req.Header.Set(WebhookEventHeader, eventType)
+	req.Header.Set(WebhookDeliveryHeader, deliveryID)
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(webhook.Secret, timestamp, payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, webhookResponseLimit))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// SignWebhookPayload returns the signature header value for a payload:
+// "sha256=" followed by the hex HMAC-SHA256 of "<timestamp>.<payload>" keyed
+// with the webhook secret. Receivers recompute it to verify a request and
+// reject stale timestamps to prevent replays.
+func SignWebhookPayload(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
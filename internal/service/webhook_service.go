@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/rodolfodpk/instagrano/internal/domain"
+	"github.com/rodolfodpk/instagrano/internal/events"
+	"github.com/rodolfodpk/instagrano/internal/repository/postgres"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrWebhookNotFound = errors.New("webhook not found")
+	ErrInvalidWebhook  = errors.New("invalid webhook")
+)
+
+// webhookResolveTimeout bounds the DNS lookup of a webhook host on register
+const webhookResolveTimeout = 5 * time.Second
+
+// EventTypeWebhookPing is the type of the event sent when a webhook is test-fired
+const EventTypeWebhookPing events.EventType = "ping"
+
+// WebhookEventTypes are the events webhooks can subscribe to
+var WebhookEventTypes = []events.EventType{
+	events.EventTypeNewPost,
+	events.EventTypePostLiked,
+	events.EventTypePostCommented,
+	events.EventTypePostDeleted,
+}
+
+// WebhookTestResult is the outcome of test-firing a webhook
+type WebhookTestResult struct {
+	StatusCode int
+	Duration   time.Duration
+	Err        error
+}
+
+type WebhookService struct {
+	webhookRepo postgres.WebhookRepository
+	sender      *WebhookSender
+	logger      *zap.Logger
+}
+
+func NewWebhookService(webhookRepo postgres.WebhookRepository, sender *WebhookSender, logger *zap.Logger) *WebhookService {
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+		sender:      sender,
+		logger:      logger,
+	}
+}
+
+// Register creates a webhook for userID with a generated signing secret. The
+// scope defaults to user and an empty eventTypes subscribes to every type.
+// URLs whose host resolves to a non-public address are rejected.
+func (s *WebhookService) Register(ctx context.Context, userID uint, rawURL, scope string, eventTypes []string) (*domain.Webhook, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return nil, fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidWebhook)
+	}
+
+	resolveCtx, cancel := context.WithTimeout(ctx, webhookResolveTimeout)
+	defer cancel()
+	if err := s.sender.CheckHost(resolveCtx, parsed.Hostname()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWebhook, err)
+	}
+
+	if scope == "" {
+		scope = domain.WebhookScopeUser
+	}
+	if scope != domain.WebhookScopeUser && scope != domain.WebhookScopeApp {
+		return nil, fmt.Errorf("%w: scope must be %q or %q", ErrInvalidWebhook, domain.WebhookScopeUser, domain.WebhookScopeApp)
+	}
+
+	types, err := normalizeWebhookEventTypes(eventTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook := &domain.Webhook{
+		UserID:     userID,
+		URL:        rawURL,
+		Secret:     "whsec_" + secret,
+		Scope:      scope,
+		EventTypes: types,
+	}
+	if err := s.webhookRepo.Create(webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// List returns the webhooks registered by userID
+func (s *WebhookService) List(userID uint) ([]*domain.Webhook, error) {
+	return s.webhookRepo.FindByUserID(userID)
+}
+
+// Delete removes one of userID's webhooks and its pending deliveries
+func (s *WebhookService) Delete(userID, webhookID uint) error {
+	if _, err := s.getOwned(userID, webhookID); err != nil {
+		return err
+	}
+	return s.webhookRepo.Delete(webhookID)
+}
+
+// Test sends a signed ping event to one of userID's webhooks right away and
+// reports how the endpoint responded. Pings are not retried or recorded.
+func (s *WebhookService) Test(ctx context.Context, userID, webhookID uint) (*WebhookTestResult, error) {
+	webhook, err := s.getOwned(userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	ping := events.Event{
+		ID:                fmt.Sprintf("ping-%d", now.UnixNano()),
+		Type:              EventTypeWebhookPing,
+		TriggeredByUserID: userID,
+		Data:              map[string]uint{"webhook_id": webhook.ID},
+		Timestamp:         now.Unix(),
+	}
+	payload, err := json.Marshal(ping)
+	if err != nil {
+		return nil, err
+	}
+
+	statusCode, err := s.sender.Send(ctx, webhook, string(ping.Type), ping.ID, payload)
+	return &WebhookTestResult{
+		StatusCode: statusCode,
+		Duration:   time.Since(now),
+		Err:        err,
+	}, nil
+}
+
+// ListDeliveries returns the most recent deliveries of one of userID's webhooks
+func (s *WebhookService) ListDeliveries(userID, webhookID uint, limit int) ([]*domain.WebhookDelivery, error) {
+	if _, err := s.getOwned(userID, webhookID); err != nil {
+		return nil, err
+	}
+	return s.webhookRepo.FindDeliveries(webhookID, limit)
+}
+
+// getOwned loads a webhook, hiding webhooks of other users as not found
+func (s *WebhookService) getOwned(userID, webhookID uint) (*domain.Webhook, error) {
+	webhook, err := s.webhookRepo.FindByID(webhookID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	if webhook.UserID != userID {
+		return nil, ErrWebhookNotFound
+	}
+	return webhook, nil
+}
+
+// normalizeWebhookEventTypes validates and de-duplicates event types,
+// defaulting to every webhook event type
+func normalizeWebhookEventTypes(eventTypes []string) ([]string, error) {
+	if len(eventTypes) == 0 {
+		eventTypes = make([]string, len(WebhookEventTypes))
+		for i, t := range WebhookEventTypes {
+			eventTypes[i] = string(t)
+		}
+		return eventTypes, nil
+	}
+
+	seen := make(map[string]bool)
+	var types []string
+	for _, t := range eventTypes {
+		if !isWebhookEventType(events.EventType(t)) {
+			return nil, fmt.Errorf("%w: unsupported event type %q", ErrInvalidWebhook, t)
+		}
+		if !seen[t] {
+			seen[t] = true
+			types = append(types, t)
+		}
+	}
+	return types, nil
+}
+
+func isWebhookEventType(t events.EventType) bool {
+	for _, supported := range WebhookEventTypes {
+		if t == supported {
+			return true
+		}
+	}
+	return false
+}
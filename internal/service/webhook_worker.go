@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rodolfodpk/instagrano/internal/cache"
+	"github.com/rodolfodpk/instagrano/internal/domain"
+	"github.com/rodolfodpk/instagrano/internal/events"
+	"github.com/rodolfodpk/instagrano/internal/repository/postgres"
+	"go.uber.org/zap"
+)
+
+const (
+	// webhookCursorKey stores the ID of the last event queued for webhooks
+	webhookCursorKey = "instagrano:webhooks:cursor"
+	// webhookBatchSize is how many due deliveries are claimed at a time
+	webhookBatchSize = 50
+	// webhookRetryBase is the delay before the first retry, doubled each time
+	webhookRetryBase = 10 * time.Second
+	// webhookMaxBackoff caps the delay between delivery attempts
+	webhookMaxBackoff = time.Hour
+)
+
+// WebhookWorker queues events from the event bus for the webhooks subscribed
+// to them and delivers the queue, retrying failed deliveries with
+// exponential backoff until maxAttempts is reached.
+//
+// Every API process runs a worker. Queued deliveries are unique per webhook
+// and event, and are claimed before sending, so each is sent by one worker.
+type WebhookWorker struct {
+	webhookRepo  postgres.WebhookRepository
+	bus          events.EventBus
+	cache        cache.Cache
+	sender       *WebhookSender
+	maxAttempts  int
+	pollInterval time.Duration
+	lease        time.Duration
+	logger       *zap.Logger
+}
+
+func NewWebhookWorker(webhookRepo postgres.WebhookRepository, bus events.EventBus, cache cache.Cache, sender *WebhookSender, maxAttempts int, pollInterval time.Duration, logger *zap.Logger) *WebhookWorker {
+	return &WebhookWorker{
+		webhookRepo:  webhookRepo,
+		bus:          bus,
+		cache:        cache,
+		sender:       sender,
+		maxAttempts:  maxAttempts,
+		pollInterval: pollInterval,
+		// A claimed delivery must not be claimed again before it is sent. The
+		// batch is sent one delivery at a time, so the last one may wait for
+		// every other to time out first.
+		lease:  webhookBatchSize*sender.client.Timeout + 30*time.Second,
+		logger: logger,
+	}
+}
+
+// Run queues and delivers events until ctx is done
+func (w *WebhookWorker) Run(ctx context.Context) {
+	go w.consume(ctx)
+
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain full batches without waiting for the next tick
+		for {
+			delivered, err := w.DeliverDue(ctx)
+			if err != nil {
+				w.logger.Error("failed to deliver webhooks", zap.Error(err))
+				break
+			}
+			if delivered < webhookBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// consume queues every event read from the bus, resuming after the last event
+// queued. An event that cannot be queued is read again after a pause.
+func (w *WebhookWorker) consume(ctx context.Context) {
+	for ctx.Err() == nil {
+		cursor := w.loadCursor(ctx)
+		if err := w.consumeFrom(ctx, cursor); err != nil {
+			w.logger.Error("failed to queue webhook events", zap.Error(err))
+			select {
+			case <-time.After(time.Second):
+			case <-ctx.Done():
+			}
+		}
+	}
+}
+
+func (w *WebhookWorker) consumeFrom(ctx context.Context, cursor string) error {
+	subCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	eventCh, err := w.bus.Subscribe(subCtx, cursor)
+	if err != nil {
+		return err
+	}
+	for event := range eventCh {
+		if _, err := w.Enqueue(event); err != nil {
+			return err
+		}
+		if err := w.cache.Set(ctx, webhookCursorKey, []byte(event.ID), 0); err != nil {
+			w.logger.Warn("failed to save webhook cursor", zap.Error(err))
+		}
+	}
+	return nil
+}
+
+func (w *WebhookWorker) loadCursor(ctx context.Context) string {
+	cursor, err := w.cache.Get(ctx, webhookCursorKey)
+	if err != nil || events.ValidateEventID(string(cursor)) != nil {
+		// Nothing saved yet: start with new events
+		return ""
+	}
+	return string(cursor)
+}
+
+// Enqueue queues event for the webhooks subscribed to it and returns how many
+// deliveries were queued
+func (w *WebhookWorker) Enqueue(event events.Event) (int, error) {
+	if !isWebhookEventType(event.Type) {
+		return 0, nil
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+	return w.webhookRepo.EnqueueDeliveries(event.ID, string(event.Type), event.PostID, event.TriggeredByUserID, payload)
+}
+
+// DeliverDue sends one batch of due deliveries and returns how many were
+// claimed
+func (w *WebhookWorker) DeliverDue(ctx context.Context) (int, error) {
+	deliveries, err := w.webhookRepo.ClaimDueDeliveries(webhookBatchSize, w.lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, delivery := range deliveries {
+		if err := w.deliver(ctx, delivery); err != nil {
+			return len(deliveries), err
+		}
+	}
+	return len(deliveries), nil
+}
+
+func (w *WebhookWorker) deliver(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	deliveryID := strconv.FormatInt(delivery.ID, 10)
+	statusCode, sendErr := w.sender.Send(ctx, delivery.Webhook, delivery.EventType, deliveryID, delivery.Payload)
+	if sendErr == nil {
+		return w.webhookRepo.MarkDelivered(delivery.ID, statusCode)
+	}
+
+	attempts := delivery.Attempts + 1
+	var retryAfter time.Duration
+	if attempts < w.maxAttempts {
+		retryAfter = retryBackoff(delivery.Attempts, webhookRetryBase, webhookMaxBackoff)
+	}
+	w.logger.Warn("webhook delivery failed",
+		zap.Error(sendErr),
+		zap.Int64("delivery_id", delivery.ID),
+		zap.Uint("webhook_id", delivery.WebhookID),
+		zap.Int("status_code", statusCode),
+		zap.Int("attempts", attempts),
+		zap.Duration("retry_after", retryAfter))
+	return w.webhookRepo.MarkFailed(delivery.ID, statusCode, sendErr, retryAfter)
+}
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS follows (
+    follower_id INT REFERENCES users(id) ON DELETE CASCADE,
+    followee_id INT REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+
+-- Followers/following lists are paginated by (created_at DESC, user id DESC)
+CREATE INDEX IF NOT EXISTS idx_follows_followee_created_at ON follows(followee_id, created_at DESC, follower_id DESC);
+CREATE INDEX IF NOT EXISTS idx_follows_follower_created_at ON follows(follower_id, created_at DESC, followee_id DESC);
+
+-- Denormalized counters, maintained in the same transaction as the follow row
+ALTER TABLE users ADD COLUMN IF NOT EXISTS followers_count INT DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS following_count INT DEFAULT 0;
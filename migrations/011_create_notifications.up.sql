@@ -0,0 +1,26 @@
+-- In-app notifications. While a notification is unread, later likes (or
+-- comments) on the same post are folded into it: last_actor_id is the most
+-- recent actor and actor_count the number of distinct actors.
+CREATE TABLE IF NOT EXISTS notifications (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL,
+    post_id INT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    last_actor_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    actor_count INT NOT NULL DEFAULT 0,
+    read_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- At most one unread notification per recipient, post and type
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_unread_group ON notifications(user_id, post_id, type) WHERE read_at IS NULL;
+-- The inbox is paginated by (updated_at DESC, id DESC)
+CREATE INDEX IF NOT EXISTS idx_notifications_user_updated_at ON notifications(user_id, updated_at DESC, id DESC);
+
+-- Distinct actors folded into a notification
+CREATE TABLE IF NOT EXISTS notification_actors (
+    notification_id INT REFERENCES notifications(id) ON DELETE CASCADE,
+    actor_id INT REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (notification_id, actor_id)
+);
@@ -0,0 +1,7 @@
+-- Threaded replies. A reply's parent is always a top-level comment on the
+-- same post; replies to replies are attached to the thread's top-level comment.
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS parent_id INT REFERENCES comments(id) ON DELETE CASCADE;
+
+-- Top-level comments are listed per post, replies per thread, both oldest first
+CREATE INDEX IF NOT EXISTS idx_comments_post_top_level ON comments(post_id, created_at, id) WHERE parent_id IS NULL;
+CREATE INDEX IF NOT EXISTS idx_comments_parent_id_created_at ON comments(parent_id, created_at, id);
@@ -0,0 +1,2 @@
+-- When the author last edited the comment's text; NULL if never edited
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS edited_at TIMESTAMP;
@@ -0,0 +1,3 @@
+-- Top-level comments sorted by likes ("top"); oldest and newest use
+-- idx_comments_post_top_level in either direction
+CREATE INDEX IF NOT EXISTS idx_comments_post_top_likes ON comments(post_id, likes_count DESC, id DESC) WHERE parent_id IS NULL;
@@ -0,0 +1,8 @@
+-- A like is a reaction of type 'like'; a user still has at most one reaction per post
+ALTER TABLE likes ADD COLUMN IF NOT EXISTS reaction VARCHAR(20) NOT NULL DEFAULT 'like';
+
+-- Per-type counts kept in step with likes in the same transaction. likes_count
+-- stays the total across every type.
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS reaction_counts JSONB NOT NULL DEFAULT '{}';
+UPDATE posts SET reaction_counts = jsonb_build_object('like', likes_count)
+WHERE likes_count > 0 AND reaction_counts = '{}';
@@ -0,0 +1,5 @@
+-- Authors can hide who liked their posts from everyone but themselves
+ALTER TABLE users ADD COLUMN IF NOT EXISTS hide_like_counts BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- A post's likers are paginated by (created_at DESC, user_id DESC)
+CREATE INDEX IF NOT EXISTS idx_likes_post_created_at ON likes(post_id, created_at DESC, user_id DESC);
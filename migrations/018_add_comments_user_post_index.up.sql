@@ -0,0 +1,2 @@
+-- Lets a page of posts be checked for the viewer's comments in one query
+CREATE INDEX IF NOT EXISTS idx_comments_user_post ON comments(user_id, post_id);
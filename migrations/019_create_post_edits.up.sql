@@ -0,0 +1,11 @@
+-- Edit history: each row keeps a post's title and caption as they were
+-- before the edit made at edited_at
+CREATE TABLE IF NOT EXISTS post_edits (
+    id SERIAL PRIMARY KEY,
+    post_id INT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    title VARCHAR(200) NOT NULL,
+    caption TEXT NOT NULL DEFAULT '',
+    edited_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_edits_post_id ON post_edits(post_id, edited_at DESC);
@@ -0,0 +1,15 @@
+-- Carousel posts: up to 10 ordered media items per post. posts.media_type and
+-- posts.media_url keep the first item as the post's cover.
+CREATE TABLE IF NOT EXISTS post_media (
+    id SERIAL PRIMARY KEY,
+    post_id INT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    position SMALLINT NOT NULL,
+    media_type VARCHAR(10) NOT NULL,
+    media_url VARCHAR(500) NOT NULL,
+    UNIQUE (post_id, position)
+);
+
+-- Existing posts become single-item carousels
+INSERT INTO post_media (post_id, position, media_type, media_url)
+SELECT id, 0, media_type, media_url FROM posts
+ON CONFLICT (post_id, position) DO NOTHING;
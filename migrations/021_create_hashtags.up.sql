@@ -0,0 +1,29 @@
+-- Hashtags parsed from post captions, stored lowercased without the #
+CREATE TABLE IF NOT EXISTS hashtags (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) UNIQUE NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS post_hashtags (
+    post_id INT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    hashtag_id INT NOT NULL REFERENCES hashtags(id) ON DELETE CASCADE,
+    PRIMARY KEY (post_id, hashtag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_hashtags_hashtag_id ON post_hashtags(hashtag_id, post_id);
+
+-- Index the captions of existing posts with the same rules as the API: a #
+-- not following a letter, digit or underscore, and at least one letter
+INSERT INTO hashtags (name)
+SELECT DISTINCT lower(m[2])
+FROM posts p, regexp_matches(COALESCE(p.caption, ''), '(^|[^[:alnum:]_])#([[:alnum:]_]+)', 'g') AS m
+WHERE m[2] ~ '[[:alpha:]]' AND char_length(m[2]) <= 100
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO post_hashtags (post_id, hashtag_id)
+SELECT DISTINCT p.id, h.id
+FROM posts p
+CROSS JOIN LATERAL regexp_matches(COALESCE(p.caption, ''), '(^|[^[:alnum:]_])#([[:alnum:]_]+)', 'g') AS m
+JOIN hashtags h ON h.name = lower(m[2])
+ON CONFLICT (post_id, hashtag_id) DO NOTHING;
@@ -0,0 +1,16 @@
+-- @username mentions in post captions (comment_id is NULL) and comments.
+-- text_offset and text_length are in characters and include the @.
+CREATE TABLE IF NOT EXISTS mentions (
+    id SERIAL PRIMARY KEY,
+    post_id INT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    comment_id INT REFERENCES comments(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    mentioned_by INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    text_offset INT NOT NULL,
+    text_length INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_mentions_post_id ON mentions(post_id) WHERE comment_id IS NULL;
+CREATE INDEX IF NOT EXISTS idx_mentions_comment_id ON mentions(comment_id);
+CREATE INDEX IF NOT EXISTS idx_mentions_user_id ON mentions(user_id, created_at DESC);
@@ -0,0 +1,25 @@
+-- Named collections a user files saved posts into
+CREATE TABLE IF NOT EXISTS collections (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, name)
+);
+
+-- A user saves a post once, in at most one of their collections. Deleting a
+-- collection keeps its posts saved.
+CREATE TABLE IF NOT EXISTS saves (
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id INT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    collection_id INT REFERENCES collections(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, post_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_saves_user_created ON saves(user_id, created_at DESC, post_id DESC);
+CREATE INDEX IF NOT EXISTS idx_saves_collection_created ON saves(collection_id, created_at DESC, post_id DESC) WHERE collection_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_saves_post_id ON saves(post_id);
+
+-- Kept in step with saves in the same transaction, like posts.likes_count
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS saves_count INT NOT NULL DEFAULT 0;
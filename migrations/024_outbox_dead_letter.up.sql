@@ -0,0 +1,8 @@
+-- Messages that failed too often are dead-lettered instead of retried forever
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS dead_at TIMESTAMP;
+
+DROP INDEX IF EXISTS idx_outbox_pending;
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox(available_at, id) WHERE delivered_at IS NULL AND dead_at IS NULL;
+
+-- The relay prunes delivered rows once they are past retention
+CREATE INDEX IF NOT EXISTS idx_outbox_delivered ON outbox(delivered_at) WHERE delivered_at IS NOT NULL;
@@ -0,0 +1,7 @@
+-- The setting only ever hid the likers list; name it for what it does
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'users' AND column_name = 'hide_like_counts') THEN
+        ALTER TABLE users RENAME COLUMN hide_like_counts TO hide_likers;
+    END IF;
+END $$;
@@ -134,8 +134,10 @@ class SSETestClient {
         return new Promise((resolve, reject) => {
             console.log(`Connecting SSE for user: ${this.user.username}`);
             
-            const url = `${BASE_URL}/api/events/stream?token=${encodeURIComponent(this.token)}`;
-            this.eventSource = new (require('eventsource'))(url);
+            const url = `${BASE_URL}/api/events/stream`;
+            this.eventSource = new (require('eventsource'))(url, {
+                headers: { 'Authorization': `Bearer ${this.token}` }
+            });
             
             this.eventSource.onopen = () => {
                 console.log(`✓ SSE connected for ${this.user.username}`);
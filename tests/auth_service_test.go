@@ -1,6 +1,9 @@
 package tests
 
 import (
+	"context"
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -8,12 +11,18 @@ import (
 	"github.com/rodolfodpk/instagrano/internal/service"
 )
 
+// Helper function to create auth service with all required dependencies
+func createAuthService() *service.AuthService {
+	userRepo := postgresRepo.NewUserRepository(sharedContainers.DB)
+	refreshTokenRepo := postgresRepo.NewRefreshTokenRepository(sharedContainers.DB)
+	return service.NewAuthService(userRepo, refreshTokenRepo, sharedContainers.Cache, "test-secret", 15*time.Minute, 24*time.Hour)
+}
+
 var _ = Describe("AuthService", func() {
 	Describe("Register", func() {
 		It("should register a new user successfully", func() {
 			// Given: Auth service with test database
-			userRepo := postgresRepo.NewUserRepository(sharedContainers.DB)
-			authService := service.NewAuthService(userRepo, "test-secret")
+			authService := createAuthService()
 
 			// When: Register a new user
 			user, err := authService.Register("testuser", "test@example.com", "password123")
@@ -27,8 +36,7 @@ var _ = Describe("AuthService", func() {
 
 		It("should reject duplicate username", func() {
 			// Given: Auth service with existing user
-			userRepo := postgresRepo.NewUserRepository(sharedContainers.DB)
-			authService := service.NewAuthService(userRepo, "test-secret")
+			authService := createAuthService()
 
 			// First registration
 			_, err := authService.Register("testuser", "test@example.com", "password123")
@@ -44,8 +52,7 @@ var _ = Describe("AuthService", func() {
 
 		It("should reject duplicate email", func() {
 			// Given: Auth service with existing user
-			userRepo := postgresRepo.NewUserRepository(sharedContainers.DB)
-			authService := service.NewAuthService(userRepo, "test-secret")
+			authService := createAuthService()
 
 			// First registration
 			_, err := authService.Register("testuser", "test@example.com", "password123")
@@ -61,8 +68,7 @@ var _ = Describe("AuthService", func() {
 
 		It("should reject invalid email format", func() {
 			// Given: Auth service
-			userRepo := postgresRepo.NewUserRepository(sharedContainers.DB)
-			authService := service.NewAuthService(userRepo, "test-secret")
+			authService := createAuthService()
 
 			// When: Register with invalid email (empty email)
 			_, err := authService.Register("testuser", "", "password123")
@@ -74,8 +80,7 @@ var _ = Describe("AuthService", func() {
 
 		It("should reject short password", func() {
 			// Given: Auth service
-			userRepo := postgresRepo.NewUserRepository(sharedContainers.DB)
-			authService := service.NewAuthService(userRepo, "test-secret")
+			authService := createAuthService()
 
 			// When: Register with empty password
 			_, err := authService.Register("testuser", "test@example.com", "")
@@ -87,8 +92,7 @@ var _ = Describe("AuthService", func() {
 
 		It("should reject empty username", func() {
 			// Given: Auth service
-			userRepo := postgresRepo.NewUserRepository(sharedContainers.DB)
-			authService := service.NewAuthService(userRepo, "test-secret")
+			authService := createAuthService()
 
 			// When: Register with empty username
 			_, err := authService.Register("", "test@example.com", "password123")
@@ -100,8 +104,7 @@ var _ = Describe("AuthService", func() {
 
 		It("should hash password correctly", func() {
 			// Given: Auth service
-			userRepo := postgresRepo.NewUserRepository(sharedContainers.DB)
-			authService := service.NewAuthService(userRepo, "test-secret")
+			authService := createAuthService()
 
 			// When: Register a user
 			user, err := authService.Register("testuser", "test@example.com", "password123")
@@ -116,25 +119,24 @@ var _ = Describe("AuthService", func() {
 	Describe("Login", func() {
 		It("should login with correct credentials", func() {
 			// Given: Registered user
-			userRepo := postgresRepo.NewUserRepository(sharedContainers.DB)
-			authService := service.NewAuthService(userRepo, "test-secret")
+			authService := createAuthService()
 
 			_, err := authService.Register("testuser", "test@example.com", "password123")
 			Expect(err).NotTo(HaveOccurred())
 
 			// When: Login with correct credentials
-			user, token, err := authService.Login("test@example.com", "password123")
+			user, tokens, err := authService.Login("test@example.com", "password123")
 
 			// Then: Should login successfully
 			Expect(err).NotTo(HaveOccurred())
 			Expect(user.Username).To(Equal("testuser"))
-			Expect(token).NotTo(BeEmpty())
+			Expect(tokens.AccessToken).NotTo(BeEmpty())
+			Expect(tokens.RefreshToken).NotTo(BeEmpty())
 		})
 
 		It("should reject incorrect password", func() {
 			// Given: Registered user
-			userRepo := postgresRepo.NewUserRepository(sharedContainers.DB)
-			authService := service.NewAuthService(userRepo, "test-secret")
+			authService := createAuthService()
 
 			_, err := authService.Register("testuser", "test@example.com", "password123")
 			Expect(err).NotTo(HaveOccurred())
@@ -149,8 +151,7 @@ var _ = Describe("AuthService", func() {
 
 		It("should reject non-existent email", func() {
 			// Given: Auth service
-			userRepo := postgresRepo.NewUserRepository(sharedContainers.DB)
-			authService := service.NewAuthService(userRepo, "test-secret")
+			authService := createAuthService()
 
 			// When: Login with non-existent email
 			_, _, err := authService.Login("nonexistent@example.com", "password123")
@@ -162,19 +163,100 @@ var _ = Describe("AuthService", func() {
 
 		It("should generate valid JWT token", func() {
 			// Given: Registered user
-			userRepo := postgresRepo.NewUserRepository(sharedContainers.DB)
-			authService := service.NewAuthService(userRepo, "test-secret")
+			authService := createAuthService()
 
 			_, err := authService.Register("testuser", "test@example.com", "password123")
 			Expect(err).NotTo(HaveOccurred())
 
 			// When: Login
-			_, token, err := authService.Login("test@example.com", "password123")
+			_, tokens, err := authService.Login("test@example.com", "password123")
 
 			// Then: Should generate valid token
 			Expect(err).NotTo(HaveOccurred())
-			Expect(token).NotTo(BeEmpty())
-			Expect(len(token)).To(BeNumerically(">", 50)) // JWT tokens are longer
+			Expect(tokens.AccessToken).NotTo(BeEmpty())
+			Expect(len(tokens.AccessToken)).To(BeNumerically(">", 50)) // JWT tokens are longer
+		})
+	})
+
+	Describe("Refresh", func() {
+		It("should rotate the refresh token and issue a new access token", func() {
+			// Given: Logged in user
+			authService := createAuthService()
+			_, err := authService.Register("testuser", "test@example.com", "password123")
+			Expect(err).NotTo(HaveOccurred())
+			_, tokens, err := authService.Login("test@example.com", "password123")
+			Expect(err).NotTo(HaveOccurred())
+
+			// When: Refresh with the issued refresh token
+			user, refreshed, err := authService.Refresh(context.Background(), tokens.RefreshToken)
+
+			// Then: Should return a new pair for the same user
+			Expect(err).NotTo(HaveOccurred())
+			Expect(user.Username).To(Equal("testuser"))
+			Expect(refreshed.AccessToken).NotTo(Equal(tokens.AccessToken))
+			Expect(refreshed.RefreshToken).NotTo(Equal(tokens.RefreshToken))
+		})
+
+		It("should reject an unknown refresh token", func() {
+			// Given: Auth service
+			authService := createAuthService()
+
+			// When: Refresh with a token that was never issued
+			_, _, err := authService.Refresh(context.Background(), "not-a-real-token")
+
+			// Then: Should reject it
+			Expect(err).To(Equal(service.ErrInvalidRefreshToken))
+		})
+
+		It("should revoke the whole session when a rotated token is reused", func() {
+			// Given: A refresh token that has already been rotated once
+			authService := createAuthService()
+			_, err := authService.Register("testuser", "test@example.com", "password123")
+			Expect(err).NotTo(HaveOccurred())
+			_, tokens, err := authService.Login("test@example.com", "password123")
+			Expect(err).NotTo(HaveOccurred())
+			_, refreshed, err := authService.Refresh(context.Background(), tokens.RefreshToken)
+			Expect(err).NotTo(HaveOccurred())
+
+			// When: The old refresh token is presented again
+			_, _, err = authService.Refresh(context.Background(), tokens.RefreshToken)
+
+			// Then: Reuse is detected and the newest token is revoked as well
+			Expect(err).To(Equal(service.ErrRefreshTokenReused))
+			_, _, err = authService.Refresh(context.Background(), refreshed.RefreshToken)
+			Expect(err).To(Equal(service.ErrRefreshTokenReused))
+
+			// And: Access tokens of the session are revoked
+			claims, err := authService.ParseAccessToken(refreshed.AccessToken)
+			Expect(err).NotTo(HaveOccurred())
+			revoked, err := authService.IsTokenRevoked(context.Background(), claims.TokenID, claims.SessionID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(revoked).To(BeTrue())
+		})
+	})
+
+	Describe("Logout", func() {
+		It("should revoke the access token and its refresh token", func() {
+			// Given: Logged in user
+			authService := createAuthService()
+			_, err := authService.Register("testuser", "test@example.com", "password123")
+			Expect(err).NotTo(HaveOccurred())
+			_, tokens, err := authService.Login("test@example.com", "password123")
+			Expect(err).NotTo(HaveOccurred())
+			claims, err := authService.ParseAccessToken(tokens.AccessToken)
+			Expect(err).NotTo(HaveOccurred())
+
+			// When: Logout
+			err = authService.Logout(context.Background(), claims)
+
+			// Then: Access token is revoked and refresh no longer works
+			Expect(err).NotTo(HaveOccurred())
+			revoked, err := authService.IsTokenRevoked(context.Background(), claims.TokenID, claims.SessionID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(revoked).To(BeTrue())
+
+			_, _, err = authService.Refresh(context.Background(), tokens.RefreshToken)
+			Expect(err).To(HaveOccurred())
 		})
 	})
 })
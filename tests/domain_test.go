@@ -1,8 +1,6 @@
 package tests
 
 import (
-	"time"
-
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"golang.org/x/crypto/bcrypt"
@@ -10,84 +8,6 @@ import (
 	"github.com/rodolfodpk/instagrano/internal/domain"
 )
 
-var _ = Describe("Post", func() {
-	Describe("CalculateScore", func() {
-		It("should calculate high score for fresh post with high engagement", func() {
-			// Given: A fresh post with high engagement
-			post := &domain.Post{
-				LikesCount:    100,
-				CommentsCount: 50,
-				ViewsCount:    1000,
-				CreatedAt:     time.Now().Add(-1 * time.Hour), // 1 hour old
-			}
-
-			// When: Calculate score
-			score := post.CalculateScore()
-
-			// Then: Score should be high due to fresh content and engagement
-			Expect(score).To(BeNumerically(">", 0))
-			Expect(score).To(BeNumerically(">", 50)) // Should be high due to engagement
-		})
-
-		It("should calculate lower score for old post", func() {
-			// Given: An old post
-			post := &domain.Post{
-				LikesCount:    100,
-				CommentsCount: 50,
-				ViewsCount:    1000,
-				CreatedAt:     time.Now().Add(-24 * time.Hour), // 1 day old
-			}
-
-			// When: Calculate score
-			score := post.CalculateScore()
-
-			// Then: Score should be lower due to age
-			Expect(score).To(BeNumerically(">", 0))
-			Expect(score).To(BeNumerically("<", 50)) // Should be lower due to age
-		})
-
-		It("should calculate score based on engagement ratio", func() {
-			// Given: Posts with different engagement ratios
-			highEngagementPost := &domain.Post{
-				LikesCount:    1000,
-				CommentsCount: 200,
-				ViewsCount:    1000, // High engagement ratio
-				CreatedAt:     time.Now().Add(-1 * time.Hour),
-			}
-
-			lowEngagementPost := &domain.Post{
-				LikesCount:    10,
-				CommentsCount: 2,
-				ViewsCount:    1000, // Low engagement ratio
-				CreatedAt:     time.Now().Add(-1 * time.Hour),
-			}
-
-			// When: Calculate scores
-			highScore := highEngagementPost.CalculateScore()
-			lowScore := lowEngagementPost.CalculateScore()
-
-			// Then: High engagement post should have higher score
-			Expect(highScore).To(BeNumerically(">", lowScore))
-		})
-
-		It("should handle zero values gracefully", func() {
-			// Given: A post with zero engagement
-			post := &domain.Post{
-				LikesCount:    0,
-				CommentsCount: 0,
-				ViewsCount:    0,
-				CreatedAt:     time.Now().Add(-1 * time.Hour),
-			}
-
-			// When: Calculate score
-			score := post.CalculateScore()
-
-			// Then: Should not panic and return a valid score
-			Expect(score).To(BeNumerically(">=", 0))
-		})
-	})
-})
-
 var _ = Describe("User", func() {
 	Describe("ValidatePassword", func() {
 		It("should validate correct password", func() {
@@ -236,4 +156,48 @@ var _ = Describe("Comment", func() {
 			Expect(comment.PostID).To(Equal(uint(0)))
 		})
 	})
-})
\ No newline at end of file
+})
+
+var _ = Describe("Reaction", func() {
+	Describe("IsReactionType", func() {
+		It("should accept every supported reaction", func() {
+			for _, reaction := range domain.ReactionTypes {
+				Expect(domain.IsReactionType(reaction)).To(BeTrue())
+			}
+		})
+
+		It("should reject unknown and empty reactions", func() {
+			Expect(domain.IsReactionType("angry")).To(BeFalse())
+			Expect(domain.IsReactionType("")).To(BeFalse())
+		})
+	})
+
+	Describe("PostCounts.MoveReaction", func() {
+		It("should count a new reaction", func() {
+			counts := domain.PostCounts{}
+
+			counts.MoveReaction("", domain.ReactionHeart)
+
+			Expect(counts.LikesCount).To(Equal(1))
+			Expect(counts.ReactionCounts).To(Equal(domain.ReactionCounts{domain.ReactionHeart: 1}))
+		})
+
+		It("should move a changed reaction without changing the total", func() {
+			counts := domain.PostCounts{LikesCount: 3, ReactionCounts: domain.ReactionCounts{domain.ReactionLike: 2, domain.ReactionHeart: 1}}
+
+			counts.MoveReaction(domain.ReactionHeart, domain.ReactionFire)
+
+			Expect(counts.LikesCount).To(Equal(3))
+			Expect(counts.ReactionCounts).To(Equal(domain.ReactionCounts{domain.ReactionLike: 2, domain.ReactionFire: 1}))
+		})
+
+		It("should drop types nobody reacts with any more", func() {
+			counts := domain.PostCounts{LikesCount: 1, ReactionCounts: domain.ReactionCounts{domain.ReactionWow: 1}}
+
+			counts.MoveReaction(domain.ReactionWow, "")
+
+			Expect(counts.LikesCount).To(BeZero())
+			Expect(counts.ReactionCounts).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+
+	"github.com/rodolfodpk/instagrano/internal/events"
+)
+
+// describeEventBus runs the EventBus contract against one implementation
+func describeEventBus(name string, newBus func() events.EventBus) {
+	Describe(name, func() {
+		var (
+			ctx context.Context
+			bus events.EventBus
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			Expect(sharedContainers.Cache.FlushAll(ctx)).To(Succeed())
+			bus = newBus()
+		})
+
+		appendLike := func(postID uint) events.Event {
+			event, err := bus.Append(ctx, events.Event{Type: events.EventTypePostLiked, PostID: postID})
+			Expect(err).NotTo(HaveOccurred())
+			return event
+		}
+
+		receive := func(ch <-chan events.Event) events.Event {
+			var event events.Event
+			Eventually(ch, 5*time.Second).Should(Receive(&event))
+			return event
+		}
+
+		It("should assign increasing IDs", func() {
+			first := appendLike(1)
+			second := appendLike(2)
+
+			Expect(events.ValidateEventID(first.ID)).To(Succeed())
+			Expect(events.CompareEventIDs(second.ID, first.ID)).To(Equal(1))
+		})
+
+		It("should return events after a given ID oldest first", func() {
+			first := appendLike(1)
+			appendLike(2)
+			appendLike(3)
+
+			missed, err := bus.Since(ctx, first.ID, 10)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(missed).To(HaveLen(2))
+			Expect(missed[0].PostID).To(Equal(uint(2)))
+			Expect(missed[1].PostID).To(Equal(uint(3)))
+		})
+
+		It("should replay missed events before live ones", func() {
+			// Given: Events published while the client was away
+			first := appendLike(1)
+			appendLike(2)
+
+			// When: Subscribing from the last event seen
+			subCtx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			ch, err := bus.Subscribe(subCtx, first.ID)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Then: The missed event arrives, followed by live ones
+			Expect(receive(ch).PostID).To(Equal(uint(2)))
+			appendLike(3)
+			Expect(receive(ch).PostID).To(Equal(uint(3)))
+		})
+
+		It("should only deliver new events without a starting ID", func() {
+			appendLike(1)
+
+			subCtx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			ch, err := bus.Subscribe(subCtx, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			appendLike(2)
+			Expect(receive(ch).PostID).To(Equal(uint(2)))
+		})
+
+		It("should close the subscription when the context is cancelled", func() {
+			subCtx, cancel := context.WithCancel(ctx)
+			ch, err := bus.Subscribe(subCtx, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			cancel()
+			Eventually(ch, 10*time.Second).Should(BeClosed())
+		})
+
+		It("should reject malformed IDs", func() {
+			_, err := bus.Since(ctx, "abc", 10)
+			Expect(err).To(MatchError(events.ErrInvalidEventID))
+
+			_, err = bus.Subscribe(ctx, "1-x")
+			Expect(err).To(MatchError(events.ErrInvalidEventID))
+		})
+	})
+}
+
+var _ = Describe("Event Bus", func() {
+	logger, _ := zap.NewDevelopment()
+
+	describeEventBus("StreamBus", func() events.EventBus {
+		return events.NewStreamBus(sharedContainers.Cache, 1000, logger)
+	})
+
+	describeEventBus("MemoryBus", func() events.EventBus {
+		return events.NewMemoryBus(1000)
+	})
+
+	It("should keep only the newest events in memory", func() {
+		ctx := context.Background()
+		bus := events.NewMemoryBus(2)
+		for postID := uint(1); postID <= 3; postID++ {
+			_, err := bus.Append(ctx, events.Event{Type: events.EventTypePostLiked, PostID: postID})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		all, err := bus.Since(ctx, "0-0", 10)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(all).To(HaveLen(2))
+		Expect(all[0].PostID).To(Equal(uint(2)))
+	})
+
+	It("should stamp published events", func() {
+		ctx := context.Background()
+		bus := events.NewMemoryBus(10)
+		publisher := events.NewPublisher(bus, logger)
+
+		Expect(publisher.PublishPostDeleted(ctx, 7, 1)).To(Succeed())
+
+		all, err := bus.Since(ctx, "0-0", 10)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(all).To(HaveLen(1))
+		Expect(all[0].Type).To(Equal(events.EventTypePostDeleted))
+		Expect(all[0].Timestamp).NotTo(BeZero())
+	})
+})
@@ -7,10 +7,19 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/rodolfodpk/instagrano/internal/domain"
 	postgresRepo "github.com/rodolfodpk/instagrano/internal/repository/postgres"
 	"github.com/rodolfodpk/instagrano/internal/service"
 )
 
+// Helper function to create feed service ranking the newest rankWindow posts with the decay ranker
+func createFeedService(postRepo postgresRepo.PostRepository, rankWindow int) *service.FeedService {
+	weights := testRankingWeights()
+	ranker, err := service.NewRanker(service.RankDecay, weights)
+	Expect(err).NotTo(HaveOccurred())
+	return service.NewFeedService(postRepo, sharedContainers.Cache, 5*time.Minute, rankWindow, 30*time.Minute, ranker, weights)
+}
+
 var _ = Describe("FeedService", func() {
 	Describe("GetFeedWithCursor", func() {
 		It("should return posts with cursor pagination", func() {
@@ -23,7 +32,7 @@ var _ = Describe("FeedService", func() {
 			}
 
 			postRepo := postgresRepo.NewPostRepository(sharedContainers.DB)
-			feedService := service.NewFeedService(postRepo, sharedContainers.Cache, 5*time.Minute)
+			feedService := createFeedService(postRepo, 1000)
 
 			// When: Get feed with cursor
 			result, err := feedService.GetFeedWithCursor(3, "")
@@ -40,7 +49,7 @@ var _ = Describe("FeedService", func() {
 			createTestPost(sharedContainers.DB, user.ID, "Test Post", "Test Caption")
 
 			postRepo := postgresRepo.NewPostRepository(sharedContainers.DB)
-			feedService := service.NewFeedService(postRepo, sharedContainers.Cache, 5*time.Minute)
+			feedService := createFeedService(postRepo, 1000)
 
 			// When: Get feed with empty cursor
 			result, err := feedService.GetFeedWithCursor(10, "")
@@ -61,7 +70,7 @@ var _ = Describe("FeedService", func() {
 			}
 
 			postRepo := postgresRepo.NewPostRepository(sharedContainers.DB)
-			feedService := service.NewFeedService(postRepo, sharedContainers.Cache, 5*time.Minute)
+			feedService := createFeedService(postRepo, 1000)
 
 			// When: Get feed with page size limit
 			result, err := feedService.GetFeedWithCursor(5, "")
@@ -78,7 +87,7 @@ var _ = Describe("FeedService", func() {
 			createTestPost(sharedContainers.DB, user.ID, "Test Post", "Test Caption")
 
 			postRepo := postgresRepo.NewPostRepository(sharedContainers.DB)
-			feedService := service.NewFeedService(postRepo, sharedContainers.Cache, 5*time.Minute)
+			feedService := createFeedService(postRepo, 1000)
 
 			// When: Get feed with invalid cursor
 			result, err := feedService.GetFeedWithCursor(10, "invalid-cursor")
@@ -98,7 +107,7 @@ var _ = Describe("FeedService", func() {
 			createTestPost(sharedContainers.DB, user.ID, "Second Post", "Second Caption")
 
 			postRepo := postgresRepo.NewPostRepository(sharedContainers.DB)
-			feedService := service.NewFeedService(postRepo, sharedContainers.Cache, 5*time.Minute)
+			feedService := createFeedService(postRepo, 1000)
 
 			// When: Get feed
 			result, err := feedService.GetFeedWithCursor(10, "")
@@ -116,7 +125,7 @@ var _ = Describe("FeedService", func() {
 			createTestPost(sharedContainers.DB, user.ID, "Test Post", "Test Caption")
 
 			postRepo := postgresRepo.NewPostRepository(sharedContainers.DB)
-			feedService := service.NewFeedService(postRepo, sharedContainers.Cache, 5*time.Minute)
+			feedService := createFeedService(postRepo, 1000)
 
 			// When: Get feed multiple times
 			result1, err1 := feedService.GetFeedWithCursor(10, "")
@@ -131,4 +140,32 @@ var _ = Describe("FeedService", func() {
 			// This test verifies the same number of posts are returned
 		})
 	})
-})
\ No newline at end of file
+
+	Describe("GetUserPosts", func() {
+		It("should page one user's posts newest first", func() {
+			// Given: Two users with posts
+			user := createTestUser(sharedContainers.DB, "profileuser", "profile@example.com")
+			other := createTestUser(sharedContainers.DB, "otheruser", "other@example.com")
+			for i := 0; i < 3; i++ {
+				createTestPost(sharedContainers.DB, user.ID, fmt.Sprintf("Post %d", i), "Caption")
+			}
+			createTestPost(sharedContainers.DB, other.ID, "Not mine", "Caption")
+
+			feedService := createFeedService(postgresRepo.NewPostRepository(sharedContainers.DB), 1000)
+
+			// When: Paging the user's posts two at a time
+			result, err := feedService.GetUserPosts(user.ID, 2, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Posts).To(HaveLen(2))
+			Expect(result.HasMore).To(BeTrue())
+			Expect(result.Posts[0].(*domain.Post).Title).To(Equal("Post 2"))
+
+			// Then: The last page holds only the user's remaining post
+			result, err = feedService.GetUserPosts(user.ID, 2, result.NextCursor)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Posts).To(HaveLen(1))
+			Expect(result.Posts[0].(*domain.Post).Title).To(Equal("Post 0"))
+			Expect(result.HasMore).To(BeFalse())
+		})
+	})
+})
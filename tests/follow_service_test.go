@@ -0,0 +1,153 @@
+package tests
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/rodolfodpk/instagrano/internal/domain"
+	postgresRepo "github.com/rodolfodpk/instagrano/internal/repository/postgres"
+	"github.com/rodolfodpk/instagrano/internal/service"
+	"go.uber.org/zap"
+)
+
+// Helper function to create follow service with all required dependencies
+func createFollowService() *service.FollowService {
+	followRepo := postgresRepo.NewFollowRepository(sharedContainers.DB)
+	userRepo := postgresRepo.NewUserRepository(sharedContainers.DB)
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+	return service.NewFollowService(followRepo, userRepo, nil, logger)
+}
+
+var _ = Describe("FollowService", func() {
+	Describe("Follow", func() {
+		It("should follow a user and update both counters", func() {
+			// Given: Two users
+			followService := createFollowService()
+			alice := createTestUser(sharedContainers.DB, "alice", "alice@example.com")
+			bob := createTestUser(sharedContainers.DB, "bob", "bob@example.com")
+
+			// When: Alice follows Bob
+			followee, err := followService.Follow(alice.ID, bob.ID, alice.Username)
+
+			// Then: Bob has one follower and Alice follows one user
+			Expect(err).NotTo(HaveOccurred())
+			Expect(followee.FollowersCount).To(Equal(1))
+
+			follower, err := followService.GetUser(alice.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(follower.FollowingCount).To(Equal(1))
+
+			following, err := followService.IsFollowing(alice.ID, bob.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(following).To(BeTrue())
+		})
+
+		It("should be idempotent", func() {
+			// Given: Alice already follows Bob
+			followService := createFollowService()
+			alice := createTestUser(sharedContainers.DB, "alice", "alice@example.com")
+			bob := createTestUser(sharedContainers.DB, "bob", "bob@example.com")
+			_, err := followService.Follow(alice.ID, bob.ID, alice.Username)
+			Expect(err).NotTo(HaveOccurred())
+
+			// When: Alice follows Bob again
+			followee, err := followService.Follow(alice.ID, bob.ID, alice.Username)
+
+			// Then: Counters are unchanged
+			Expect(err).NotTo(HaveOccurred())
+			Expect(followee.FollowersCount).To(Equal(1))
+		})
+
+		It("should reject following yourself", func() {
+			followService := createFollowService()
+			alice := createTestUser(sharedContainers.DB, "alice", "alice@example.com")
+
+			_, err := followService.Follow(alice.ID, alice.ID, alice.Username)
+
+			Expect(err).To(MatchError(service.ErrCannotFollowSelf))
+		})
+
+		It("should return not found for unknown users", func() {
+			followService := createFollowService()
+			alice := createTestUser(sharedContainers.DB, "alice", "alice@example.com")
+
+			_, err := followService.Follow(alice.ID, 9999, alice.Username)
+
+			Expect(err).To(MatchError(service.ErrUserNotFound))
+		})
+	})
+
+	Describe("Unfollow", func() {
+		It("should remove the follow and decrement counters", func() {
+			// Given: Alice follows Bob
+			followService := createFollowService()
+			alice := createTestUser(sharedContainers.DB, "alice", "alice@example.com")
+			bob := createTestUser(sharedContainers.DB, "bob", "bob@example.com")
+			_, err := followService.Follow(alice.ID, bob.ID, alice.Username)
+			Expect(err).NotTo(HaveOccurred())
+
+			// When: Alice unfollows Bob twice
+			_, err = followService.Unfollow(alice.ID, bob.ID)
+			Expect(err).NotTo(HaveOccurred())
+			followee, err := followService.Unfollow(alice.ID, bob.ID)
+
+			// Then: Counters go back to zero and never below
+			Expect(err).NotTo(HaveOccurred())
+			Expect(followee.FollowersCount).To(Equal(0))
+
+			follower, err := followService.GetUser(alice.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(follower.FollowingCount).To(Equal(0))
+		})
+	})
+
+	Describe("GetFollowers", func() {
+		It("should paginate followers newest first", func() {
+			// Given: Three users follow Bob
+			followService := createFollowService()
+			bob := createTestUser(sharedContainers.DB, "bob", "bob@example.com")
+			alice := createTestUser(sharedContainers.DB, "alice", "alice@example.com")
+			carol := createTestUser(sharedContainers.DB, "carol", "carol@example.com")
+			dave := createTestUser(sharedContainers.DB, "dave", "dave@example.com")
+			for _, u := range []*domain.User{alice, carol, dave} {
+				_, err := followService.Follow(u.ID, bob.ID, u.Username)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			// When: Getting the first page of two
+			page1, cursor, err := followService.GetFollowers(bob.ID, 2, "")
+
+			// Then: The two most recent followers are returned with a cursor
+			Expect(err).NotTo(HaveOccurred())
+			Expect(page1).To(HaveLen(2))
+			Expect(page1[0].Username).To(Equal("dave"))
+			Expect(page1[1].Username).To(Equal("carol"))
+			Expect(cursor).NotTo(BeEmpty())
+
+			// When: Getting the next page
+			page2, cursor, err := followService.GetFollowers(bob.ID, 2, cursor)
+
+			// Then: The remaining follower is returned and there are no more pages
+			Expect(err).NotTo(HaveOccurred())
+			Expect(page2).To(HaveLen(1))
+			Expect(page2[0].Username).To(Equal("alice"))
+			Expect(cursor).To(BeEmpty())
+
+			// And: Following list of Alice contains Bob
+			following, _, err := followService.GetFollowing(alice.ID, 10, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(following).To(HaveLen(1))
+			Expect(following[0].ID).To(Equal(bob.ID))
+		})
+
+		It("should reject an invalid cursor", func() {
+			followService := createFollowService()
+			bob := createTestUser(sharedContainers.DB, "bob", "bob@example.com")
+
+			_, _, err := followService.GetFollowers(bob.ID, 10, "not-a-cursor!")
+
+			Expect(err).To(MatchError(service.ErrInvalidCursor))
+		})
+	})
+})
@@ -33,12 +33,12 @@ var _ = Describe("PostHandler", func() {
 
 			postRepo := postgresRepo.NewPostRepository(sharedContainers.DB)
 			mediaStorage := createTestS3Storage()
-			postService := service.NewPostService(postRepo, mediaStorage, sharedContainers.Cache, 5*time.Minute)
+			postService := service.NewPostService(postRepo, mediaStorage, sharedContainers.Cache, 5*time.Minute, nil, nil, nil)
 
 			logger, _ := zap.NewProduction()
 			defer logger.Sync()
-			eventPublisher := events.NewPublisher(sharedContainers.Cache, logger)
-			postHandler := handler.NewPostHandler(postService, eventPublisher, logger)
+			eventPublisher := events.NewPublisher(events.NewMemoryBus(1000), logger)
+			postHandler := handler.NewPostHandler(postService, nil, eventPublisher, logger)
 
 			// Create Fiber app
 			app := fiber.New()
@@ -62,12 +62,12 @@ var _ = Describe("PostHandler", func() {
 			// Given: Post handler
 			postRepo := postgresRepo.NewPostRepository(sharedContainers.DB)
 			mediaStorage := createTestS3Storage()
-			postService := service.NewPostService(postRepo, mediaStorage, sharedContainers.Cache, 5*time.Minute)
+			postService := service.NewPostService(postRepo, mediaStorage, sharedContainers.Cache, 5*time.Minute, nil, nil, nil)
 
 			logger, _ := zap.NewProduction()
 			defer logger.Sync()
-			eventPublisher := events.NewPublisher(sharedContainers.Cache, logger)
-			postHandler := handler.NewPostHandler(postService, eventPublisher, logger)
+			eventPublisher := events.NewPublisher(events.NewMemoryBus(1000), logger)
+			postHandler := handler.NewPostHandler(postService, nil, eventPublisher, logger)
 
 			// Create Fiber app
 			app := fiber.New()
@@ -86,12 +86,12 @@ var _ = Describe("PostHandler", func() {
 			// Given: Post handler
 			postRepo := postgresRepo.NewPostRepository(sharedContainers.DB)
 			mediaStorage := createTestS3Storage()
-			postService := service.NewPostService(postRepo, mediaStorage, sharedContainers.Cache, 5*time.Minute)
+			postService := service.NewPostService(postRepo, mediaStorage, sharedContainers.Cache, 5*time.Minute, nil, nil, nil)
 
 			logger, _ := zap.NewProduction()
 			defer logger.Sync()
-			eventPublisher := events.NewPublisher(sharedContainers.Cache, logger)
-			postHandler := handler.NewPostHandler(postService, eventPublisher, logger)
+			eventPublisher := events.NewPublisher(events.NewMemoryBus(1000), logger)
+			postHandler := handler.NewPostHandler(postService, nil, eventPublisher, logger)
 
 			// Create Fiber app
 			app := fiber.New()
@@ -139,17 +139,17 @@ var _ = Describe("PostHandler", func() {
 			err = mediaStorage.CreateBucketIfNotExists()
 			Expect(err).NotTo(HaveOccurred())
 
-			postService := service.NewPostService(postRepo, mediaStorage, sharedContainers.Cache, 5*time.Minute)
+			postService := service.NewPostService(postRepo, mediaStorage, sharedContainers.Cache, 5*time.Minute, nil, nil, nil)
 
 			logger, _ := zap.NewProduction()
 			defer logger.Sync()
-			eventPublisher := events.NewPublisher(sharedContainers.Cache, logger)
-			postHandler := handler.NewPostHandler(postService, eventPublisher, logger)
+			eventPublisher := events.NewPublisher(events.NewMemoryBus(1000), logger)
+			postHandler := handler.NewPostHandler(postService, nil, eventPublisher, logger)
 
 			// Create Fiber app with auth middleware
 			app := fiber.New()
 			appCfg := &config.Config{JWTSecret: "test-secret"}
-			app.Use(middleware.JWT(appCfg.JWTSecret))
+			app.Use(middleware.JWT(appCfg.JWTSecret, createAuthService()))
 			app.Post("/posts", postHandler.CreatePost)
 
 			// When: Create post
@@ -189,17 +189,17 @@ var _ = Describe("PostHandler", func() {
 			// Given: Post handler
 			postRepo := postgresRepo.NewPostRepository(sharedContainers.DB)
 			mediaStorage := createTestS3Storage()
-			postService := service.NewPostService(postRepo, mediaStorage, sharedContainers.Cache, 5*time.Minute)
+			postService := service.NewPostService(postRepo, mediaStorage, sharedContainers.Cache, 5*time.Minute, nil, nil, nil)
 
 			logger, _ := zap.NewProduction()
 			defer logger.Sync()
-			eventPublisher := events.NewPublisher(sharedContainers.Cache, logger)
-			postHandler := handler.NewPostHandler(postService, eventPublisher, logger)
+			eventPublisher := events.NewPublisher(events.NewMemoryBus(1000), logger)
+			postHandler := handler.NewPostHandler(postService, nil, eventPublisher, logger)
 
 			// Create Fiber app with auth middleware
 			app := fiber.New()
 			appCfg := &config.Config{JWTSecret: "test-secret"}
-			app.Use(middleware.JWT(appCfg.JWTSecret))
+			app.Use(middleware.JWT(appCfg.JWTSecret, createAuthService()))
 			app.Post("/posts", postHandler.CreatePost)
 
 			// When: Create post without authentication
@@ -225,17 +225,17 @@ var _ = Describe("PostHandler", func() {
 
 			postRepo := postgresRepo.NewPostRepository(sharedContainers.DB)
 			mediaStorage := createTestS3Storage()
-			postService := service.NewPostService(postRepo, mediaStorage, sharedContainers.Cache, 5*time.Minute)
+			postService := service.NewPostService(postRepo, mediaStorage, sharedContainers.Cache, 5*time.Minute, nil, nil, nil)
 
 			logger, _ := zap.NewProduction()
 			defer logger.Sync()
-			eventPublisher := events.NewPublisher(sharedContainers.Cache, logger)
-			postHandler := handler.NewPostHandler(postService, eventPublisher, logger)
+			eventPublisher := events.NewPublisher(events.NewMemoryBus(1000), logger)
+			postHandler := handler.NewPostHandler(postService, nil, eventPublisher, logger)
 
 			// Create Fiber app with auth middleware
 			app := fiber.New()
 			appCfg := &config.Config{JWTSecret: "test-secret"}
-			app.Use(middleware.JWT(appCfg.JWTSecret))
+			app.Use(middleware.JWT(appCfg.JWTSecret, createAuthService()))
 			app.Post("/posts", postHandler.CreatePost)
 
 			// When: Create post with invalid data
@@ -0,0 +1,198 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+
+	"github.com/rodolfodpk/instagrano/internal/domain"
+	"github.com/rodolfodpk/instagrano/internal/dto"
+	postgresRepo "github.com/rodolfodpk/instagrano/internal/repository/postgres"
+	"github.com/rodolfodpk/instagrano/internal/service"
+)
+
+var _ = Describe("Hashtags", func() {
+	Describe("ParseHashtags", func() {
+		It("should return lowercased hashtags in order without duplicates", func() {
+			Expect(domain.ParseHashtags("#Sunset at the #beach. #sunset #Beach_Life!")).
+				To(Equal([]string{"sunset", "beach", "beach_life"}))
+		})
+
+		It("should ignore anchors, bare numbers and empty tags", func() {
+			Expect(domain.ParseHashtags("see page#section, I'm #1 # and ##")).To(BeEmpty())
+		})
+
+		It("should accept letters from any script", func() {
+			Expect(domain.ParseHashtags("#café #東京2024")).To(Equal([]string{"café", "東京2024"}))
+		})
+	})
+
+	Describe("Hashtag pages", func() {
+		var (
+			postService *service.PostService
+			feedService *service.FeedService
+			user        *domain.User
+		)
+
+		BeforeEach(func() {
+			postRepo := postgresRepo.NewPostRepository(sharedContainers.DB)
+			postService = service.NewPostService(postRepo, NewMockMediaStorage(), sharedContainers.Cache, 5*time.Minute, nil, nil, nil)
+			feedService = createFeedService(postRepo, 1000)
+			user = createTestUser(sharedContainers.DB, "tagger", "tagger@example.com")
+		})
+
+		createPost := func(title, caption string) *domain.Post {
+			post, err := postService.CreatePostFromURL(user.ID, title, caption, "https://example.com/"+strings.ToLower(title)+".jpg")
+			Expect(err).NotTo(HaveOccurred())
+			return post
+		}
+
+		It("should page the posts with a hashtag", func() {
+			// Given: Three posts tagged #travel, one in a different case, and one untagged
+			for i := 0; i < 3; i++ {
+				createPost(fmt.Sprintf("Trip%d", i), fmt.Sprintf("Day %d #Travel", i))
+			}
+			createPost("Home", "Staying in #cozy")
+
+			// When: Paging #travel two at a time
+			result, err := feedService.GetHashtagFeed("#travel", 2, "", service.RankChronological)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Posts).To(HaveLen(2))
+			Expect(result.HasMore).To(BeTrue())
+			Expect(result.Posts[0].(*domain.Post).Title).To(Equal("Trip2"))
+
+			// Then: The last page holds the remaining tagged post
+			result, err = feedService.GetHashtagFeed("travel", 2, result.NextCursor, service.RankChronological)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Posts).To(HaveLen(1))
+			Expect(result.Posts[0].(*domain.Post).Title).To(Equal("Trip0"))
+			Expect(result.HasMore).To(BeFalse())
+		})
+
+		It("should reject a ranking cursor from another hashtag", func() {
+			// Given: Three posts tagged #travel and one tagged #food
+			for i := 0; i < 3; i++ {
+				createPost(fmt.Sprintf("Trip%d", i), "On the road #travel")
+			}
+			createPost("Lunch", "Noodles #food")
+
+			// When: Paging #food with the cursor of a #travel ranking
+			result, err := feedService.GetHashtagFeed("travel", 2, "", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.HasMore).To(BeTrue())
+			_, err = feedService.GetHashtagFeed("food", 2, result.NextCursor, "")
+
+			// Then: The cursor is rejected
+			Expect(err).To(MatchError(service.ErrInvalidCursor))
+		})
+
+		It("should follow caption edits", func() {
+			// Given: A post tagged #draft
+			post := createPost("Edited", "Work in progress #draft")
+
+			// When: The caption is edited to #final
+			caption := "Done #final"
+			_, err := postService.UpdatePost(post.ID, user.ID, nil, &caption, post.UpdatedAt)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Then: The post moves from #draft to #final
+			result, err := feedService.GetHashtagFeed("draft", 10, "", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Posts).To(BeEmpty())
+
+			result, err = feedService.GetHashtagFeed("final", 10, "", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Posts).To(HaveLen(1))
+		})
+
+		It("should reject invalid hashtags", func() {
+			_, err := feedService.GetHashtagFeed("not a tag", 10, "", "")
+			Expect(err).To(MatchError(service.ErrInvalidHashtag))
+		})
+	})
+
+	Describe("Trending", func() {
+		var hashtagService *service.HashtagService
+
+		BeforeEach(func() {
+			hashtagService = service.NewHashtagService(sharedContainers.Cache, zap.NewNop())
+		})
+
+		It("should rank hashtags by posts within the window", func() {
+			// Given: #go used three times now, #rust twice now and #java three times two hours ago
+			ctx := context.Background()
+			now := time.Now()
+			Expect(hashtagService.RecordHashtags(ctx, []string{"go", "rust"}, now)).To(Succeed())
+			Expect(hashtagService.RecordHashtags(ctx, []string{"go", "rust"}, now)).To(Succeed())
+			Expect(hashtagService.RecordHashtags(ctx, []string{"go"}, now)).To(Succeed())
+			for i := 0; i < 3; i++ {
+				Expect(hashtagService.RecordHashtags(ctx, []string{"java"}, now.Add(-2*time.Hour))).To(Succeed())
+			}
+
+			// Then: The last hour leaves #java out
+			hashtags, err := hashtagService.GetTrending(service.TrendingHour, 10)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hashtags).To(Equal([]domain.TrendingHashtag{
+				{Name: "go", PostsCount: 3},
+				{Name: "rust", PostsCount: 2},
+			}))
+
+			// Then: The last day includes it, and limit keeps the top ones
+			hashtags, err = hashtagService.GetTrending(service.TrendingDay, 2)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hashtags).To(Equal([]domain.TrendingHashtag{
+				{Name: "go", PostsCount: 3},
+				{Name: "java", PostsCount: 3},
+			}))
+
+			// Then: No scratch union is left behind
+			keys, err := sharedContainers.Cache.Keys(ctx, "zunion:*")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(keys).To(BeEmpty())
+		})
+
+		It("should reject unknown windows", func() {
+			_, err := hashtagService.GetTrending("1y", 10)
+			Expect(err).To(MatchError(service.ErrUnknownTrendingWindow))
+		})
+
+		It("should count new posts and serve them over HTTP", func() {
+			app, _, cleanup := setupTestApp()
+			defer cleanup()
+
+			// Given: A post tagged #launch
+			token := registerAndLogin(app, "launcher", "launcher@example.com", "password123")
+			createTestPostWithSSE(app, token, "Launch", "We are live #Launch")
+
+			// When: Listing trending hashtags and the #launch page
+			req := httptest.NewRequest("GET", "/api/hashtags/trending?window=1h", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			resp, err := app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(200))
+
+			var trending dto.TrendingHashtagsResponse
+			Expect(json.NewDecoder(resp.Body).Decode(&trending)).To(Succeed())
+			Expect(trending.Hashtags).To(ContainElement(domain.TrendingHashtag{Name: "launch", PostsCount: 1}))
+
+			req = httptest.NewRequest("GET", "/api/hashtags/%23launch/posts", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			resp, err = app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(200))
+
+			// Then: The post is on its hashtag page
+			var feed dto.FeedResponse
+			Expect(json.NewDecoder(resp.Body).Decode(&feed)).To(Succeed())
+			Expect(feed.Posts).To(HaveLen(1))
+			Expect(feed.Posts[0].Title).To(Equal("Launch"))
+		})
+	})
+})
@@ -0,0 +1,250 @@
+package tests
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+
+	"github.com/rodolfodpk/instagrano/internal/events"
+)
+
+var _ = Describe("Event Hub", func() {
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+		bus    *events.MemoryBus
+		hub    *events.Hub
+	)
+
+	// startHub runs a hub with the given per-client buffer on an in-memory bus
+	startHub := func(bufferSize int) {
+		logger, _ := zap.NewDevelopment()
+		bus = events.NewMemoryBus(100)
+		hub = events.NewHub(bus, bufferSize, logger)
+		go hub.Run(ctx)
+		// Let the hub subscribe before anything is published
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	publish := func(event events.Event) events.Event {
+		event, err := bus.Append(ctx, event)
+		Expect(err).NotTo(HaveOccurred())
+		return event
+	}
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	It("should fan out each event to every client", func() {
+		startHub(10)
+		first, _, err := hub.Register(ctx, 1, events.TransportWebSocket, "")
+		Expect(err).NotTo(HaveOccurred())
+		second, _, err := hub.Register(ctx, 2, events.TransportSSE, "")
+		Expect(err).NotTo(HaveOccurred())
+
+		publish(events.Event{Type: events.EventTypePostLiked, PostID: 7})
+
+		Eventually(first.Events()).Should(Receive(HaveField("PostID", uint(7))))
+		Eventually(second.Events()).Should(Receive(HaveField("PostID", uint(7))))
+	})
+
+	It("should only deliver private events to their recipient", func() {
+		startHub(10)
+		recipient, _, _ := hub.Register(ctx, 1, events.TransportWebSocket, "")
+		other, _, _ := hub.Register(ctx, 2, events.TransportWebSocket, "")
+
+		publish(events.Event{Type: events.EventTypeUserFollowed, RecipientUserID: 1})
+
+		Eventually(recipient.Events()).Should(Receive())
+		Consistently(other.Events(), 200*time.Millisecond).ShouldNot(Receive())
+	})
+
+	It("should drop clients whose buffer is full", func() {
+		startHub(2)
+		slow, _, _ := hub.Register(ctx, 1, events.TransportWebSocket, "")
+		fast, _, _ := hub.Register(ctx, 2, events.TransportWebSocket, "")
+
+		// Given: The fast client keeps reading
+		received := make(chan events.Event, 10)
+		go func() {
+			for {
+				select {
+				case event := <-fast.Events():
+					received <- event
+				case <-fast.Done():
+					return
+				}
+			}
+		}()
+
+		// When: More events arrive than the slow client can buffer
+		for postID := uint(1); postID <= 3; postID++ {
+			publish(events.Event{Type: events.EventTypePostLiked, PostID: postID})
+		}
+
+		// Then: Only the slow client is disconnected
+		Eventually(slow.Done()).Should(BeClosed())
+		Eventually(received).Should(HaveLen(3))
+		Expect(fast.Done()).NotTo(BeClosed())
+
+		stats := hub.Stats()
+		Expect(stats.Connections).To(Equal(1))
+		Expect(stats.Dropped).To(Equal(int64(1)))
+	})
+
+	It("should count connections by transport", func() {
+		startHub(10)
+		ws, _, _ := hub.Register(ctx, 1, events.TransportWebSocket, "")
+		hub.Register(ctx, 2, events.TransportSSE, "")
+		hub.Register(ctx, 3, events.TransportSSE, "")
+
+		stats := hub.Stats()
+		Expect(stats.Connections).To(Equal(3))
+		Expect(stats.ByTransport[events.TransportWebSocket]).To(Equal(1))
+		Expect(stats.ByTransport[events.TransportSSE]).To(Equal(2))
+
+		hub.Unregister(ws)
+		hub.Unregister(ws)
+		Expect(hub.Stats().Connections).To(Equal(2))
+		Expect(ws.Done()).To(BeClosed())
+	})
+
+	It("should return missed events on register and skip them when they arrive live", func() {
+		startHub(10)
+		seen := publish(events.Event{Type: events.EventTypePostLiked, PostID: 1})
+		missed := publish(events.Event{Type: events.EventTypePostLiked, PostID: 2})
+		publish(events.Event{Type: events.EventTypeUserFollowed, RecipientUserID: 99})
+
+		client, backlog, err := hub.Register(ctx, 1, events.TransportWebSocket, seen.ID)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(backlog).To(HaveLen(1))
+		Expect(backlog[0].ID).To(Equal(missed.ID))
+		Expect(client.Advance(backlog[0].ID)).To(BeTrue())
+		Expect(client.Advance(missed.ID)).To(BeFalse())
+		Expect(client.Advance(seen.ID)).To(BeFalse())
+	})
+
+	It("should send a resync instead of a backlog longer than the buffer", func() {
+		// Given: A client that missed more events than its buffer holds
+		startHub(2)
+		seen := publish(events.Event{Type: events.EventTypePostLiked, PostID: 1})
+		for i := 0; i < 3; i++ {
+			publish(events.Event{Type: events.EventTypePostLiked, PostID: 2})
+		}
+		newest := publish(events.Event{Type: events.EventTypeUserFollowed, RecipientUserID: 99})
+		// Let the hub dispatch them before the client registers
+		time.Sleep(50 * time.Millisecond)
+
+		// When: Reconnecting from the last event it saw
+		client, backlog, err := hub.Register(ctx, 1, events.TransportSSE, seen.ID)
+
+		// Then: It gets a single resync past every missed event
+		Expect(err).NotTo(HaveOccurred())
+		Expect(backlog).To(HaveLen(1))
+		Expect(backlog[0].Type).To(Equal(events.EventTypeResync))
+		Expect(backlog[0].ID).To(Equal(newest.ID))
+		Expect(backlog[0].IsVisibleTo(1)).To(BeTrue())
+		Expect(client.Advance(backlog[0].ID)).To(BeTrue())
+		Expect(client.Advance(newest.ID)).To(BeFalse())
+		Expect(hub.Stats().Connections).To(Equal(1))
+	})
+
+	It("should reject malformed resume IDs", func() {
+		startHub(10)
+		_, _, err := hub.Register(ctx, 1, events.TransportSSE, "nope")
+		Expect(err).To(MatchError(events.ErrInvalidEventID))
+		Expect(hub.Stats().Connections).To(BeZero())
+	})
+
+	It("should disconnect every client when stopped", func() {
+		startHub(10)
+		client, _, _ := hub.Register(ctx, 1, events.TransportSSE, "")
+
+		cancel()
+
+		Eventually(client.Done()).Should(BeClosed())
+	})
+
+	Describe("Subscriptions", func() {
+		It("should only deliver events matching every subscribed dimension", func() {
+			startHub(10)
+			client, _, _ := hub.Register(ctx, 1, events.TransportWebSocket, "")
+			client.Subscribe(events.Subscription{
+				PostIDs: []uint{7},
+				Types:   []events.EventType{events.EventTypePostCommented},
+			})
+
+			publish(events.Event{Type: events.EventTypePostLiked, PostID: 7})
+			publish(events.Event{Type: events.EventTypePostCommented, PostID: 8})
+			wanted := publish(events.Event{Type: events.EventTypePostCommented, PostID: 7})
+
+			Eventually(client.Events()).Should(Receive(HaveField("ID", wanted.ID)))
+			Consistently(client.Events(), 200*time.Millisecond).ShouldNot(Receive())
+		})
+
+		It("should match users who triggered or received an event", func() {
+			startHub(10)
+			client, _, _ := hub.Register(ctx, 5, events.TransportWebSocket, "")
+			client.Subscribe(events.Subscription{UserIDs: []uint{5}})
+
+			publish(events.Event{Type: events.EventTypePostLiked, TriggeredByUserID: 5})
+			publish(events.Event{Type: events.EventTypeUserFollowed, TriggeredByUserID: 2, RecipientUserID: 5})
+			publish(events.Event{Type: events.EventTypePostLiked, TriggeredByUserID: 6})
+
+			Eventually(client.Events()).Should(Receive(HaveField("TriggeredByUserID", uint(5))))
+			Eventually(client.Events()).Should(Receive(HaveField("RecipientUserID", uint(5))))
+			Consistently(client.Events(), 200*time.Millisecond).ShouldNot(Receive())
+		})
+
+		It("should deliver the subscriber's private events past post and user filters", func() {
+			// Given: A client narrowed to one post and one other user
+			startHub(10)
+			client, _, _ := hub.Register(ctx, 5, events.TransportWebSocket, "")
+			client.Subscribe(events.Subscription{PostIDs: []uint{7}, UserIDs: []uint{2}})
+
+			// When: Events addressed to the client concern other posts and users
+			notification := publish(events.Event{Type: events.EventTypeNotification, PostID: 8, TriggeredByUserID: 3, RecipientUserID: 5})
+			publish(events.Event{Type: events.EventTypePostLiked, PostID: 8, TriggeredByUserID: 3})
+
+			// Then: Only the private event gets through
+			Eventually(client.Events()).Should(Receive(HaveField("ID", notification.ID)))
+			Consistently(client.Events(), 200*time.Millisecond).ShouldNot(Receive())
+
+			// Then: A type filter still applies to them
+			client.Subscribe(events.Subscription{Types: []events.EventType{events.EventTypePostLiked}})
+			publish(events.Event{Type: events.EventTypeNotification, RecipientUserID: 5})
+			Consistently(client.Events(), 200*time.Millisecond).ShouldNot(Receive())
+		})
+
+		It("should receive everything again once fully unsubscribed", func() {
+			startHub(10)
+			client, _, _ := hub.Register(ctx, 1, events.TransportWebSocket, "")
+			client.Subscribe(events.Subscription{PostIDs: []uint{7, 8}})
+			client.Unsubscribe(events.Subscription{PostIDs: []uint{7}})
+			Expect(client.Subscriptions().PostIDs).To(Equal([]uint{8}))
+
+			client.Unsubscribe(events.Subscription{PostIDs: []uint{8}})
+			Expect(client.Subscriptions()).To(Equal(events.Subscription{}))
+
+			publish(events.Event{Type: events.EventTypePostLiked, PostID: 9})
+			Eventually(client.Events()).Should(Receive())
+		})
+
+		It("should reject unknown event types", func() {
+			err := events.Subscription{Types: []events.EventType{"heartbeat"}}.Validate()
+			Expect(err).To(HaveOccurred())
+
+			err = events.Subscription{Types: []events.EventType{events.EventTypeNewPost}}.Validate()
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
@@ -9,6 +9,8 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+
+	"github.com/rodolfodpk/instagrano/internal/service"
 )
 
 var _ = Describe("Integration Tests", func() {
@@ -78,6 +80,71 @@ var _ = Describe("Integration Tests", func() {
 			json.NewDecoder(feedResp.Body).Decode(&feedResult)
 			Expect(feedResult).To(HaveKey("posts"))
 		})
+
+		It("should return the following feed", func() {
+			// Given: Test app setup
+			app, _, cleanup := setupTestApp()
+			defer cleanup()
+
+			// Given: User is registered and logged in
+			token := registerAndLogin(app, "timelineuser", "timeline@example.com", "pass123")
+
+			// When: Request following feed
+			feedReq := httptest.NewRequest("GET", "/api/feed?type=following", nil)
+			feedReq.Header.Set("Authorization", "Bearer "+token)
+			feedResp, err := app.Test(feedReq, 2000)
+
+			// Then: Feed is returned
+			Expect(err).NotTo(HaveOccurred())
+			Expect(feedResp.StatusCode).To(Equal(200))
+
+			var feedResult map[string]interface{}
+			json.NewDecoder(feedResp.Body).Decode(&feedResult)
+			Expect(feedResult).To(HaveKey("posts"))
+			Expect(feedResult["has_more"]).To(BeFalse())
+		})
+
+		It("should rank the feed with the requested ranker", func() {
+			// Given: Test app setup
+			app, _, cleanup := setupTestApp()
+			defer cleanup()
+
+			token := registerAndLogin(app, "rankuser", "rank@example.com", "pass123")
+
+			// When: Request feed with each built-in ranker and an unknown one
+			for _, rank := range service.RankerNames {
+				req := httptest.NewRequest("GET", "/api/feed?rank="+rank, nil)
+				req.Header.Set("Authorization", "Bearer "+token)
+				resp, err := app.Test(req, 2000)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(200), rank)
+			}
+
+			req := httptest.NewRequest("GET", "/api/feed?rank=bogus", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			resp, err := app.Test(req, 2000)
+
+			// Then: Only the unknown ranker is rejected
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(400))
+		})
+
+		It("should reject an unknown feed type", func() {
+			// Given: Test app setup
+			app, _, cleanup := setupTestApp()
+			defer cleanup()
+
+			token := registerAndLogin(app, "badtypeuser", "badtype@example.com", "pass123")
+
+			// When: Request feed with an unknown type
+			feedReq := httptest.NewRequest("GET", "/api/feed?type=bogus", nil)
+			feedReq.Header.Set("Authorization", "Bearer "+token)
+			feedResp, err := app.Test(feedReq, 2000)
+
+			// Then: Should return 400
+			Expect(err).NotTo(HaveOccurred())
+			Expect(feedResp.StatusCode).To(Equal(400))
+		})
 	})
 
 	Describe("JWT Token Validation", func() {
@@ -114,6 +181,70 @@ var _ = Describe("Integration Tests", func() {
 		})
 	})
 
+	Describe("Logout", func() {
+		It("should reject the access token after logout", func() {
+			// Given: Test app setup
+			app, _, cleanup := setupTestApp()
+			defer cleanup()
+
+			// Given: User is registered and logged in
+			token := registerAndLogin(app, "logoutuser", "logout@example.com", "pass123")
+
+			// When: User logs out
+			logoutReq := httptest.NewRequest("POST", "/api/auth/logout", nil)
+			logoutReq.Header.Set("Authorization", "Bearer "+token)
+			logoutResp, err := app.Test(logoutReq, 2000) // 2 second timeout
+
+			// Then: Logout succeeds
+			Expect(err).NotTo(HaveOccurred())
+			Expect(logoutResp.StatusCode).To(Equal(200))
+
+			// When: The same token is used again
+			feedReq := httptest.NewRequest("GET", "/api/feed", nil)
+			feedReq.Header.Set("Authorization", "Bearer "+token)
+			feedResp, err := app.Test(feedReq, 2000) // 2 second timeout
+
+			// Then: Should return 401
+			Expect(err).NotTo(HaveOccurred())
+			Expect(feedResp.StatusCode).To(Equal(401))
+		})
+	})
+
+	Describe("Event Stream", func() {
+		It("should require the Authorization header", func() {
+			// Given: Test app setup
+			app, _, cleanup := setupTestApp()
+			defer cleanup()
+
+			// When: Opening the stream without a token
+			req := httptest.NewRequest("GET", "/api/events/stream", nil)
+			resp, err := app.Test(req, 2000) // 2 second timeout
+
+			// Then: Should return 401
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(401))
+		})
+
+		It("should reject an invalid Last-Event-ID", func() {
+			// Given: Test app setup
+			app, _, cleanup := setupTestApp()
+			defer cleanup()
+
+			// Given: User is registered and logged in
+			token := registerAndLogin(app, "sseuser", "sse@example.com", "pass123")
+
+			// When: Resuming from a malformed event ID
+			req := httptest.NewRequest("GET", "/api/events/stream", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Last-Event-ID", "not-an-id")
+			resp, err := app.Test(req, 2000) // 2 second timeout
+
+			// Then: Should return 400
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(400))
+		})
+	})
+
 	Describe("Post Creation", func() {
 		It("should create post successfully", func() {
 			// Given: Test app setup
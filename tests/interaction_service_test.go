@@ -1,9 +1,19 @@
 package tests
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/rodolfodpk/instagrano/internal/domain"
+	"github.com/rodolfodpk/instagrano/internal/dto"
 	"github.com/rodolfodpk/instagrano/internal/events"
 	postgresRepo "github.com/rodolfodpk/instagrano/internal/repository/postgres"
 	"github.com/rodolfodpk/instagrano/internal/service"
@@ -17,8 +27,8 @@ func createInteractionService() (*service.InteractionService, postgresRepo.LikeR
 	postRepo := postgresRepo.NewPostRepository(sharedContainers.DB)
 	logger, _ := zap.NewProduction()
 	defer logger.Sync()
-	eventPublisher := events.NewPublisher(sharedContainers.Cache, logger)
-	interactionService := service.NewInteractionService(likeRepo, commentRepo, postRepo, sharedContainers.Cache, eventPublisher, logger)
+	notificationService := service.NewNotificationService(postgresRepo.NewNotificationRepository(sharedContainers.DB), logger)
+	interactionService := service.NewInteractionService(likeRepo, commentRepo, postRepo, sharedContainers.Cache, notificationService, nil, logger)
 	return interactionService, likeRepo, commentRepo, postRepo
 }
 
@@ -145,6 +155,311 @@ var _ = Describe("InteractionService", func() {
 		})
 	})
 
+	Describe("AddLike and RemoveLike", func() {
+		It("should keep a post liked when the like is retried", func() {
+			// Given: Interaction service setup
+			interactionService, likeRepo, _, postRepo := createInteractionService()
+			author := createTestUser(sharedContainers.DB, "retryauthor", "retryauthor@example.com")
+			user := createTestUser(sharedContainers.DB, "retryuser", "retry@example.com")
+			post := createTestPost(sharedContainers.DB, author.ID, "Retried Like", "Caption")
+
+			// When: The same like is sent twice
+			likesCount, _, err := interactionService.AddLike(user.ID, post.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(likesCount).To(Equal(1))
+			likesCount, _, err = interactionService.AddLike(user.ID, post.ID)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Then: The post is liked once
+			Expect(likesCount).To(Equal(1))
+			likes, err := likeRepo.FindByPostID(post.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(likes).To(HaveLen(1))
+			updatedPost, err := postRepo.FindByID(post.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updatedPost.LikesCount).To(Equal(1))
+		})
+
+		It("should keep a post unliked when the unlike is retried", func() {
+			interactionService, likeRepo, _, postRepo := createInteractionService()
+			user := createTestUser(sharedContainers.DB, "retryunliker", "retryunliker@example.com")
+			post := createTestPost(sharedContainers.DB, user.ID, "Retried Unlike", "Caption")
+			_, _, err := interactionService.AddLike(user.ID, post.ID)
+			Expect(err).NotTo(HaveOccurred())
+
+			for i := 0; i < 2; i++ {
+				likesCount, _, err := interactionService.RemoveLike(user.ID, post.ID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(likesCount).To(BeZero())
+			}
+
+			likes, err := likeRepo.FindByPostID(post.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(likes).To(BeEmpty())
+			updatedPost, err := postRepo.FindByID(post.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updatedPost.LikesCount).To(BeZero())
+		})
+
+		It("should clear cached first feed pages of every ranker", func() {
+			// Given: Cached first feed pages
+			interactionService, _, _, _ := createInteractionService()
+			user := createTestUser(sharedContainers.DB, "cacheliker", "cacheliker@example.com")
+			post := createTestPost(sharedContainers.DB, user.ID, "Cached Like", "Caption")
+			ctx := context.Background()
+			for _, ranker := range service.RankerNames {
+				key := fmt.Sprintf("feed:%s:cursor::limit:5", ranker)
+				Expect(sharedContainers.Cache.Set(ctx, key, []byte("{}"), time.Minute)).To(Succeed())
+			}
+
+			// When: The post is liked
+			_, _, err := interactionService.AddLike(user.ID, post.ID)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Then: No stale page is left
+			for _, ranker := range service.RankerNames {
+				_, err := sharedContainers.Cache.Get(ctx, fmt.Sprintf("feed:%s:cursor::limit:5", ranker))
+				Expect(err).To(HaveOccurred())
+			}
+		})
+
+		It("should record events only for likes that changed", func() {
+			interactionService, _, _, _ := createInteractionService()
+			user := createTestUser(sharedContainers.DB, "retryevents", "retryevents@example.com")
+			post := createTestPost(sharedContainers.DB, user.ID, "Retried Events", "Caption")
+
+			interactionService.AddLike(user.ID, post.ID)
+			interactionService.AddLike(user.ID, post.ID)
+			interactionService.RemoveLike(user.ID, post.ID)
+			interactionService.RemoveLike(user.ID, post.ID)
+
+			pending, err := postgresRepo.NewOutboxRepository(sharedContainers.DB).CountPending()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pending).To(Equal(2))
+		})
+
+		It("should like and unlike over PUT and DELETE", func() {
+			// Given: Test app setup
+			app, _, cleanup := setupTestApp()
+			defer cleanup()
+
+			token := registerAndLogin(app, "putliker", "putliker@example.com", "pass123")
+			var userID uint
+			err := sharedContainers.DB.QueryRow(`SELECT id FROM users WHERE username = 'putliker'`).Scan(&userID)
+			Expect(err).NotTo(HaveOccurred())
+			post := createTestPost(sharedContainers.DB, userID, "Put Like", "Caption")
+
+			send := func(method string) dto.LikeResponse {
+				req := httptest.NewRequest(method, fmt.Sprintf("/api/posts/%d/like", post.ID), nil)
+				req.Header.Set("Authorization", "Bearer "+token)
+				resp, err := app.Test(req, 2000)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(200))
+				var result dto.LikeResponse
+				Expect(json.NewDecoder(resp.Body).Decode(&result)).To(Succeed())
+				return result
+			}
+
+			Expect(send("PUT").LikesCount).To(Equal(1))
+			Expect(send("PUT").LikesCount).To(Equal(1))
+			Expect(send("DELETE").LikesCount).To(BeZero())
+			Expect(send("DELETE").LikesCount).To(BeZero())
+		})
+
+		It("should report likes of unknown posts as not found", func() {
+			// Given: Test app setup
+			app, _, cleanup := setupTestApp()
+			defer cleanup()
+			token := registerAndLogin(app, "ghostliker", "ghostliker@example.com", "pass123")
+
+			for _, method := range []string{"PUT", "DELETE"} {
+				// When: Liking or unliking a post that does not exist
+				req := httptest.NewRequest(method, "/api/posts/99999/like", nil)
+				req.Header.Set("Authorization", "Bearer "+token)
+				resp, err := app.Test(req, 2000)
+				Expect(err).NotTo(HaveOccurred())
+
+				// Then: The post is not found
+				Expect(resp.StatusCode).To(Equal(404))
+				var body map[string]string
+				Expect(json.NewDecoder(resp.Body).Decode(&body)).To(Succeed())
+				Expect(body["error"]).To(Equal("post not found"))
+			}
+		})
+	})
+
+	Describe("React and RemoveReaction", func() {
+		It("should count reactions by type", func() {
+			// Given: Interaction service setup
+			interactionService, _, _, postRepo := createInteractionService()
+			author := createTestUser(sharedContainers.DB, "reactauthor", "reactauthor@example.com")
+			fan := createTestUser(sharedContainers.DB, "reactfan", "reactfan@example.com")
+			post := createTestPost(sharedContainers.DB, author.ID, "Reacted Post", "Caption")
+
+			// When: One user likes the post and another reacts with fire
+			_, _, err := interactionService.AddLike(author.ID, post.ID)
+			Expect(err).NotTo(HaveOccurred())
+			counts, err := interactionService.React(fan.ID, post.ID, domain.ReactionFire)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Then: Both count towards likes_count and are broken down by type
+			Expect(counts.LikesCount).To(Equal(2))
+			Expect(counts.ReactionCounts).To(Equal(domain.ReactionCounts{domain.ReactionLike: 1, domain.ReactionFire: 1}))
+			updatedPost, err := postRepo.FindByID(post.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updatedPost.LikesCount).To(Equal(2))
+			Expect(updatedPost.ReactionCounts).To(Equal(counts.ReactionCounts))
+		})
+
+		It("should replace a user's reaction instead of adding another", func() {
+			interactionService, _, _, postRepo := createInteractionService()
+			user := createTestUser(sharedContainers.DB, "reactswitcher", "reactswitcher@example.com")
+			post := createTestPost(sharedContainers.DB, user.ID, "Switched Reaction", "Caption")
+
+			_, err := interactionService.React(user.ID, post.ID, domain.ReactionHeart)
+			Expect(err).NotTo(HaveOccurred())
+			counts, err := interactionService.React(user.ID, post.ID, domain.ReactionLaugh)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(counts.LikesCount).To(Equal(1))
+			Expect(counts.ReactionCounts).To(Equal(domain.ReactionCounts{domain.ReactionLaugh: 1}))
+			states, err := postRepo.FindViewerStates(user.ID, []uint{post.ID})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(states).To(HaveLen(1))
+			Expect(states[post.ID].Reaction).To(Equal(domain.ReactionLaugh))
+		})
+
+		It("should remove a reaction of any type", func() {
+			interactionService, _, _, postRepo := createInteractionService()
+			user := createTestUser(sharedContainers.DB, "reactremover", "reactremover@example.com")
+			post := createTestPost(sharedContainers.DB, user.ID, "Removed Reaction", "Caption")
+
+			_, err := interactionService.React(user.ID, post.ID, domain.ReactionSad)
+			Expect(err).NotTo(HaveOccurred())
+			for i := 0; i < 2; i++ {
+				counts, err := interactionService.RemoveReaction(user.ID, post.ID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(counts.LikesCount).To(BeZero())
+				Expect(counts.ReactionCounts).To(BeEmpty())
+			}
+
+			updatedPost, err := postRepo.FindByID(post.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updatedPost.LikesCount).To(BeZero())
+			Expect(updatedPost.ReactionCounts).To(BeEmpty())
+		})
+
+		It("should reject unknown reactions", func() {
+			interactionService, _, _, _ := createInteractionService()
+			user := createTestUser(sharedContainers.DB, "reactbogus", "reactbogus@example.com")
+			post := createTestPost(sharedContainers.DB, user.ID, "Bogus Reaction", "Caption")
+
+			_, err := interactionService.React(user.ID, post.ID, "angry")
+
+			Expect(err).To(MatchError(service.ErrInvalidReaction))
+		})
+
+		It("should record reaction-aware events only for changes", func() {
+			interactionService, _, _, _ := createInteractionService()
+			user := createTestUser(sharedContainers.DB, "reactevents", "reactevents@example.com")
+			post := createTestPost(sharedContainers.DB, user.ID, "Reaction Events", "Caption")
+
+			interactionService.React(user.ID, post.ID, domain.ReactionWow)
+			interactionService.React(user.ID, post.ID, domain.ReactionWow)
+			interactionService.React(user.ID, post.ID, domain.ReactionFire)
+
+			outboxRepo := postgresRepo.NewOutboxRepository(sharedContainers.DB)
+			pending, err := outboxRepo.CountPending()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pending).To(Equal(2))
+
+			var payload []byte
+			err = sharedContainers.DB.QueryRow(`SELECT payload FROM outbox ORDER BY id DESC LIMIT 1`).Scan(&payload)
+			Expect(err).NotTo(HaveOccurred())
+			var event struct {
+				Type events.EventType           `json:"type"`
+				Data events.PostInteractionData `json:"data"`
+			}
+			Expect(json.Unmarshal(payload, &event)).To(Succeed())
+			Expect(event.Type).To(Equal(events.EventTypePostLiked))
+			Expect(event.Data.Reaction).To(Equal(domain.ReactionFire))
+			Expect(event.Data.PreviousReaction).To(Equal(domain.ReactionWow))
+			Expect(event.Data.Removed).To(BeFalse())
+			Expect(event.Data.ReactionCounts).To(Equal(map[string]int{domain.ReactionFire: 1}))
+
+			// When: The reaction is removed
+			_, err = interactionService.RemoveReaction(user.ID, post.ID)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Then: The event is flagged as a removal of the previous reaction
+			err = sharedContainers.DB.QueryRow(`SELECT payload FROM outbox ORDER BY id DESC LIMIT 1`).Scan(&payload)
+			Expect(err).NotTo(HaveOccurred())
+			event.Data = events.PostInteractionData{}
+			Expect(json.Unmarshal(payload, &event)).To(Succeed())
+			Expect(event.Type).To(Equal(events.EventTypePostLiked))
+			Expect(event.Data.Removed).To(BeTrue())
+			Expect(event.Data.Reaction).To(BeEmpty())
+			Expect(event.Data.PreviousReaction).To(Equal(domain.ReactionFire))
+		})
+
+		It("should react over PUT and show the caller's reaction on the post", func() {
+			// Given: Test app setup
+			app, _, cleanup := setupTestApp()
+			defer cleanup()
+
+			token := registerAndLogin(app, "putreactor", "putreactor@example.com", "pass123")
+			var userID uint
+			err := sharedContainers.DB.QueryRow(`SELECT id FROM users WHERE username = 'putreactor'`).Scan(&userID)
+			Expect(err).NotTo(HaveOccurred())
+			post := createTestPost(sharedContainers.DB, userID, "Put Reaction", "Caption")
+
+			// When: The user reacts with a heart
+			req := httptest.NewRequest("PUT", fmt.Sprintf("/api/posts/%d/reaction", post.ID), strings.NewReader(`{"reaction":"heart"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+			resp, err := app.Test(req, 2000)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(200))
+			var reaction dto.ReactionResponse
+			Expect(json.NewDecoder(resp.Body).Decode(&reaction)).To(Succeed())
+			Expect(reaction.LikesCount).To(Equal(1))
+			Expect(reaction.MyReaction).To(Equal(domain.ReactionHeart))
+
+			// Then: The post shows the breakdown and the caller's reaction
+			req = httptest.NewRequest("GET", fmt.Sprintf("/api/posts/%d", post.ID), nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			resp, err = app.Test(req, 2000)
+			Expect(err).NotTo(HaveOccurred())
+			var got dto.PostResponse
+			Expect(json.NewDecoder(resp.Body).Decode(&got)).To(Succeed())
+			Expect(got.ReactionCounts).To(Equal(domain.ReactionCounts{domain.ReactionHeart: 1}))
+			Expect(got.MyReaction).To(Equal(domain.ReactionHeart))
+		})
+
+		It("should report reactions to unknown posts as not found", func() {
+			// Given: Test app setup
+			app, _, cleanup := setupTestApp()
+			defer cleanup()
+			token := registerAndLogin(app, "ghostreactor", "ghostreactor@example.com", "pass123")
+
+			// When: Reacting to, and removing a reaction from, a post that does not exist
+			req := httptest.NewRequest("PUT", "/api/posts/99999/reaction", strings.NewReader(`{"reaction":"heart"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+			putResp, err := app.Test(req, 2000)
+			Expect(err).NotTo(HaveOccurred())
+
+			req = httptest.NewRequest("DELETE", "/api/posts/99999/reaction", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			deleteResp, err := app.Test(req, 2000)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Then: Both are not found
+			Expect(putResp.StatusCode).To(Equal(404))
+			Expect(deleteResp.StatusCode).To(Equal(404))
+		})
+	})
+
 	Describe("CommentPost", func() {
 		It("should comment on post successfully", func() {
 			// Given: Interaction service setup
@@ -162,7 +477,7 @@ var _ = Describe("InteractionService", func() {
 			Expect(err).NotTo(HaveOccurred())
 
 			// Verify comment was saved to database
-			comments, err := commentRepo.FindByPostID(post.ID)
+			comments, err := commentRepo.FindByPostID(post.ID, domain.CommentSortOldest, 100, nil)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(comments).To(HaveLen(1))
 			Expect(comments[0].UserID).To(Equal(user.ID))
@@ -190,7 +505,7 @@ var _ = Describe("InteractionService", func() {
 			Expect(err).NotTo(HaveOccurred())
 
 			// Verify comment was saved
-			comments, err := commentRepo.FindByPostID(post.ID)
+			comments, err := commentRepo.FindByPostID(post.ID, domain.CommentSortOldest, 100, nil)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(comments).To(HaveLen(1))
 			Expect(comments[0].Text).To(Equal(""))
@@ -224,7 +539,7 @@ var _ = Describe("InteractionService", func() {
 				Expect(err.Error()).To(ContainSubstring("too long"))
 			} else {
 				// If it succeeds, verify it was saved correctly
-				comments, err := commentRepo.FindByPostID(post.ID)
+				comments, err := commentRepo.FindByPostID(post.ID, domain.CommentSortOldest, 100, nil)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(comments).To(HaveLen(1))
 				Expect(comments[0].Text).To(Equal(longCommentText))
@@ -268,7 +583,7 @@ var _ = Describe("InteractionService", func() {
 			Expect(err3).NotTo(HaveOccurred())
 
 			// Verify all comments were saved
-			comments, err := commentRepo.FindByPostID(post.ID)
+			comments, err := commentRepo.FindByPostID(post.ID, domain.CommentSortOldest, 100, nil)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(comments).To(HaveLen(3))
 
@@ -279,3 +594,661 @@ var _ = Describe("InteractionService", func() {
 		})
 	})
 })
+
+var _ = Describe("Likers list", func() {
+	var (
+		interactionService *service.InteractionService
+		author             *domain.User
+		viewer             *domain.User
+		post               *domain.Post
+		likers             []*domain.User
+	)
+
+	BeforeEach(func() {
+		interactionService, _, _, _ = createInteractionService()
+		author = createTestUser(sharedContainers.DB, "likersauthor", "likersauthor@example.com")
+		viewer = createTestUser(sharedContainers.DB, "likersviewer", "likersviewer@example.com")
+		post = createTestPost(sharedContainers.DB, author.ID, "Liked by many", "Caption")
+
+		likers = nil
+		for i := 0; i < 3; i++ {
+			liker := createTestUser(sharedContainers.DB, fmt.Sprintf("liker%d", i), fmt.Sprintf("liker%d@example.com", i))
+			_, _, err := interactionService.AddLike(liker.ID, post.ID)
+			Expect(err).NotTo(HaveOccurred())
+			likers = append(likers, liker)
+		}
+		_, err := sharedContainers.DB.Exec(`INSERT INTO follows (follower_id, followee_id) VALUES ($1, $2)`, viewer.ID, likers[1].ID)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should page likers most recent first, marking those the viewer follows", func() {
+		// When: Listing two likers at a time
+		page, nextCursor, err := interactionService.GetLikers(post.ID, viewer.ID, 2, "")
+		Expect(err).NotTo(HaveOccurred())
+
+		// Then: The latest likers come first with their reaction
+		Expect(page).To(HaveLen(2))
+		Expect(page[0].Username).To(Equal("liker2"))
+		Expect(page[0].Reaction).To(Equal(domain.ReactionLike))
+		Expect(page[0].FollowedByMe).To(BeFalse())
+		Expect(page[1].Username).To(Equal("liker1"))
+		Expect(page[1].FollowedByMe).To(BeTrue())
+		Expect(nextCursor).NotTo(BeEmpty())
+
+		// Then: The next page holds the rest
+		page, nextCursor, err = interactionService.GetLikers(post.ID, viewer.ID, 2, nextCursor)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(page).To(HaveLen(1))
+		Expect(page[0].ID).To(Equal(likers[0].ID))
+		Expect(nextCursor).To(BeEmpty())
+	})
+
+	It("should hide the list from everyone but the author when they hide likers", func() {
+		// Given: The author hides their likers
+		userService := service.NewUserService(postgresRepo.NewUserRepository(sharedContainers.DB), zap.NewNop())
+		_, err := userService.UpdateSettings(author.ID, true)
+		Expect(err).NotTo(HaveOccurred())
+
+		// Then: Only the author can list the likers
+		_, _, err = interactionService.GetLikers(post.ID, viewer.ID, 20, "")
+		Expect(err).To(MatchError(service.ErrLikesHidden))
+		page, _, err := interactionService.GetLikers(post.ID, author.ID, 20, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(page).To(HaveLen(3))
+	})
+
+	It("should report unknown posts and cursors", func() {
+		_, _, err := interactionService.GetLikers(99999, viewer.ID, 20, "")
+		Expect(err).To(MatchError(service.ErrPostNotFound))
+
+		_, _, err = interactionService.GetLikers(post.ID, viewer.ID, 20, "not-a-cursor")
+		Expect(err).To(MatchError(service.ErrInvalidCursor))
+	})
+})
+
+var _ = Describe("Viewer state", func() {
+	It("should report what the viewer did with each post in one call", func() {
+		// Given: A viewer who reacted to one post and commented on another
+		interactionService, _, _, _ := createInteractionService()
+		author := createTestUser(sharedContainers.DB, "stateauthor", "stateauthor@example.com")
+		viewer := createTestUser(sharedContainers.DB, "stateviewer", "stateviewer@example.com")
+		reacted := createTestPost(sharedContainers.DB, author.ID, "Reacted", "Caption")
+		commented := createTestPost(sharedContainers.DB, author.ID, "Commented", "Caption")
+		untouched := createTestPost(sharedContainers.DB, author.ID, "Untouched", "Caption")
+
+		_, err := interactionService.React(viewer.ID, reacted.ID, domain.ReactionWow)
+		Expect(err).NotTo(HaveOccurred())
+		_, _, err = interactionService.CommentPost(viewer.ID, commented.ID, "Nice", viewer.Username)
+		Expect(err).NotTo(HaveOccurred())
+
+		// When: Looking up the viewer's state for the page
+		states, err := interactionService.ViewerStates(viewer.ID, []uint{reacted.ID, commented.ID, untouched.ID})
+
+		// Then: Each post has the viewer's own state
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*states[reacted.ID]).To(Equal(domain.PostViewerState{Reaction: domain.ReactionWow}))
+		Expect(*states[commented.ID]).To(Equal(domain.PostViewerState{Commented: true}))
+		Expect(*states[untouched.ID]).To(Equal(domain.PostViewerState{}))
+	})
+
+	It("should add each caller's state to the shared feed page", func() {
+		// Given: Test app setup
+		app, _, cleanup := setupTestApp()
+		defer cleanup()
+
+		liker := registerAndLogin(app, "feedliker", "feedliker@example.com", "pass123")
+		lurker := registerAndLogin(app, "feedlurker", "feedlurker@example.com", "pass123")
+		var likerID uint
+		err := sharedContainers.DB.QueryRow(`SELECT id FROM users WHERE username = 'feedliker'`).Scan(&likerID)
+		Expect(err).NotTo(HaveOccurred())
+		post := createTestPost(sharedContainers.DB, likerID, "Shared Page", "Caption")
+
+		req := httptest.NewRequest("PUT", fmt.Sprintf("/api/posts/%d/like", post.ID), nil)
+		req.Header.Set("Authorization", "Bearer "+liker)
+		resp, err := app.Test(req, 2000)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(200))
+
+		getPosts := func(path, token string) []*dto.PostResponse {
+			req := httptest.NewRequest("GET", path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			resp, err := app.Test(req, 2000)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(200))
+			var feed dto.FeedResponse
+			Expect(json.NewDecoder(resp.Body).Decode(&feed)).To(Succeed())
+			Expect(feed.Posts).To(HaveLen(1))
+			return feed.Posts
+		}
+
+		// When: Both users read the same, cached feed page
+		likerPosts := getPosts("/api/feed", liker)
+		lurkerPosts := getPosts("/api/feed", lurker)
+
+		// Then: Only the liker sees the post as liked
+		Expect(likerPosts[0].LikedByMe).To(BeTrue())
+		Expect(likerPosts[0].MyReaction).To(Equal(domain.ReactionLike))
+		Expect(lurkerPosts[0].LikedByMe).To(BeFalse())
+		Expect(lurkerPosts[0].MyReaction).To(BeEmpty())
+
+		// Then: The author's profile posts carry the caller's state too
+		profilePosts := getPosts(fmt.Sprintf("/api/users/%d/posts", likerID), liker)
+		Expect(profilePosts[0].LikedByMe).To(BeTrue())
+		Expect(profilePosts[0].CommentedByMe).To(BeFalse())
+		Expect(profilePosts[0].SavedByMe).To(BeFalse())
+	})
+})
+
+var _ = Describe("Comment replies", func() {
+	var (
+		interactionService *service.InteractionService
+		commentRepo        postgresRepo.CommentRepository
+		postRepo           postgresRepo.PostRepository
+		user               *domain.User
+		post               *domain.Post
+		root               *domain.Comment
+	)
+
+	BeforeEach(func() {
+		interactionService, _, commentRepo, postRepo = createInteractionService()
+		user = createTestUser(sharedContainers.DB, "threaduser", "thread@example.com")
+		post = createTestPost(sharedContainers.DB, user.ID, "Thread Post", "Caption")
+
+		_, _, err := interactionService.CommentPost(user.ID, post.ID, "Top-level", user.Username)
+		Expect(err).NotTo(HaveOccurred())
+		comments, err := commentRepo.FindByPostID(post.ID, domain.CommentSortOldest, 100, nil)
+		Expect(err).NotTo(HaveOccurred())
+		root = comments[0]
+	})
+
+	It("should list top-level comments with their reply counts", func() {
+		// When: Two replies are added to the comment
+		_, _, err := interactionService.ReplyToComment(user.ID, post.ID, root.ID, "First reply", user.Username)
+		Expect(err).NotTo(HaveOccurred())
+		_, commentsCount, err := interactionService.ReplyToComment(user.ID, post.ID, root.ID, "Second reply", user.Username)
+		Expect(err).NotTo(HaveOccurred())
+
+		// Then: Only the top-level comment is listed, with its replies counted
+		comments, _, err := interactionService.GetComments(post.ID, user.ID, "", 20, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(comments).To(HaveLen(1))
+		Expect(comments[0].ParentID).To(BeNil())
+		Expect(comments[0].ReplyCount).To(Equal(2))
+
+		// Then: Replies count towards the post's comments
+		Expect(commentsCount).To(Equal(3))
+		updatedPost, err := postRepo.FindByID(post.ID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(updatedPost.CommentsCount).To(Equal(3))
+	})
+
+	It("should attach replies to replies to the top-level comment", func() {
+		_, _, err := interactionService.ReplyToComment(user.ID, post.ID, root.ID, "Reply", user.Username)
+		Expect(err).NotTo(HaveOccurred())
+		replies, _, err := interactionService.GetReplies(post.ID, root.ID, user.ID, 10, "")
+		Expect(err).NotTo(HaveOccurred())
+
+		// When: Replying to the reply
+		_, _, err = interactionService.ReplyToComment(user.ID, post.ID, replies[0].ID, "Nested", user.Username)
+		Expect(err).NotTo(HaveOccurred())
+
+		// Then: Both replies are in the top-level comment's thread
+		replies, _, err = interactionService.GetReplies(post.ID, root.ID, user.ID, 10, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(replies).To(HaveLen(2))
+		Expect(*replies[1].ParentID).To(Equal(root.ID))
+		Expect(replies[1].Text).To(Equal("Nested"))
+	})
+
+	It("should paginate replies oldest first with a cursor", func() {
+		for i := 1; i <= 3; i++ {
+			_, _, err := interactionService.ReplyToComment(user.ID, post.ID, root.ID, fmt.Sprintf("Reply %d", i), user.Username)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		firstPage, cursor, err := interactionService.GetReplies(post.ID, root.ID, user.ID, 2, "")
+		Expect(err).NotTo(HaveOccurred())
+		secondPage, nextCursor, err := interactionService.GetReplies(post.ID, root.ID, user.ID, 2, cursor)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(firstPage).To(HaveLen(2))
+		Expect(firstPage[0].Text).To(Equal("Reply 1"))
+		Expect(cursor).NotTo(BeEmpty())
+		Expect(secondPage).To(HaveLen(1))
+		Expect(secondPage[0].Text).To(Equal("Reply 3"))
+		Expect(nextCursor).To(BeEmpty())
+
+		_, _, err = interactionService.GetReplies(post.ID, root.ID, user.ID, 2, "not-a-cursor")
+		Expect(err).To(MatchError(service.ErrInvalidCursor))
+	})
+
+	It("should reject parents that are not on the post", func() {
+		otherPost := createTestPost(sharedContainers.DB, user.ID, "Other Post", "Caption")
+
+		_, _, err := interactionService.ReplyToComment(user.ID, otherPost.ID, root.ID, "Wrong post", user.Username)
+		Expect(err).To(MatchError(service.ErrCommentNotFound))
+		_, _, err = interactionService.ReplyToComment(user.ID, post.ID, 99999, "Missing", user.Username)
+		Expect(err).To(MatchError(service.ErrCommentNotFound))
+		_, _, err = interactionService.GetReplies(otherPost.ID, root.ID, user.ID, 10, "")
+		Expect(err).To(MatchError(service.ErrCommentNotFound))
+	})
+
+	It("should include the parent in the post_commented event", func() {
+		ctx := context.Background()
+		logger, _ := zap.NewDevelopment()
+		outboxRepo := postgresRepo.NewOutboxRepository(sharedContainers.DB)
+		_, _, err := interactionService.ReplyToComment(user.ID, post.ID, root.ID, "Reply", user.Username)
+		Expect(err).NotTo(HaveOccurred())
+
+		// When: The outbox is relayed
+		bus := events.NewMemoryBus(10)
+		relay := service.NewOutboxRelay(outboxRepo, events.NewPublisher(bus, logger), 10, 20, 0, time.Hour, logger)
+		_, err = relay.RelayPending(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		// Then: The reply's event names its parent; the top-level comment's does not
+		published, err := bus.Since(ctx, "0-0", 10)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(published).To(HaveLen(2))
+
+		Expect(eventCommentParentID(published[0])).To(BeNil())
+		Expect(eventCommentParentID(published[1])).To(Equal(&root.ID))
+	})
+})
+
+var _ = Describe("Comment editing and moderation", func() {
+	var (
+		interactionService *service.InteractionService
+		commentRepo        postgresRepo.CommentRepository
+		postRepo           postgresRepo.PostRepository
+		owner              *domain.User
+		commenter          *domain.User
+		post               *domain.Post
+		comment            *domain.Comment
+	)
+
+	BeforeEach(func() {
+		interactionService, _, commentRepo, postRepo = createInteractionService()
+		owner = createTestUser(sharedContainers.DB, "postowner", "postowner@example.com")
+		commenter = createTestUser(sharedContainers.DB, "commenter", "commenter@example.com")
+		post = createTestPost(sharedContainers.DB, owner.ID, "Moderated Post", "Caption")
+
+		_, _, err := interactionService.CommentPost(commenter.ID, post.ID, "Original", commenter.Username)
+		Expect(err).NotTo(HaveOccurred())
+		comments, err := commentRepo.FindByPostID(post.ID, domain.CommentSortOldest, 100, nil)
+		Expect(err).NotTo(HaveOccurred())
+		comment = comments[0]
+	})
+
+	It("should let the author edit their comment", func() {
+		// When: The author edits the comment
+		updated, err := interactionService.UpdateComment(commenter.ID, post.ID, comment.ID, "Edited")
+
+		// Then: The new text is saved and marked edited
+		Expect(err).NotTo(HaveOccurred())
+		Expect(updated.Text).To(Equal("Edited"))
+		Expect(updated.EditedAt).NotTo(BeNil())
+
+		saved, err := commentRepo.FindByID(comment.ID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(saved.Text).To(Equal("Edited"))
+		Expect(saved.EditedAt).NotTo(BeNil())
+	})
+
+	It("should not let anyone else edit the comment, including the post owner", func() {
+		_, err := interactionService.UpdateComment(owner.ID, post.ID, comment.ID, "Hijacked")
+		Expect(err).To(MatchError(service.ErrCommentForbidden))
+
+		_, err = interactionService.UpdateComment(commenter.ID, post.ID, 99999, "Missing")
+		Expect(err).To(MatchError(service.ErrCommentNotFound))
+	})
+
+	It("should let the author delete their comment and decrement the count", func() {
+		_, commentsCount, err := interactionService.DeleteComment(commenter.ID, post.ID, comment.ID)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(commentsCount).To(BeZero())
+		updatedPost, err := postRepo.FindByID(post.ID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(updatedPost.CommentsCount).To(BeZero())
+	})
+
+	It("should let the post owner delete a comment with its replies", func() {
+		// Given: Two replies in the comment's thread
+		for i := 1; i <= 2; i++ {
+			_, _, err := interactionService.ReplyToComment(commenter.ID, post.ID, comment.ID, fmt.Sprintf("Reply %d", i), commenter.Username)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		// When: The post owner deletes the comment
+		_, commentsCount, err := interactionService.DeleteComment(owner.ID, post.ID, comment.ID)
+
+		// Then: The thread is gone and no longer counted
+		Expect(err).NotTo(HaveOccurred())
+		Expect(commentsCount).To(BeZero())
+		comments, err := commentRepo.FindByPostID(post.ID, domain.CommentSortOldest, 100, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(comments).To(BeEmpty())
+	})
+
+	It("should report a comment deleted by a concurrent request as not found", func() {
+		// Given: The comment is deleted once
+		_, _, err := interactionService.DeleteComment(commenter.ID, post.ID, comment.ID)
+		Expect(err).NotTo(HaveOccurred())
+
+		// When: A request that loaded it before the delete commits deletes or edits it
+		noEvent := func(likesCount, commentsCount int) (*domain.OutboxMessage, error) {
+			Fail("no event should be recorded")
+			return nil, nil
+		}
+		_, _, deleteErr := commentRepo.DeleteWithOutbox(comment, noEvent)
+		_, _, updateErr := commentRepo.UpdateWithOutbox(comment, noEvent, nil)
+
+		// Then: Both are not found rather than failures
+		Expect(deleteErr).To(MatchError(sql.ErrNoRows))
+		Expect(updateErr).To(MatchError(sql.ErrNoRows))
+		_, _, err = interactionService.DeleteComment(commenter.ID, post.ID, comment.ID)
+		Expect(err).To(MatchError(service.ErrCommentNotFound))
+
+		updatedPost, err := postRepo.FindByID(post.ID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(updatedPost.CommentsCount).To(BeZero())
+	})
+
+	It("should not let other users delete the comment", func() {
+		stranger := createTestUser(sharedContainers.DB, "stranger", "stranger@example.com")
+
+		_, _, err := interactionService.DeleteComment(stranger.ID, post.ID, comment.ID)
+
+		Expect(err).To(MatchError(service.ErrCommentForbidden))
+		updatedPost, err := postRepo.FindByID(post.ID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(updatedPost.CommentsCount).To(Equal(1))
+	})
+
+	It("should publish comment_updated and comment_deleted events", func() {
+		ctx := context.Background()
+		logger, _ := zap.NewDevelopment()
+		_, err := interactionService.UpdateComment(commenter.ID, post.ID, comment.ID, "Edited")
+		Expect(err).NotTo(HaveOccurred())
+		_, _, err = interactionService.DeleteComment(owner.ID, post.ID, comment.ID)
+		Expect(err).NotTo(HaveOccurred())
+
+		bus := events.NewMemoryBus(10)
+		relay := service.NewOutboxRelay(postgresRepo.NewOutboxRepository(sharedContainers.DB), events.NewPublisher(bus, logger), 10, 20, 0, time.Hour, logger)
+		_, err = relay.RelayPending(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		// The comment and the owner's notification come first
+		published, err := bus.Since(ctx, "0-0", 10)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(published).To(HaveLen(4))
+		Expect(published[1].Type).To(Equal(events.EventTypeNotification))
+		Expect(published[2].Type).To(Equal(events.EventTypeCommentUpdated))
+		Expect(published[2].TriggeredByUserID).To(Equal(commenter.ID))
+		Expect(published[3].Type).To(Equal(events.EventTypeCommentDeleted))
+		Expect(published[3].TriggeredByUserID).To(Equal(owner.ID))
+		Expect(published[3].PostID).To(Equal(post.ID))
+	})
+})
+
+var _ = Describe("Comment likes", func() {
+	var (
+		interactionService *service.InteractionService
+		author             *domain.User
+		liker              *domain.User
+		post               *domain.Post
+		comment            *domain.Comment
+	)
+
+	BeforeEach(func() {
+		var commentRepo postgresRepo.CommentRepository
+		interactionService, _, commentRepo, _ = createInteractionService()
+		author = createTestUser(sharedContainers.DB, "commentauthor", "commentauthor@example.com")
+		liker = createTestUser(sharedContainers.DB, "commentliker", "commentliker@example.com")
+		post = createTestPost(sharedContainers.DB, author.ID, "Liked Comments", "Caption")
+
+		_, _, err := interactionService.CommentPost(author.ID, post.ID, "Like me", author.Username)
+		Expect(err).NotTo(HaveOccurred())
+		comments, err := commentRepo.FindByPostID(post.ID, domain.CommentSortOldest, 100, nil)
+		Expect(err).NotTo(HaveOccurred())
+		comment = comments[0]
+	})
+
+	It("should count likes and report them to each viewer", func() {
+		// When: The comment is liked twice by the same user
+		likesCount, err := interactionService.LikeComment(liker.ID, post.ID, comment.ID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(likesCount).To(Equal(1))
+		likesCount, err = interactionService.LikeComment(liker.ID, post.ID, comment.ID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(likesCount).To(Equal(1))
+
+		// Then: The liker sees it liked; the author sees the count only
+		comments, _, err := interactionService.GetComments(post.ID, liker.ID, "", 20, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(comments[0].LikesCount).To(Equal(1))
+		Expect(comments[0].LikedByMe).To(BeTrue())
+
+		comments, _, err = interactionService.GetComments(post.ID, author.ID, "", 20, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(comments[0].LikesCount).To(Equal(1))
+		Expect(comments[0].LikedByMe).To(BeFalse())
+	})
+
+	It("should unlike a comment once", func() {
+		_, err := interactionService.LikeComment(liker.ID, post.ID, comment.ID)
+		Expect(err).NotTo(HaveOccurred())
+
+		likesCount, err := interactionService.UnlikeComment(liker.ID, post.ID, comment.ID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(likesCount).To(BeZero())
+		likesCount, err = interactionService.UnlikeComment(liker.ID, post.ID, comment.ID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(likesCount).To(BeZero())
+
+		comments, _, err := interactionService.GetComments(post.ID, liker.ID, "", 20, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(comments[0].LikedByMe).To(BeFalse())
+	})
+
+	It("should reject comments that are not on the post", func() {
+		otherPost := createTestPost(sharedContainers.DB, author.ID, "Other", "Caption")
+
+		_, err := interactionService.LikeComment(liker.ID, otherPost.ID, comment.ID)
+
+		Expect(err).To(MatchError(service.ErrCommentNotFound))
+	})
+
+	It("should publish a comment_liked event only when the like changes", func() {
+		ctx := context.Background()
+		logger, _ := zap.NewDevelopment()
+		interactionService.LikeComment(liker.ID, post.ID, comment.ID)
+		interactionService.LikeComment(liker.ID, post.ID, comment.ID)
+		interactionService.UnlikeComment(liker.ID, post.ID, comment.ID)
+
+		bus := events.NewMemoryBus(10)
+		relay := service.NewOutboxRelay(postgresRepo.NewOutboxRepository(sharedContainers.DB), events.NewPublisher(bus, logger), 10, 20, 0, time.Hour, logger)
+		_, err := relay.RelayPending(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		// Then: The comment, one like and one unlike
+		published, err := bus.Since(ctx, "0-0", 10)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(published).To(HaveLen(3))
+		Expect(published[1].Type).To(Equal(events.EventTypeCommentLiked))
+		Expect(published[1].PostID).To(Equal(post.ID))
+		Expect(published[2].Type).To(Equal(events.EventTypeCommentLiked))
+		Expect(published[2].TriggeredByUserID).To(Equal(liker.ID))
+	})
+})
+
+var _ = Describe("Comment listing", func() {
+	var (
+		interactionService *service.InteractionService
+		user               *domain.User
+		post               *domain.Post
+	)
+
+	// collect pages through the post's comments and returns their texts in the order served
+	collect := func(sort string, limit int) []string {
+		var texts []string
+		cursor := ""
+		for page := 0; page < 10; page++ {
+			comments, nextCursor, err := interactionService.GetComments(post.ID, user.ID, sort, limit, cursor)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(comments)).To(BeNumerically("<=", limit))
+			for _, comment := range comments {
+				texts = append(texts, comment.Text)
+			}
+			if nextCursor == "" {
+				return texts
+			}
+			cursor = nextCursor
+		}
+		Fail("comments did not terminate")
+		return nil
+	}
+
+	BeforeEach(func() {
+		var commentRepo postgresRepo.CommentRepository
+		interactionService, _, commentRepo, _ = createInteractionService()
+		user = createTestUser(sharedContainers.DB, "lister", "lister@example.com")
+		post = createTestPost(sharedContainers.DB, user.ID, "Busy Post", "Caption")
+
+		// Given: Five comments; the second and fourth are liked, the fourth most
+		for i := 1; i <= 5; i++ {
+			_, _, err := interactionService.CommentPost(user.ID, post.ID, fmt.Sprintf("Comment %d", i), user.Username)
+			Expect(err).NotTo(HaveOccurred())
+		}
+		comments, err := commentRepo.FindByPostID(post.ID, domain.CommentSortOldest, 100, nil)
+		Expect(err).NotTo(HaveOccurred())
+		for i := 1; i <= 2; i++ {
+			liker := createTestUser(sharedContainers.DB, fmt.Sprintf("fan%d", i), fmt.Sprintf("fan%d@example.com", i))
+			_, err := interactionService.LikeComment(liker.ID, post.ID, comments[3].ID)
+			Expect(err).NotTo(HaveOccurred())
+		}
+		_, err = interactionService.LikeComment(user.ID, post.ID, comments[1].ID)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should page oldest first by default", func() {
+		Expect(collect("", 2)).To(Equal([]string{"Comment 1", "Comment 2", "Comment 3", "Comment 4", "Comment 5"}))
+	})
+
+	It("should page newest first", func() {
+		Expect(collect(domain.CommentSortNewest, 2)).To(Equal([]string{"Comment 5", "Comment 4", "Comment 3", "Comment 2", "Comment 1"}))
+	})
+
+	It("should page most liked first, newest first among ties", func() {
+		Expect(collect(domain.CommentSortTop, 2)).To(Equal([]string{"Comment 4", "Comment 2", "Comment 5", "Comment 3", "Comment 1"}))
+	})
+
+	It("should reject unknown sorts and mismatched cursors", func() {
+		_, _, err := interactionService.GetComments(post.ID, user.ID, "popular", 2, "")
+		Expect(err).To(MatchError(service.ErrInvalidSort))
+
+		_, cursor, err := interactionService.GetComments(post.ID, user.ID, domain.CommentSortOldest, 2, "")
+		Expect(err).NotTo(HaveOccurred())
+		_, _, err = interactionService.GetComments(post.ID, user.ID, domain.CommentSortTop, 2, cursor)
+		Expect(err).To(MatchError(service.ErrInvalidCursor))
+	})
+})
+
+var _ = Describe("Comment replies API", func() {
+	It("should reply to, like, edit and delete comments", func() {
+		// Given: Test app setup
+		app, _, cleanup := setupTestApp()
+		defer cleanup()
+
+		// Given: A post with a comment
+		token := registerAndLogin(app, "replier", "replier@example.com", "pass123")
+		var userID uint
+		err := sharedContainers.DB.QueryRow(`SELECT id FROM users WHERE username = 'replier'`).Scan(&userID)
+		Expect(err).NotTo(HaveOccurred())
+		post := createTestPost(sharedContainers.DB, userID, "Threads", "Caption")
+
+		comment := func(body string) int {
+			req := httptest.NewRequest("POST", fmt.Sprintf("/api/posts/%d/comment", post.ID), strings.NewReader(body))
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req, 2000)
+			Expect(err).NotTo(HaveOccurred())
+			return resp.StatusCode
+		}
+		get := func(path string, result interface{}) int {
+			req := httptest.NewRequest("GET", path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			resp, err := app.Test(req, 2000)
+			Expect(err).NotTo(HaveOccurred())
+			if result != nil {
+				Expect(json.NewDecoder(resp.Body).Decode(result)).To(Succeed())
+			}
+			return resp.StatusCode
+		}
+
+		Expect(comment(`{"content": "Top-level"}`)).To(Equal(200))
+		var list dto.CommentListResponse
+		Expect(get(fmt.Sprintf("/api/posts/%d/comments", post.ID), &list)).To(Equal(200))
+		comments := list.Comments
+		Expect(comments).To(HaveLen(1))
+
+		// When: Replying to it
+		Expect(comment(fmt.Sprintf(`{"content": "Reply", "parent_id": %d}`, comments[0].ID))).To(Equal(200))
+
+		// Then: The reply is counted on the comment and listed in its thread
+		Expect(get(fmt.Sprintf("/api/posts/%d/comments", post.ID), &list)).To(Equal(200))
+		comments = list.Comments
+		Expect(comments).To(HaveLen(1))
+		Expect(comments[0].ReplyCount).To(Equal(1))
+
+		var replies dto.CommentListResponse
+		Expect(get(fmt.Sprintf("/api/posts/%d/comments/%d/replies", post.ID, comments[0].ID), &replies)).To(Equal(200))
+		Expect(replies.Comments).To(HaveLen(1))
+		Expect(replies.Comments[0].Text).To(Equal("Reply"))
+		Expect(replies.HasMore).To(BeFalse())
+
+		// Then: Unknown parents are not found
+		Expect(comment(`{"content": "Orphan", "parent_id": 99999}`)).To(Equal(404))
+		Expect(get(fmt.Sprintf("/api/posts/%d/comments/99999/replies", post.ID), nil)).To(Equal(404))
+
+		// When: Liking, editing and then deleting the top-level comment
+		send := func(method, path, body string) int {
+			req := httptest.NewRequest(method, path, strings.NewReader(body))
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req, 2000)
+			Expect(err).NotTo(HaveOccurred())
+			return resp.StatusCode
+		}
+		commentPath := fmt.Sprintf("/api/posts/%d/comments/%d", post.ID, comments[0].ID)
+		Expect(send("POST", commentPath+"/like", "")).To(Equal(200))
+		Expect(get(fmt.Sprintf("/api/posts/%d/comments", post.ID), &list)).To(Equal(200))
+		comments = list.Comments
+		Expect(comments[0].LikesCount).To(Equal(1))
+		Expect(comments[0].LikedByMe).To(BeTrue())
+		Expect(send("DELETE", commentPath+"/like", "")).To(Equal(200))
+		Expect(send("PUT", commentPath, `{"content": "Edited"}`)).To(Equal(200))
+		Expect(send("DELETE", commentPath, "")).To(Equal(200))
+
+		// Then: The thread is gone
+		Expect(get(fmt.Sprintf("/api/posts/%d/comments", post.ID), &list)).To(Equal(200))
+		comments = list.Comments
+		Expect(comments).To(BeEmpty())
+		Expect(send("DELETE", commentPath, "")).To(Equal(404))
+	})
+})
+
+// eventCommentParentID returns the parent_id of a post_commented event's comment as clients see it
+func eventCommentParentID(event events.Event) *uint {
+	payload, err := json.Marshal(event.Data)
+	Expect(err).NotTo(HaveOccurred())
+
+	var data struct {
+		Comment struct {
+			ParentID *uint `json:"parent_id"`
+		} `json:"comment"`
+	}
+	Expect(json.Unmarshal(payload, &data)).To(Succeed())
+	return data.Comment.ParentID
+}
@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+
+	"github.com/rodolfodpk/instagrano/internal/domain"
+	"github.com/rodolfodpk/instagrano/internal/dto"
+	"github.com/rodolfodpk/instagrano/internal/events"
+	postgresRepo "github.com/rodolfodpk/instagrano/internal/repository/postgres"
+	"github.com/rodolfodpk/instagrano/internal/service"
+)
+
+// mentionEvents returns the user_mentioned events recorded in the outbox, oldest first
+func mentionEvents() []events.Event {
+	rows, err := sharedContainers.DB.Query(`SELECT payload FROM outbox WHERE event_type = $1 ORDER BY id`, string(events.EventTypeUserMentioned))
+	Expect(err).NotTo(HaveOccurred())
+	defer rows.Close()
+
+	var recorded []events.Event
+	for rows.Next() {
+		var payload []byte
+		Expect(rows.Scan(&payload)).To(Succeed())
+		var event events.Event
+		Expect(json.Unmarshal(payload, &event)).To(Succeed())
+		recorded = append(recorded, event)
+	}
+	Expect(rows.Err()).NotTo(HaveOccurred())
+	return recorded
+}
+
+var _ = Describe("Mentions", func() {
+	Describe("ParseMentions", func() {
+		It("should locate mentions in characters", func() {
+			Expect(domain.ParseMentions("Olá @ana.b, and @joão_2.")).To(Equal([]domain.Mention{
+				{Username: "ana.b", Offset: 4, Length: 6},
+				{Username: "joão_2", Offset: 16, Length: 7},
+			}))
+		})
+
+		It("should ignore email addresses and lone @", func() {
+			Expect(domain.ParseMentions("mail ana@example.com @ or @@")).To(BeEmpty())
+		})
+	})
+
+	Describe("Service", func() {
+		var (
+			postService        *service.PostService
+			interactionService *service.InteractionService
+			postRepo           postgresRepo.PostRepository
+			author, bob, carol *domain.User
+		)
+
+		BeforeEach(func() {
+			logger := zap.NewNop()
+			postRepo = postgresRepo.NewPostRepository(sharedContainers.DB)
+			mentionService := service.NewMentionService(
+				postgresRepo.NewUserRepository(sharedContainers.DB),
+				postgresRepo.NewMentionRepository(sharedContainers.DB),
+				logger)
+			postService = service.NewPostService(postRepo, NewMockMediaStorage(), sharedContainers.Cache, 5*time.Minute, nil, nil, mentionService)
+			interactionService = service.NewInteractionService(
+				postgresRepo.NewLikeRepository(sharedContainers.DB),
+				postgresRepo.NewCommentRepository(sharedContainers.DB),
+				postRepo, sharedContainers.Cache, nil, mentionService, logger)
+
+			author = createTestUser(sharedContainers.DB, "author", "author@example.com")
+			bob = createTestUser(sharedContainers.DB, "bob", "bob@example.com")
+			carol = createTestUser(sharedContainers.DB, "carol", "carol@example.com")
+		})
+
+		It("should resolve caption mentions and tell each mentioned user once", func() {
+			// When: A caption mentions bob twice, an unknown user and the author
+			post, err := postService.CreatePostFromURL(author.ID, "Thanks", "Thanks @bob and @nobody, cc @bob @author", "https://example.com/a.jpg")
+			Expect(err).NotTo(HaveOccurred())
+
+			// Then: Known users are mentioned where they appear
+			expected := []domain.Mention{
+				{UserID: bob.ID, Username: "bob", Offset: 7, Length: 4},
+				{UserID: bob.ID, Username: "bob", Offset: 28, Length: 4},
+				{UserID: author.ID, Username: "author", Offset: 33, Length: 7},
+			}
+			Expect(post.Mentions).To(Equal(expected))
+
+			savedPost, err := postRepo.FindByID(post.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(savedPost.Mentions).To(Equal(expected))
+			Expect(dto.ToPostResponse(savedPost).Mentions).To(Equal(expected))
+
+			// Then: Only bob is told, privately
+			recorded := mentionEvents()
+			Expect(recorded).To(HaveLen(1))
+			Expect(recorded[0].RecipientUserID).To(Equal(bob.ID))
+			Expect(recorded[0].TriggeredByUserID).To(Equal(author.ID))
+			Expect(recorded[0].PostID).To(Equal(post.ID))
+		})
+
+		It("should only tell users newly mentioned by an edit", func() {
+			// Given: A post mentioning bob
+			post, err := postService.CreatePostFromURL(author.ID, "Team", "With @bob", "https://example.com/a.jpg")
+			Expect(err).NotTo(HaveOccurred())
+
+			// When: The caption is edited to mention carol too
+			caption := "With @carol and @bob"
+			updated, err := postService.UpdatePost(post.ID, author.ID, nil, &caption, post.UpdatedAt)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Then: The offsets follow the new caption and only carol is told
+			Expect(updated.Mentions).To(Equal([]domain.Mention{
+				{UserID: carol.ID, Username: "carol", Offset: 5, Length: 6},
+				{UserID: bob.ID, Username: "bob", Offset: 16, Length: 4},
+			}))
+			recorded := mentionEvents()
+			Expect(recorded).To(HaveLen(2))
+			Expect(recorded[1].RecipientUserID).To(Equal(carol.ID))
+		})
+
+		It("should resolve mentions in comments", func() {
+			// Given: A post by the author
+			post := createTestPost(sharedContainers.DB, author.ID, "Commented", "Caption")
+
+			// When: bob mentions carol in a comment
+			_, _, err := interactionService.CommentPost(bob.ID, post.ID, "@carol look", bob.Username)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Then: The comment lists the mention and carol is told about the comment
+			comments, _, err := interactionService.GetComments(post.ID, author.ID, domain.CommentSortOldest, 10, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(comments).To(HaveLen(1))
+			Expect(comments[0].Mentions).To(Equal([]domain.Mention{
+				{UserID: carol.ID, Username: "carol", Offset: 0, Length: 6},
+			}))
+
+			recorded := mentionEvents()
+			Expect(recorded).To(HaveLen(1))
+			Expect(recorded[0].RecipientUserID).To(Equal(carol.ID))
+
+			data, err := json.Marshal(recorded[0].Data)
+			Expect(err).NotTo(HaveOccurred())
+			var mentioned events.UserMentionedData
+			Expect(json.Unmarshal(data, &mentioned)).To(Succeed())
+			Expect(mentioned.CommentID).To(HaveValue(Equal(comments[0].ID)))
+			Expect(mentioned.Text).To(Equal("@carol look"))
+		})
+
+		It("should save mentions with the edit that makes them", func() {
+			// Given: A post mentioning bob, already edited once
+			post, err := postService.CreatePostFromURL(author.ID, "Team", "With @bob", "https://example.com/a.jpg")
+			Expect(err).NotTo(HaveOccurred())
+			caption := "With @bob again"
+			_, err = postService.UpdatePost(post.ID, author.ID, nil, &caption, post.UpdatedAt)
+			Expect(err).NotTo(HaveOccurred())
+
+			// When: A stale edit mentions carol
+			caption = "With @carol"
+			_, err = postService.UpdatePost(post.ID, author.ID, nil, &caption, post.UpdatedAt)
+			Expect(err).To(MatchError(service.ErrPostConflict))
+
+			// Then: The mentions still match the saved caption and carol is not told
+			saved, err := postRepo.FindByID(post.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(saved.Mentions).To(Equal([]domain.Mention{
+				{UserID: bob.ID, Username: "bob", Offset: 5, Length: 4},
+			}))
+			Expect(mentionEvents()).To(HaveLen(1))
+
+			// When: bob edits a comment to mention carol
+			_, _, err = interactionService.CommentPost(bob.ID, post.ID, "Nice", bob.Username)
+			Expect(err).NotTo(HaveOccurred())
+			var commentID uint
+			Expect(sharedContainers.DB.QueryRow(`SELECT id FROM comments WHERE post_id = $1`, post.ID).Scan(&commentID)).To(Succeed())
+			edited, err := interactionService.UpdateComment(bob.ID, post.ID, commentID, "Nice @carol")
+			Expect(err).NotTo(HaveOccurred())
+
+			// Then: The edited comment lists the mention and carol is told
+			Expect(edited.Mentions).To(Equal([]domain.Mention{
+				{UserID: carol.ID, Username: "carol", Offset: 5, Length: 6},
+			}))
+			recorded := mentionEvents()
+			Expect(recorded).To(HaveLen(2))
+			Expect(recorded[1].RecipientUserID).To(Equal(carol.ID))
+		})
+	})
+})
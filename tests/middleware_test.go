@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"context"
 	"net/http/httptest"
 
 	"github.com/gofiber/fiber/v2"
@@ -137,7 +138,7 @@ var _ = Describe("AuthRequired", func() {
 	It("should allow requests with valid JWT token", func() {
 		// Given: A Fiber app with auth middleware
 		app := fiber.New()
-		app.Use(middleware.JWT("test-secret"))
+		app.Use(middleware.JWT("test-secret", createAuthService()))
 
 		// Add a protected route
 		app.Get("/protected", func(c *fiber.Ctx) error {
@@ -164,7 +165,7 @@ var _ = Describe("AuthRequired", func() {
 	It("should reject requests without JWT token", func() {
 		// Given: A Fiber app with auth middleware
 		app := fiber.New()
-		app.Use(middleware.JWT("test-secret"))
+		app.Use(middleware.JWT("test-secret", createAuthService()))
 
 		// Add a protected route
 		app.Get("/protected", func(c *fiber.Ctx) error {
@@ -183,7 +184,7 @@ var _ = Describe("AuthRequired", func() {
 	It("should reject requests with invalid JWT token", func() {
 		// Given: A Fiber app with auth middleware
 		app := fiber.New()
-		app.Use(middleware.JWT("test-secret"))
+		app.Use(middleware.JWT("test-secret", createAuthService()))
 
 		// Add a protected route
 		app.Get("/protected", func(c *fiber.Ctx) error {
@@ -203,7 +204,7 @@ var _ = Describe("AuthRequired", func() {
 	It("should reject requests with malformed Authorization header", func() {
 		// Given: A Fiber app with auth middleware
 		app := fiber.New()
-		app.Use(middleware.JWT("test-secret"))
+		app.Use(middleware.JWT("test-secret", createAuthService()))
 
 		// Add a protected route
 		app.Get("/protected", func(c *fiber.Ctx) error {
@@ -223,7 +224,7 @@ var _ = Describe("AuthRequired", func() {
 	It("should extract user ID from valid token", func() {
 		// Given: A Fiber app with auth middleware
 		app := fiber.New()
-		app.Use(middleware.JWT("test-secret"))
+		app.Use(middleware.JWT("test-secret", createAuthService()))
 
 		// Add a protected route that returns user ID
 		app.Get("/protected", func(c *fiber.Ctx) error {
@@ -247,4 +248,33 @@ var _ = Describe("AuthRequired", func() {
 		Expect(err).NotTo(HaveOccurred())
 		Expect(resp.StatusCode).To(Equal(200))
 	})
+
+	It("should reject requests with a revoked token", func() {
+		// Given: A Fiber app with auth middleware
+		authService := createAuthService()
+		app := fiber.New()
+		app.Use(middleware.JWT("test-secret", authService))
+
+		// Add a protected route
+		app.Get("/protected", func(c *fiber.Ctx) error {
+			return c.JSON(fiber.Map{"message": "protected"})
+		})
+
+		// Given: A token that has been logged out
+		user := createTestUser(sharedContainers.DB, "revokeduser", "revoked@example.com")
+		token, err := createTestJWT(user.ID)
+		Expect(err).NotTo(HaveOccurred())
+		claims, err := authService.ParseAccessToken(token)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(authService.Logout(context.Background(), claims)).To(Succeed())
+
+		// When: Make request with the revoked token
+		req := httptest.NewRequest("GET", "/protected", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := app.Test(req)
+
+		// Then: Should reject access
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(401))
+	})
 })
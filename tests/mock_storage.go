@@ -5,11 +5,14 @@ import (
 	"io"
 	"net/url"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 )
 
 // MockMediaStorage implements s3.MediaStorage interface for testing
 type MockMediaStorage struct {
+	mu    sync.Mutex // Posts upload their media concurrently
 	files map[string][]byte
 }
 
@@ -28,11 +31,18 @@ func (m *MockMediaStorage) Upload(file io.Reader, filename, contentType string)
 		return "", fmt.Errorf("failed to read file: %w", err)
 	}
 
+	// Simulate a failed upload for files named to fail
+	if strings.HasPrefix(filename, "fail") {
+		return "", fmt.Errorf("failed to upload %s", filename)
+	}
+
 	// Generate a mock S3 key
 	key := fmt.Sprintf("mock-s3/%s", filename)
 
 	// Store in memory
+	m.mu.Lock()
 	m.files[key] = content
+	m.mu.Unlock()
 
 	return key, nil
 }
@@ -44,10 +54,20 @@ func (m *MockMediaStorage) GetURL(key string) string {
 
 // GetFile returns the stored file content (for testing)
 func (m *MockMediaStorage) GetFile(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	content, exists := m.files[key]
 	return content, exists
 }
 
+// Delete removes a stored file
+func (m *MockMediaStorage) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.files, key)
+	return nil
+}
+
 // UploadFromURL simulates downloading media from a URL without making real HTTP requests
 func (m *MockMediaStorage) UploadFromURL(urlStr string) (string, string, error) {
 	// Validate URL format
@@ -57,9 +77,11 @@ func (m *MockMediaStorage) UploadFromURL(urlStr string) (string, string, error)
 	}
 
 	// Generate mock key and content (no real HTTP request)
-	key := fmt.Sprintf("mock-s3/url-%d", time.Now().Unix())
+	key := fmt.Sprintf("mock-s3/url-%d-%s", time.Now().UnixNano(), filepath.Base(parsedURL.Path))
 	mockContent := []byte("mock-image-data")
+	m.mu.Lock()
 	m.files[key] = mockContent
+	m.mu.Unlock()
 
 	contentType := "image/jpeg"
 	if filepath.Ext(urlStr) == ".png" {
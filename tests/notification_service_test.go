@@ -0,0 +1,244 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+
+	"github.com/rodolfodpk/instagrano/internal/domain"
+	"github.com/rodolfodpk/instagrano/internal/events"
+	postgresRepo "github.com/rodolfodpk/instagrano/internal/repository/postgres"
+	"github.com/rodolfodpk/instagrano/internal/service"
+)
+
+var _ = Describe("NotificationService", func() {
+	var (
+		logger              *zap.Logger
+		bus                 *events.MemoryBus
+		notificationService *service.NotificationService
+		interactionService  *service.InteractionService
+		author              *domain.User
+		post                *domain.Post
+	)
+
+	BeforeEach(func() {
+		logger, _ = zap.NewDevelopment()
+		bus = events.NewMemoryBus(100)
+		notificationService = service.NewNotificationService(postgresRepo.NewNotificationRepository(sharedContainers.DB), logger)
+		interactionService = service.NewInteractionService(
+			postgresRepo.NewLikeRepository(sharedContainers.DB),
+			postgresRepo.NewCommentRepository(sharedContainers.DB),
+			postgresRepo.NewPostRepository(sharedContainers.DB),
+			sharedContainers.Cache, notificationService, nil, logger)
+
+		author = createTestUser(sharedContainers.DB, "author", "author@example.com")
+		post = createTestPost(sharedContainers.DB, author.ID, "Notified", "Caption")
+	})
+
+	It("should notify the author of a like and push it to the author only", func() {
+		// Given: Another user
+		liker := createTestUser(sharedContainers.DB, "liker", "liker@example.com")
+
+		// When: They like the author's post
+		_, _, err := interactionService.LikePost(liker.ID, post.ID)
+		Expect(err).NotTo(HaveOccurred())
+
+		// Then: The author has one unread notification
+		notifications, _, err := notificationService.List(author.ID, 20, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(notifications).To(HaveLen(1))
+		Expect(notifications[0].Type).To(Equal(domain.NotificationTypeLike))
+		Expect(notifications[0].Message()).To(Equal("liker liked your post"))
+
+		count, err := notificationService.UnreadCount(author.ID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(count).To(Equal(1))
+
+		// Then: It was recorded in the outbox as a private event after the like
+		relay := service.NewOutboxRelay(postgresRepo.NewOutboxRepository(sharedContainers.DB), events.NewPublisher(bus, logger), 10, 20, 0, time.Hour, logger)
+		_, err = relay.RelayPending(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+
+		pushed, err := bus.Since(context.Background(), "0-0", 10)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pushed).To(HaveLen(2))
+		Expect(pushed[0].Type).To(Equal(events.EventTypePostLiked))
+		Expect(pushed[1].Type).To(Equal(events.EventTypeNotification))
+		Expect(pushed[1].IsVisibleTo(author.ID)).To(BeTrue())
+		Expect(pushed[1].IsVisibleTo(liker.ID)).To(BeFalse())
+		Expect(pushed[1].Data).To(HaveKeyWithValue("unread_count", BeNumerically("==", 1)))
+	})
+
+	It("should not notify authors of their own interactions", func() {
+		_, _, err := interactionService.LikePost(author.ID, post.ID)
+		Expect(err).NotTo(HaveOccurred())
+		_, _, err = interactionService.CommentPost(author.ID, post.ID, "My own post", author.Username)
+		Expect(err).NotTo(HaveOccurred())
+
+		count, err := notificationService.UnreadCount(author.ID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(count).To(BeZero())
+	})
+
+	It("should coalesce likes from distinct users while unread", func() {
+		// Given: Three users like the post, and the first one likes it again
+		var likers []*domain.User
+		for i := 1; i <= 3; i++ {
+			liker := createTestUser(sharedContainers.DB, fmt.Sprintf("liker%d", i), fmt.Sprintf("liker%d@example.com", i))
+			_, _, err := interactionService.LikePost(liker.ID, post.ID)
+			Expect(err).NotTo(HaveOccurred())
+			likers = append(likers, liker)
+		}
+		interactionService.LikePost(likers[0].ID, post.ID) // unlike
+		interactionService.LikePost(likers[0].ID, post.ID) // like again
+
+		// Then: One notification counts each liker once
+		notifications, _, err := notificationService.List(author.ID, 20, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(notifications).To(HaveLen(1))
+		Expect(notifications[0].ActorCount).To(Equal(3))
+		Expect(notifications[0].ActorID).To(Equal(likers[0].ID))
+		Expect(notifications[0].Message()).To(Equal("liker1 and 2 others liked your post"))
+
+		// Then: Comments are notified separately
+		_, _, err = interactionService.CommentPost(likers[1].ID, post.ID, "Nice", likers[1].Username)
+		Expect(err).NotTo(HaveOccurred())
+		count, _ := notificationService.UnreadCount(author.ID)
+		Expect(count).To(Equal(2))
+	})
+
+	It("should start a new notification once the previous one is read", func() {
+		first := createTestUser(sharedContainers.DB, "first", "first@example.com")
+		second := createTestUser(sharedContainers.DB, "second", "second@example.com")
+		interactionService.LikePost(first.ID, post.ID)
+
+		notifications, _, _ := notificationService.List(author.ID, 20, "")
+		Expect(notificationService.MarkRead(author.ID, notifications[0].ID)).To(Succeed())
+		Expect(notificationService.MarkRead(author.ID, notifications[0].ID)).To(Succeed())
+
+		interactionService.LikePost(second.ID, post.ID)
+
+		notifications, _, err := notificationService.List(author.ID, 20, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(notifications).To(HaveLen(2))
+		Expect(notifications[0].ActorID).To(Equal(second.ID))
+		Expect(notifications[0].ActorCount).To(Equal(1))
+		Expect(notifications[0].ReadAt).To(BeNil())
+		Expect(notifications[1].ReadAt).NotTo(BeNil())
+	})
+
+	It("should only let recipients mark their notifications read", func() {
+		liker := createTestUser(sharedContainers.DB, "liker", "liker@example.com")
+		interactionService.LikePost(liker.ID, post.ID)
+		notifications, _, _ := notificationService.List(author.ID, 20, "")
+
+		err := notificationService.MarkRead(liker.ID, notifications[0].ID)
+
+		Expect(err).To(MatchError(service.ErrNotificationNotFound))
+	})
+
+	It("should mark all notifications read", func() {
+		liker := createTestUser(sharedContainers.DB, "liker", "liker@example.com")
+		interactionService.LikePost(liker.ID, post.ID)
+		interactionService.CommentPost(liker.ID, post.ID, "Nice", liker.Username)
+
+		marked, err := notificationService.MarkAllRead(author.ID)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(marked).To(Equal(2))
+		count, _ := notificationService.UnreadCount(author.ID)
+		Expect(count).To(BeZero())
+	})
+
+	It("should paginate notifications with a cursor", func() {
+		// Given: Likes on three different posts
+		liker := createTestUser(sharedContainers.DB, "liker", "liker@example.com")
+		interactionService.LikePost(liker.ID, post.ID)
+		for i := 1; i <= 2; i++ {
+			other := createTestPost(sharedContainers.DB, author.ID, fmt.Sprintf("Post %d", i), "Caption")
+			interactionService.LikePost(liker.ID, other.ID)
+		}
+
+		// When: Listing two at a time
+		firstPage, cursor, err := notificationService.List(author.ID, 2, "")
+		Expect(err).NotTo(HaveOccurred())
+		secondPage, nextCursor, err := notificationService.List(author.ID, 2, cursor)
+		Expect(err).NotTo(HaveOccurred())
+
+		// Then: The pages do not overlap and the oldest comes last
+		Expect(firstPage).To(HaveLen(2))
+		Expect(cursor).NotTo(BeEmpty())
+		Expect(secondPage).To(HaveLen(1))
+		Expect(nextCursor).To(BeEmpty())
+		Expect(secondPage[0].PostID).To(Equal(post.ID))
+
+		_, _, err = notificationService.List(author.ID, 2, "not-a-cursor")
+		Expect(err).To(MatchError(service.ErrInvalidCursor))
+	})
+})
+
+var _ = Describe("Notification API", func() {
+	It("should list, count and mark notifications read", func() {
+		// Given: Test app setup
+		app, _, cleanup := setupTestApp()
+		defer cleanup()
+
+		// Given: An author with a post liked by another user
+		authorToken := registerAndLogin(app, "inboxauthor", "inboxauthor@example.com", "pass123")
+		var authorID uint
+		err := sharedContainers.DB.QueryRow(`SELECT id FROM users WHERE username = 'inboxauthor'`).Scan(&authorID)
+		Expect(err).NotTo(HaveOccurred())
+		post := createTestPost(sharedContainers.DB, authorID, "Inbox", "Caption")
+
+		likerToken := registerAndLogin(app, "inboxliker", "inboxliker@example.com", "pass123")
+		likeReq := httptest.NewRequest("POST", fmt.Sprintf("/api/posts/%d/like", post.ID), nil)
+		likeReq.Header.Set("Authorization", "Bearer "+likerToken)
+		likeResp, err := app.Test(likeReq, 2000)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(likeResp.StatusCode).To(Equal(200))
+
+		get := func(path string, result interface{}) {
+			req := httptest.NewRequest("GET", path, nil)
+			req.Header.Set("Authorization", "Bearer "+authorToken)
+			resp, err := app.Test(req, 2000)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(json.NewDecoder(resp.Body).Decode(result)).To(Succeed())
+		}
+
+		// Then: The author sees it
+		var unread map[string]int
+		get("/api/notifications/unread_count", &unread)
+		Expect(unread["unread_count"]).To(Equal(1))
+
+		var list map[string]interface{}
+		get("/api/notifications", &list)
+		notifications := list["notifications"].([]interface{})
+		Expect(notifications).To(HaveLen(1))
+		Expect(notifications[0].(map[string]interface{})["message"]).To(Equal("inboxliker liked your post"))
+
+		// When: The author marks everything read
+		req := httptest.NewRequest("POST", "/api/notifications/read_all", nil)
+		req.Header.Set("Authorization", "Bearer "+authorToken)
+		resp, err := app.Test(req, 2000)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(200))
+
+		// Then: Nothing is unread
+		get("/api/notifications/unread_count", &unread)
+		Expect(unread["unread_count"]).To(BeZero())
+
+		// Then: Unknown notifications are not found
+		req = httptest.NewRequest("POST", "/api/notifications/999/read", nil)
+		req.Header.Set("Authorization", "Bearer "+authorToken)
+		resp, err = app.Test(req, 2000)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(404))
+	})
+})
@@ -0,0 +1,198 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+
+	"github.com/rodolfodpk/instagrano/internal/events"
+	postgresRepo "github.com/rodolfodpk/instagrano/internal/repository/postgres"
+	"github.com/rodolfodpk/instagrano/internal/service"
+)
+
+// failingBus is an event bus that rejects every event, like Redis being down
+type failingBus struct {
+	events.EventBus
+}
+
+func (failingBus) Append(ctx context.Context, event events.Event) (events.Event, error) {
+	return event, errors.New("event bus unavailable")
+}
+
+var _ = Describe("OutboxRelay", func() {
+	var (
+		ctx        context.Context
+		logger     *zap.Logger
+		outboxRepo postgresRepo.OutboxRepository
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		logger, _ = zap.NewDevelopment()
+		outboxRepo = postgresRepo.NewOutboxRepository(sharedContainers.DB)
+	})
+
+	It("should record the like event in the outbox with the like", func() {
+		// Given: A post
+		interactionService, _, _, _ := createInteractionService()
+		user := createTestUser(sharedContainers.DB, "outboxuser", "outbox@example.com")
+		post := createTestPost(sharedContainers.DB, user.ID, "Outbox Post", "Caption")
+
+		// When: The user likes it
+		_, _, err := interactionService.LikePost(user.ID, post.ID)
+
+		// Then: The event waits in the outbox without anything being published
+		Expect(err).NotTo(HaveOccurred())
+		pending, err := outboxRepo.CountPending()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pending).To(Equal(1))
+	})
+
+	It("should publish pending events and mark them delivered", func() {
+		// Given: A like waiting in the outbox
+		interactionService, _, _, _ := createInteractionService()
+		user := createTestUser(sharedContainers.DB, "relayuser", "relay@example.com")
+		post := createTestPost(sharedContainers.DB, user.ID, "Relay Post", "Caption")
+		_, _, err := interactionService.LikePost(user.ID, post.ID)
+		Expect(err).NotTo(HaveOccurred())
+
+		bus := events.NewMemoryBus(10)
+		relay := service.NewOutboxRelay(outboxRepo, events.NewPublisher(bus, logger), 10, 20, 0, time.Hour, logger)
+
+		// When: The relay runs
+		relayed, err := relay.RelayPending(ctx)
+
+		// Then: The event is on the bus and no longer pending
+		Expect(err).NotTo(HaveOccurred())
+		Expect(relayed).To(Equal(1))
+
+		published, err := bus.Since(ctx, "0-0", 10)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(published).To(HaveLen(1))
+		Expect(published[0].Type).To(Equal(events.EventTypePostLiked))
+		Expect(published[0].PostID).To(Equal(post.ID))
+		Expect(published[0].Timestamp).NotTo(BeZero())
+
+		pending, err := outboxRepo.CountPending()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pending).To(BeZero())
+	})
+
+	It("should keep events pending and back off when publishing fails", func() {
+		// Given: A follow waiting in the outbox
+		followService := createFollowService()
+		alice := createTestUser(sharedContainers.DB, "alice", "alice@example.com")
+		bob := createTestUser(sharedContainers.DB, "bob", "bob@example.com")
+		_, err := followService.Follow(alice.ID, bob.ID, alice.Username)
+		Expect(err).NotTo(HaveOccurred())
+
+		relay := service.NewOutboxRelay(outboxRepo, events.NewPublisher(failingBus{}, logger), 10, 20, 0, time.Hour, logger)
+
+		// When: The relay cannot publish
+		relayed, err := relay.RelayPending(ctx)
+
+		// Then: The event stays pending and is not retried immediately
+		Expect(err).NotTo(HaveOccurred())
+		Expect(relayed).To(Equal(1))
+
+		pending, err := outboxRepo.CountPending()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pending).To(Equal(1))
+
+		relayed, err = relay.RelayPending(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(relayed).To(BeZero())
+	})
+
+	It("should dead-letter events that run out of attempts", func() {
+		// Given: A follow waiting in the outbox and a relay allowed one attempt
+		followService := createFollowService()
+		alice := createTestUser(sharedContainers.DB, "alice", "alice@example.com")
+		bob := createTestUser(sharedContainers.DB, "bob", "bob@example.com")
+		_, err := followService.Follow(alice.ID, bob.ID, alice.Username)
+		Expect(err).NotTo(HaveOccurred())
+
+		relay := service.NewOutboxRelay(outboxRepo, events.NewPublisher(failingBus{}, logger), 10, 1, 0, time.Hour, logger)
+
+		// When: Publishing fails
+		relayed, err := relay.RelayPending(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(relayed).To(Equal(1))
+
+		// Then: The event is dead-lettered instead of pending
+		pending, err := outboxRepo.CountPending()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pending).To(BeZero())
+		dead, err := outboxRepo.CountDead()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dead).To(Equal(1))
+	})
+
+	It("should dead-letter undecodable payloads without retrying", func() {
+		// Given: A message whose payload is not an event
+		_, err := sharedContainers.DB.Exec(`INSERT INTO outbox (event_type, payload) VALUES ('post_liked', '"not an event"')`)
+		Expect(err).NotTo(HaveOccurred())
+
+		bus := events.NewMemoryBus(10)
+		relay := service.NewOutboxRelay(outboxRepo, events.NewPublisher(bus, logger), 10, 20, 0, time.Hour, logger)
+
+		// When: The relay runs
+		_, err = relay.RelayPending(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		// Then: Nothing is published and the message is dead on the first attempt
+		published, err := bus.Since(ctx, "0-0", 10)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(published).To(BeEmpty())
+		dead, err := outboxRepo.CountDead()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dead).To(Equal(1))
+	})
+
+	It("should prune delivered events past retention", func() {
+		// Given: A delivered like and a pending one
+		interactionService, _, _, _ := createInteractionService()
+		user := createTestUser(sharedContainers.DB, "pruneuser", "prune@example.com")
+		delivered := createTestPost(sharedContainers.DB, user.ID, "Delivered", "Caption")
+		_, _, err := interactionService.LikePost(user.ID, delivered.ID)
+		Expect(err).NotTo(HaveOccurred())
+
+		relay := service.NewOutboxRelay(outboxRepo, events.NewPublisher(events.NewMemoryBus(10), logger), 10, 20, 0, 0, logger)
+		_, err = relay.RelayPending(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		pendingPost := createTestPost(sharedContainers.DB, user.ID, "Pending", "Caption")
+		_, _, err = interactionService.LikePost(user.ID, pendingPost.ID)
+		Expect(err).NotTo(HaveOccurred())
+
+		// When: Pruning with no retention
+		pruned, err := relay.PruneDelivered()
+
+		// Then: Only the delivered row is deleted
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pruned).To(Equal(1))
+
+		var remaining int
+		Expect(sharedContainers.DB.QueryRow(`SELECT COUNT(*) FROM outbox`).Scan(&remaining)).To(Succeed())
+		Expect(remaining).To(Equal(1))
+	})
+
+	It("should only record a follow event for new follows", func() {
+		followService := createFollowService()
+		alice := createTestUser(sharedContainers.DB, "alice", "alice@example.com")
+		bob := createTestUser(sharedContainers.DB, "bob", "bob@example.com")
+
+		_, err := followService.Follow(alice.ID, bob.ID, alice.Username)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = followService.Follow(alice.ID, bob.ID, alice.Username)
+		Expect(err).NotTo(HaveOccurred())
+
+		pending, err := outboxRepo.CountPending()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pending).To(Equal(1))
+	})
+})
@@ -1,14 +1,63 @@
 package tests
 
 import (
+	"context"
+	"encoding/base64"
+	"fmt"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/rodolfodpk/instagrano/internal/domain"
 	"github.com/rodolfodpk/instagrano/internal/pagination"
+	postgresRepo "github.com/rodolfodpk/instagrano/internal/repository/postgres"
+	"github.com/rodolfodpk/instagrano/internal/service"
 )
 
+// createRankedPost inserts a post with the given age and engagement
+func createRankedPost(userID uint, title string, age time.Duration, likes, comments, views int) uint {
+	query := `INSERT INTO posts (user_id, title, caption, media_type, media_url, likes_count, comments_count, views_count, created_at, updated_at)
+			  VALUES ($1, $2, '', 'image', '/uploads/test.jpg', $3, $4, $5, $6, NOW()) RETURNING id`
+
+	var postID uint
+	err := sharedContainers.DB.QueryRow(query, userID, title, likes, comments, views, time.Now().Add(-age)).Scan(&postID)
+	Expect(err).NotTo(HaveOccurred())
+	return postID
+}
+
+// collectFeed pages through the feed and returns every post in the order served
+func collectFeed(feedService *service.FeedService, limit int, between func()) []*domain.Post {
+	var posts []*domain.Post
+	cursor := ""
+	for page := 0; page < 100; page++ {
+		result, err := feedService.GetFeedWithCursor(limit, cursor)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(len(result.Posts)).To(BeNumerically("<=", limit))
+		for _, p := range result.Posts {
+			posts = append(posts, p.(*domain.Post))
+		}
+		if !result.HasMore {
+			Expect(result.NextCursor).To(BeEmpty())
+			return posts
+		}
+		cursor = result.NextCursor
+		if between != nil {
+			between()
+		}
+	}
+	Fail("feed did not terminate")
+	return nil
+}
+
+func postIDSet(posts []*domain.Post) map[uint]int {
+	seen := make(map[uint]int, len(posts))
+	for _, p := range posts {
+		seen[p.ID]++
+	}
+	return seen
+}
+
 var _ = Describe("Cursor", func() {
 	Describe("Encode", func() {
 		It("should encode cursor to non-empty string", func() {
@@ -104,6 +153,34 @@ var _ = Describe("Cursor", func() {
 			Expect(decodedCursor.Timestamp.Unix()).To(Equal(originalCursor.Timestamp.Unix()))
 			Expect(decodedCursor.ID).To(Equal(originalCursor.ID))
 		})
+
+		It("should preserve sub-second precision", func() {
+			// Given: A cursor with a sub-second timestamp
+			originalCursor := &pagination.Cursor{
+				Timestamp: time.Date(2024, 1, 15, 10, 30, 0, 123456000, time.UTC),
+				ID:        7,
+			}
+
+			// When: Encode and then decode
+			decodedCursor, err := pagination.DecodeCursor(originalCursor.Encode())
+
+			// Then: Microseconds should survive the round trip
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decodedCursor.Timestamp.Equal(originalCursor.Timestamp)).To(BeTrue())
+		})
+
+		It("should read cursors issued before microsecond precision as seconds", func() {
+			// Given: A cursor in the old unversioned format, with Unix seconds
+			legacy := base64Encode("1705314600_123")
+
+			// When: Decode the cursor
+			decodedCursor, err := pagination.DecodeCursor(legacy)
+
+			// Then: The timestamp is read as seconds, not microseconds
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decodedCursor.Timestamp.Equal(time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC))).To(BeTrue())
+			Expect(decodedCursor.ID).To(Equal(uint(123)))
+		})
 	})
 
 	Describe("IsEmpty", func() {
@@ -132,4 +209,152 @@ var _ = Describe("Cursor", func() {
 			Expect(isEmpty).To(BeFalse())
 		})
 	})
-})
\ No newline at end of file
+})
+
+var _ = Describe("RankCursor", func() {
+	It("should round-trip encode and decode", func() {
+		// Given: A rank cursor
+		original := &pagination.RankCursor{SessionID: "abc-123_x", Offset: 40}
+
+		// When: Encode and then decode
+		encoded := original.Encode()
+		decoded, err := pagination.DecodeRankCursor(encoded)
+
+		// Then: Should match original and be recognised as a rank cursor
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decoded).To(Equal(original))
+		Expect(pagination.IsRankCursor(encoded)).To(BeTrue())
+	})
+
+	It("should not treat time cursors as rank cursors", func() {
+		cursor := &pagination.Cursor{Timestamp: time.Now(), ID: 1}
+
+		Expect(pagination.IsRankCursor(cursor.Encode())).To(BeFalse())
+		Expect(pagination.IsRankCursor("invalid-cursor-string")).To(BeFalse())
+	})
+
+	It("should reject malformed rank cursors", func() {
+		for _, raw := range []string{"rank:", "rank:abc", "rank::3", "rank:abc:-1", "rank:abc:x"} {
+			_, err := pagination.DecodeRankCursor(base64Encode(raw))
+			Expect(err).To(HaveOccurred(), raw)
+		}
+	})
+})
+
+var _ = Describe("ScoreCursor", func() {
+	It("should round-trip encode and decode", func() {
+		original := &pagination.ScoreCursor{Score: 42, ID: 7}
+
+		decoded, err := pagination.DecodeScoreCursor(original.Encode())
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decoded).To(Equal(original))
+	})
+
+	It("should reject time cursors and malformed score cursors", func() {
+		timeCursor := &pagination.Cursor{Timestamp: time.Now(), ID: 1}
+		_, err := pagination.DecodeScoreCursor(timeCursor.Encode())
+		Expect(err).To(HaveOccurred())
+
+		for _, raw := range []string{"score:", "score:5", "score:x:1", "score:5:-1"} {
+			_, err := pagination.DecodeScoreCursor(base64Encode(raw))
+			Expect(err).To(HaveOccurred(), raw)
+		}
+	})
+})
+
+var _ = Describe("Ranked feed pagination", func() {
+	const expected = 16
+
+	BeforeEach(func() {
+		// Given: Posts with a mix of ages and engagement, including score ties
+		user := createTestUser(sharedContainers.DB, "ranker", "ranker@example.com")
+		for i := 0; i < 12; i++ {
+			createRankedPost(user.ID, fmt.Sprintf("Post %d", i), time.Duration(i)*time.Hour, (i*7)%5, i%3, i*10)
+		}
+		for i := 0; i < 4; i++ {
+			// No engagement: these all score exactly zero
+			createRankedPost(user.ID, fmt.Sprintf("Quiet %d", i), time.Duration(i)*time.Minute, 0, 0, 0)
+		}
+	})
+
+	It("should return every post exactly once in score order", func() {
+		postRepo := postgresRepo.NewPostRepository(sharedContainers.DB)
+		feedService := createFeedService(postRepo, 1000)
+
+		// When: Paging through the whole feed three posts at a time
+		posts := collectFeed(feedService, 3, nil)
+
+		// Then: No duplicates and no gaps
+		Expect(posts).To(HaveLen(expected))
+		for id, count := range postIDSet(posts) {
+			Expect(count).To(Equal(1), fmt.Sprintf("post %d returned %d times", id, count))
+		}
+
+		// And: Scores never increase across pages
+		for i := 1; i < len(posts); i++ {
+			Expect(posts[i].Score).To(BeNumerically("<=", posts[i-1].Score))
+		}
+	})
+
+	It("should stay stable while engagement changes between pages", func() {
+		postRepo := postgresRepo.NewPostRepository(sharedContainers.DB)
+		feedService := createFeedService(postRepo, 1000)
+
+		// When: Every post gets a burst of likes after each page, reshuffling live scores
+		bump := 0
+		posts := collectFeed(feedService, 4, func() {
+			bump++
+			_, err := sharedContainers.DB.Exec(`UPDATE posts SET likes_count = likes_count + (id * $1) % 97`, bump)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		// Then: The snapshot still yields every post exactly once
+		Expect(posts).To(HaveLen(expected))
+		Expect(postIDSet(posts)).To(HaveLen(expected))
+	})
+
+	It("should continue chronologically past the ranking window", func() {
+		postRepo := postgresRepo.NewPostRepository(sharedContainers.DB)
+		feedService := createFeedService(postRepo, 5)
+
+		// When: Only the five newest posts are ranked
+		posts := collectFeed(feedService, 3, nil)
+
+		// Then: Every post is still returned exactly once
+		Expect(posts).To(HaveLen(expected))
+		Expect(postIDSet(posts)).To(HaveLen(expected))
+
+		// And: Posts after the window are all older than the window, newest first
+		window, tail := posts[:5], posts[5:]
+		for _, w := range window {
+			for _, t := range tail {
+				Expect(t.CreatedAt.Before(w.CreatedAt) || (t.CreatedAt.Equal(w.CreatedAt) && t.ID < w.ID)).To(BeTrue())
+			}
+		}
+		for i := 1; i < len(tail); i++ {
+			Expect(tail[i].CreatedAt.After(tail[i-1].CreatedAt)).To(BeFalse())
+		}
+	})
+
+	It("should report an expired ranking session", func() {
+		postRepo := postgresRepo.NewPostRepository(sharedContainers.DB)
+		feedService := createFeedService(postRepo, 1000)
+
+		// Given: A first page with a rank cursor
+		first, err := feedService.GetFeedWithCursor(3, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pagination.IsRankCursor(first.NextCursor)).To(BeTrue())
+
+		// When: The session is evicted
+		Expect(sharedContainers.Cache.FlushAll(context.Background())).To(Succeed())
+		_, err = feedService.GetFeedWithCursor(3, first.NextCursor)
+
+		// Then: The client is told to restart
+		Expect(err).To(MatchError(service.ErrCursorExpired))
+	})
+})
+
+func base64Encode(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
@@ -1,6 +1,12 @@
 package tests
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"time"
 
@@ -8,6 +14,8 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/rodolfodpk/instagrano/internal/domain"
+	"github.com/rodolfodpk/instagrano/internal/dto"
+	"github.com/rodolfodpk/instagrano/internal/events"
 	postgresRepo "github.com/rodolfodpk/instagrano/internal/repository/postgres"
 	"github.com/rodolfodpk/instagrano/internal/service"
 )
@@ -18,7 +26,7 @@ var _ = Describe("PostService", func() {
 			// Given: Post service setup
 			postRepo := postgresRepo.NewPostRepository(sharedContainers.DB)
 			mediaStorage := createTestS3Storage()
-			postService := service.NewPostService(postRepo, mediaStorage, sharedContainers.Cache, 5*time.Minute)
+			postService := service.NewPostService(postRepo, mediaStorage, sharedContainers.Cache, 5*time.Minute, nil, nil, nil)
 
 			// Given: User exists
 			user := createTestUser(sharedContainers.DB, "postuser", "post@example.com")
@@ -56,7 +64,7 @@ var _ = Describe("PostService", func() {
 			// Given: Post service setup
 			postRepo := postgresRepo.NewPostRepository(sharedContainers.DB)
 			mediaStorage := createTestS3Storage()
-			postService := service.NewPostService(postRepo, mediaStorage, sharedContainers.Cache, 5*time.Minute)
+			postService := service.NewPostService(postRepo, mediaStorage, sharedContainers.Cache, 5*time.Minute, nil, nil, nil)
 
 			user := createTestUser(sharedContainers.DB, "videouser", "video@example.com")
 
@@ -87,7 +95,7 @@ var _ = Describe("PostService", func() {
 			// Given: Post service setup
 			postRepo := postgresRepo.NewPostRepository(sharedContainers.DB)
 			mediaStorage := createTestS3Storage()
-			postService := service.NewPostService(postRepo, mediaStorage, sharedContainers.Cache, 5*time.Minute)
+			postService := service.NewPostService(postRepo, mediaStorage, sharedContainers.Cache, 5*time.Minute, nil, nil, nil)
 
 			user := createTestUser(sharedContainers.DB, "emptytitle", "empty@example.com")
 
@@ -111,7 +119,7 @@ var _ = Describe("PostService", func() {
 			// Given: Post service setup
 			postRepo := postgresRepo.NewPostRepository(sharedContainers.DB)
 			mediaStorage := createTestS3Storage()
-			postService := service.NewPostService(postRepo, mediaStorage, sharedContainers.Cache, 5*time.Minute)
+			postService := service.NewPostService(postRepo, mediaStorage, sharedContainers.Cache, 5*time.Minute, nil, nil, nil)
 
 			user := createTestUser(sharedContainers.DB, "largefile", "large@example.com")
 
@@ -133,7 +141,7 @@ var _ = Describe("PostService", func() {
 			// Given: Post service setup
 			postRepo := postgresRepo.NewPostRepository(sharedContainers.DB)
 			mediaStorage := createTestS3Storage()
-			postService := service.NewPostService(postRepo, mediaStorage, sharedContainers.Cache, 5*time.Minute)
+			postService := service.NewPostService(postRepo, mediaStorage, sharedContainers.Cache, 5*time.Minute, nil, nil, nil)
 
 			user := createTestUser(sharedContainers.DB, "special", "special@example.com")
 
@@ -155,12 +163,112 @@ var _ = Describe("PostService", func() {
 		})
 	})
 
+	Describe("CreateCarouselPost", func() {
+		var (
+			postService  *service.PostService
+			postRepo     postgresRepo.PostRepository
+			mediaStorage *MockMediaStorage
+			user         *domain.User
+		)
+
+		BeforeEach(func() {
+			postRepo = postgresRepo.NewPostRepository(sharedContainers.DB)
+			mediaStorage = NewMockMediaStorage()
+			postService = service.NewPostService(postRepo, mediaStorage, sharedContainers.Cache, 5*time.Minute, nil, nil, nil)
+			user = createTestUser(sharedContainers.DB, "carousel", "carousel@example.com")
+		})
+
+		It("should store mixed media in order", func() {
+			// Given: An image, a video and another image
+			uploads := []service.MediaUpload{
+				{File: strings.NewReader("image one"), Filename: "one.jpg", MediaType: domain.MediaTypeImage},
+				{File: strings.NewReader("video two"), Filename: "two.mp4", MediaType: domain.MediaTypeVideo},
+				{File: strings.NewReader("image three"), Filename: "three.jpg", MediaType: domain.MediaTypeImage},
+			}
+
+			// When: Create the carousel
+			post, err := postService.CreateCarouselPost(user.ID, "Trip", "Day one", uploads)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Then: The items are saved in the order sent, the first one doubling as the post's media
+			savedPost, err := postRepo.FindByID(post.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(savedPost.Media).To(HaveLen(3))
+			for i, name := range []string{"one.jpg", "two.mp4", "three.jpg"} {
+				Expect(savedPost.Media[i].Position).To(Equal(i))
+				Expect(savedPost.Media[i].MediaURL).To(HaveSuffix(name))
+			}
+			Expect(savedPost.Media[1].MediaType).To(Equal(domain.MediaTypeVideo))
+			Expect(savedPost.MediaType).To(Equal(domain.MediaTypeImage))
+			Expect(savedPost.MediaURL).To(Equal(savedPost.Media[0].MediaURL))
+		})
+
+		It("should delete uploaded media when an item fails", func() {
+			// Given: The second of three uploads fails
+			uploads := []service.MediaUpload{
+				{File: strings.NewReader("image one"), Filename: "ok-one.jpg", MediaType: domain.MediaTypeImage},
+				{File: strings.NewReader("image two"), Filename: "fail-two.jpg", MediaType: domain.MediaTypeImage},
+				{File: strings.NewReader("image three"), Filename: "ok-three.jpg", MediaType: domain.MediaTypeImage},
+			}
+
+			// When: Create the carousel
+			post, err := postService.CreateCarouselPost(user.ID, "Trip", "", uploads)
+
+			// Then: No post is created and the other objects are gone
+			Expect(err).To(HaveOccurred())
+			Expect(post).To(BeNil())
+			for _, key := range []string{"mock-s3/ok-one.jpg", "mock-s3/ok-three.jpg"} {
+				_, exists := mediaStorage.GetFile(key)
+				Expect(exists).To(BeFalse())
+			}
+
+			var count int
+			Expect(sharedContainers.DB.QueryRow("SELECT COUNT(*) FROM posts WHERE user_id = $1", user.ID).Scan(&count)).To(Succeed())
+			Expect(count).To(Equal(0))
+		})
+
+		It("should reject more than the maximum number of items", func() {
+			uploads := make([]service.MediaUpload, domain.MaxPostMedia+1)
+			for i := range uploads {
+				uploads[i] = service.MediaUpload{File: strings.NewReader("image"), Filename: fmt.Sprintf("%d.jpg", i), MediaType: domain.MediaTypeImage}
+			}
+
+			_, err := postService.CreateCarouselPost(user.ID, "Too Many", "", uploads)
+			Expect(err).To(MatchError(service.ErrTooManyMedia))
+
+			_, err = postService.CreatePostFromURLs(user.ID, "Too Many", "", make([]string, domain.MaxPostMedia+1))
+			Expect(err).To(MatchError(service.ErrTooManyMedia))
+		})
+
+		It("should create a carousel from several URLs", func() {
+			post, err := postService.CreatePostFromURLs(user.ID, "Links", "", []string{
+				"https://example.com/a.jpg",
+				"https://example.com/b.png",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(post.Media).To(HaveLen(2))
+			Expect(post.Media[0].MediaURL).To(HaveSuffix("a.jpg"))
+			Expect(post.Media[1].MediaURL).To(HaveSuffix("b.png"))
+		})
+
+		It("should expose a single item for posts created before carousels", func() {
+			created := createTestPost(sharedContainers.DB, user.ID, "Old", "")
+			savedPost, err := postRepo.FindByID(created.ID)
+			Expect(err).NotTo(HaveOccurred())
+
+			response := dto.ToPostResponse(savedPost)
+			Expect(response.Media).To(HaveLen(1))
+			Expect(response.Media[0].MediaURL).To(Equal(savedPost.MediaURL))
+		})
+	})
+
 	Describe("GetPost", func() {
 		It("should retrieve post successfully", func() {
 			// Given: Post service setup
 			postRepo := postgresRepo.NewPostRepository(sharedContainers.DB)
 			mediaStorage := createTestS3Storage()
-			postService := service.NewPostService(postRepo, mediaStorage, sharedContainers.Cache, 5*time.Minute)
+			postService := service.NewPostService(postRepo, mediaStorage, sharedContainers.Cache, 5*time.Minute, nil, nil, nil)
 
 			// Given: User and post exist
 			user := createTestUser(sharedContainers.DB, "getuser", "get@example.com")
@@ -182,7 +290,7 @@ var _ = Describe("PostService", func() {
 			// Given: Post service setup
 			postRepo := postgresRepo.NewPostRepository(sharedContainers.DB)
 			mediaStorage := createTestS3Storage()
-			postService := service.NewPostService(postRepo, mediaStorage, sharedContainers.Cache, 5*time.Minute)
+			postService := service.NewPostService(postRepo, mediaStorage, sharedContainers.Cache, 5*time.Minute, nil, nil, nil)
 
 			// When: Get non-existent post
 			post, err := postService.GetPost(99999)
@@ -193,4 +301,156 @@ var _ = Describe("PostService", func() {
 			Expect(err.Error()).To(ContainSubstring("not found"))
 		})
 	})
+
+	Describe("UpdatePost", func() {
+		var (
+			postService *service.PostService
+			postRepo    postgresRepo.PostRepository
+			author      *domain.User
+			post        *domain.Post
+		)
+
+		BeforeEach(func() {
+			postRepo = postgresRepo.NewPostRepository(sharedContainers.DB)
+			postService = service.NewPostService(postRepo, createTestS3Storage(), sharedContainers.Cache, 5*time.Minute, nil, nil, nil)
+			author = createTestUser(sharedContainers.DB, "editor", "editor@example.com")
+			created := createTestPost(sharedContainers.DB, author.ID, "Edit Me", "Captoin with a typo")
+
+			var err error
+			post, err = postRepo.FindByID(created.ID)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should edit the caption and keep the previous version", func() {
+			// Given: The post and a feed page are cached
+			_, err := postService.GetPost(post.ID)
+			Expect(err).NotTo(HaveOccurred())
+			ctx := context.Background()
+			feedKey := "feed:decay:cursor:abc:limit:20"
+			Expect(sharedContainers.Cache.Set(ctx, feedKey, []byte("{}"), time.Minute)).To(Succeed())
+
+			// When: The author fixes the caption
+			caption := "Caption without a typo"
+			updated, err := postService.UpdatePost(post.ID, author.ID, nil, &caption, post.UpdatedAt)
+
+			// Then: The post is edited and newer
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated.Title).To(Equal("Edit Me"))
+			Expect(updated.Caption).To(Equal(caption))
+			Expect(updated.UpdatedAt).To(BeTemporally(">", post.UpdatedAt))
+
+			// Then: The caches no longer hold the old version
+			cached, err := postService.GetPost(post.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cached.Caption).To(Equal(caption))
+			_, err = sharedContainers.Cache.Get(ctx, feedKey)
+			Expect(err).To(HaveOccurred())
+
+			// Then: The history keeps the previous caption
+			var oldTitle, oldCaption string
+			err = sharedContainers.DB.QueryRow(`SELECT title, caption FROM post_edits WHERE post_id = $1`, post.ID).Scan(&oldTitle, &oldCaption)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(oldTitle).To(Equal("Edit Me"))
+			Expect(oldCaption).To(Equal("Captoin with a typo"))
+
+			// Then: A post_updated event is in the outbox
+			var eventType string
+			err = sharedContainers.DB.QueryRow(`SELECT event_type FROM outbox ORDER BY id DESC LIMIT 1`).Scan(&eventType)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(eventType).To(Equal(string(events.EventTypePostUpdated)))
+		})
+
+		It("should reject edits of a stale version", func() {
+			title := "First"
+			_, err := postService.UpdatePost(post.ID, author.ID, &title, nil, post.UpdatedAt)
+			Expect(err).NotTo(HaveOccurred())
+
+			// When: Editing again from the version read before the first edit
+			title = "Second"
+			_, err = postService.UpdatePost(post.ID, author.ID, &title, nil, post.UpdatedAt)
+
+			// Then: The second edit is rejected and changes nothing
+			Expect(err).To(MatchError(service.ErrPostConflict))
+			current, err := postRepo.FindByID(post.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(current.Title).To(Equal("First"))
+		})
+
+		It("should only let the author edit existing posts", func() {
+			other := createTestUser(sharedContainers.DB, "notauthor", "notauthor@example.com")
+			title := "Hijacked"
+
+			_, err := postService.UpdatePost(post.ID, other.ID, &title, nil, post.UpdatedAt)
+			Expect(err).To(MatchError(service.ErrPostForbidden))
+
+			_, err = postService.UpdatePost(99999, author.ID, &title, nil, post.UpdatedAt)
+			Expect(err).To(MatchError(service.ErrPostNotFound))
+
+			empty := "  "
+			_, err = postService.UpdatePost(post.ID, author.ID, &empty, nil, post.UpdatedAt)
+			Expect(err).To(MatchError(service.ErrInvalidInput))
+		})
+
+		It("should report a post deleted by a concurrent request as not found", func() {
+			// Given: The post is deleted after it was loaded
+			Expect(postService.DeletePost(post.ID, author.ID)).To(Succeed())
+
+			// When: Updating and deleting it again
+			updated, err := postRepo.UpdateWithOutbox(post, post.UpdatedAt, nil, nil)
+
+			// Then: The repository reports no rows and the service not found
+			Expect(updated).To(BeFalse())
+			Expect(err).To(MatchError(sql.ErrNoRows))
+			Expect(postRepo.Delete(post.ID)).To(MatchError(sql.ErrNoRows))
+			_, err = postRepo.FindByID(post.ID)
+			Expect(err).To(MatchError(sql.ErrNoRows))
+			Expect(postService.DeletePost(post.ID, author.ID)).To(MatchError(service.ErrPostNotFound))
+		})
+
+		It("should edit over PATCH with If-Match", func() {
+			// Given: Test app setup
+			app, _, cleanup := setupTestApp()
+			defer cleanup()
+
+			token := registerAndLogin(app, "patcher", "patcher@example.com", "pass123")
+			var userID uint
+			err := sharedContainers.DB.QueryRow(`SELECT id FROM users WHERE username = 'patcher'`).Scan(&userID)
+			Expect(err).NotTo(HaveOccurred())
+			created := createTestPost(sharedContainers.DB, userID, "Patch Me", "Caption")
+			path := fmt.Sprintf("/api/posts/%d", created.ID)
+
+			req := httptest.NewRequest("GET", path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			resp, err := app.Test(req, 2000)
+			Expect(err).NotTo(HaveOccurred())
+			etag := resp.Header.Get("ETag")
+			Expect(etag).NotTo(BeEmpty())
+
+			patch := func(ifMatch string) *http.Response {
+				req := httptest.NewRequest("PATCH", path, strings.NewReader(`{"caption": "Patched"}`))
+				req.Header.Set("Authorization", "Bearer "+token)
+				req.Header.Set("Content-Type", "application/json")
+				if ifMatch != "" {
+					req.Header.Set("If-Match", ifMatch)
+				}
+				resp, err := app.Test(req, 2000)
+				Expect(err).NotTo(HaveOccurred())
+				return resp
+			}
+
+			// When: Patching with the current ETag
+			resp = patch(etag)
+
+			// Then: The post is edited and has a new ETag
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.Header.Get("ETag")).NotTo(Equal(etag))
+			var updated dto.PostResponse
+			Expect(json.NewDecoder(resp.Body).Decode(&updated)).To(Succeed())
+			Expect(updated.Caption).To(Equal("Patched"))
+
+			// Then: The old ETag is stale and a missing one is required
+			Expect(patch(etag).StatusCode).To(Equal(412))
+			Expect(patch("").StatusCode).To(Equal(428))
+		})
+	})
 })
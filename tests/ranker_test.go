@@ -0,0 +1,208 @@
+package tests
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/rodolfodpk/instagrano/internal/domain"
+	"github.com/rodolfodpk/instagrano/internal/service"
+)
+
+// testRankingWeights mirrors the config defaults
+func testRankingWeights() service.RankingWeights {
+	return service.RankingWeights{
+		LikeWeight:    2.0,
+		CommentWeight: 3.0,
+		ViewWeight:    0.1,
+		SaveWeight:    4.0,
+		DecayRate:     0.1,
+		Gravity:       1.8,
+		WilsonZ:       1.96,
+	}
+}
+
+func newTestRanker(name string) service.Ranker {
+	ranker, err := service.NewRanker(name, testRankingWeights())
+	Expect(err).NotTo(HaveOccurred())
+	return ranker
+}
+
+var _ = Describe("Ranker", func() {
+	now := time.Now()
+
+	Describe("NewRanker", func() {
+		It("should build every built-in ranker", func() {
+			for _, name := range service.RankerNames {
+				ranker, err := service.NewRanker(name, testRankingWeights())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ranker.Name()).To(Equal(name))
+			}
+		})
+
+		It("should reject unknown rankers", func() {
+			_, err := service.NewRanker("bogus", testRankingWeights())
+			Expect(err).To(MatchError(service.ErrUnknownRanker))
+		})
+	})
+
+	Describe("DecayRanker", func() {
+		ranker := func() service.Ranker { return newTestRanker(service.RankDecay) }
+
+		It("should calculate high score for fresh post with high engagement", func() {
+			// Given: A fresh post with high engagement
+			post := &domain.Post{
+				LikesCount:    100,
+				CommentsCount: 50,
+				ViewsCount:    1000,
+				CreatedAt:     now.Add(-1 * time.Hour), // 1 hour old
+			}
+
+			// When: Calculate score
+			score := ranker().Score(post, now)
+
+			// Then: Score should be high due to fresh content and engagement
+			Expect(score).To(BeNumerically(">", 50))
+		})
+
+		It("should calculate lower score for old post", func() {
+			// Given: An old post
+			post := &domain.Post{
+				LikesCount:    100,
+				CommentsCount: 50,
+				ViewsCount:    1000,
+				CreatedAt:     now.Add(-24 * time.Hour), // 1 day old
+			}
+
+			// When: Calculate score
+			score := ranker().Score(post, now)
+
+			// Then: Score should be lower due to age
+			Expect(score).To(BeNumerically(">", 0))
+			Expect(score).To(BeNumerically("<", 50))
+		})
+
+		It("should score higher engagement higher", func() {
+			high := &domain.Post{LikesCount: 1000, CommentsCount: 200, ViewsCount: 1000, CreatedAt: now.Add(-time.Hour)}
+			low := &domain.Post{LikesCount: 10, CommentsCount: 2, ViewsCount: 1000, CreatedAt: now.Add(-time.Hour)}
+
+			Expect(ranker().Score(high, now)).To(BeNumerically(">", ranker().Score(low, now)))
+		})
+
+		It("should handle zero values gracefully", func() {
+			post := &domain.Post{CreatedAt: now.Add(-time.Hour)}
+
+			Expect(ranker().Score(post, now)).To(BeNumerically(">=", 0))
+		})
+
+		It("should use weights from configuration", func() {
+			// Given: Weights that only count comments
+			weights := testRankingWeights()
+			weights.LikeWeight, weights.ViewWeight, weights.DecayRate = 0, 0, 0
+			ranker, err := service.NewRanker(service.RankDecay, weights)
+			Expect(err).NotTo(HaveOccurred())
+
+			post := &domain.Post{LikesCount: 100, CommentsCount: 4, ViewsCount: 1000, CreatedAt: now.Add(-time.Hour)}
+
+			// Then: Score is just the weighted comments
+			Expect(ranker.Score(post, now)).To(BeNumerically("~", 12.0, 1e-9))
+		})
+
+		It("should weight reaction types by their configured weights", func() {
+			// Given: Fire reactions weigh twice a like; hearts have no weight of their own
+			weights := testRankingWeights()
+			weights.CommentWeight, weights.ViewWeight, weights.DecayRate = 0, 0, 0
+			weights.ReactionWeights = map[string]float64{domain.ReactionFire: 4.0}
+			ranker, err := service.NewRanker(service.RankDecay, weights)
+			Expect(err).NotTo(HaveOccurred())
+
+			post := &domain.Post{
+				LikesCount:     6,
+				ReactionCounts: domain.ReactionCounts{domain.ReactionLike: 2, domain.ReactionHeart: 1, domain.ReactionFire: 3},
+				CreatedAt:      now.Add(-time.Hour),
+			}
+
+			// Then: 3 likes and hearts * 2.0 + 3 fires * 4.0
+			Expect(ranker.Score(post, now)).To(BeNumerically("~", 18.0, 1e-9))
+		})
+
+		It("should weight reactions missing from the breakdown as likes", func() {
+			weights := testRankingWeights()
+			weights.CommentWeight, weights.ViewWeight, weights.DecayRate = 0, 0, 0
+			weights.ReactionWeights = map[string]float64{domain.ReactionFire: 4.0}
+			ranker, err := service.NewRanker(service.RankDecay, weights)
+			Expect(err).NotTo(HaveOccurred())
+
+			post := &domain.Post{LikesCount: 5, CreatedAt: now.Add(-time.Hour)}
+
+			Expect(ranker.Score(post, now)).To(BeNumerically("~", 10.0, 1e-9))
+		})
+
+		It("should count saves as engagement", func() {
+			// Given: Weights that only count saves
+			weights := testRankingWeights()
+			weights.LikeWeight, weights.CommentWeight, weights.ViewWeight, weights.DecayRate = 0, 0, 0, 0
+			ranker, err := service.NewRanker(service.RankDecay, weights)
+			Expect(err).NotTo(HaveOccurred())
+
+			post := &domain.Post{LikesCount: 10, SavesCount: 3, CreatedAt: now.Add(-time.Hour)}
+
+			// Then: Score is just the weighted saves
+			Expect(ranker.Score(post, now)).To(BeNumerically("~", 12.0, 1e-9))
+		})
+	})
+
+	Describe("ChronologicalRanker", func() {
+		It("should rank newer posts first regardless of engagement", func() {
+			ranker := newTestRanker(service.RankChronological)
+			older := &domain.Post{LikesCount: 1000, CreatedAt: now.Add(-time.Hour)}
+			newer := &domain.Post{CreatedAt: now.Add(-time.Minute)}
+
+			Expect(ranker.Score(newer, now)).To(BeNumerically(">", ranker.Score(older, now)))
+		})
+	})
+
+	Describe("GravityRanker", func() {
+		It("should decay with age at equal engagement", func() {
+			ranker := newTestRanker(service.RankGravity)
+			fresh := &domain.Post{LikesCount: 10, CreatedAt: now.Add(-time.Hour)}
+			stale := &domain.Post{LikesCount: 10, CreatedAt: now.Add(-10 * time.Hour)}
+
+			Expect(ranker.Score(fresh, now)).To(BeNumerically(">", ranker.Score(stale, now)))
+		})
+
+		It("should match the Hacker News formula", func() {
+			ranker := newTestRanker(service.RankGravity)
+			// 10 likes * 2.0 = 20 points, 2 hours old: 20 / (2+2)^1.8
+			post := &domain.Post{LikesCount: 10, CreatedAt: now.Add(-2 * time.Hour)}
+
+			Expect(ranker.Score(post, now)).To(BeNumerically("~", 1.6494, 1e-4))
+		})
+	})
+
+	Describe("WilsonRanker", func() {
+		It("should prefer a confident engagement rate over a lucky one", func() {
+			ranker := newTestRanker(service.RankWilson)
+			// 1 of 1 views engaged vs 90 of 100
+			lucky := &domain.Post{LikesCount: 1, ViewsCount: 1, CreatedAt: now}
+			confident := &domain.Post{LikesCount: 90, ViewsCount: 100, CreatedAt: now}
+
+			Expect(ranker.Score(confident, now)).To(BeNumerically(">", ranker.Score(lucky, now)))
+		})
+
+		It("should stay within [0, 1]", func() {
+			ranker := newTestRanker(service.RankWilson)
+			for _, post := range []*domain.Post{
+				{CreatedAt: now},
+				{LikesCount: 5, CreatedAt: now},
+				{LikesCount: 5, CommentsCount: 5, ViewsCount: 3, CreatedAt: now},
+				{ViewsCount: 1000, CreatedAt: now},
+			} {
+				score := ranker.Score(post, now)
+				Expect(score).To(BeNumerically(">=", 0))
+				Expect(score).To(BeNumerically("<=", 1))
+			}
+		})
+	})
+})
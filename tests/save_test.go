@@ -0,0 +1,287 @@
+package tests
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+
+	"github.com/rodolfodpk/instagrano/internal/domain"
+	"github.com/rodolfodpk/instagrano/internal/dto"
+	postgresRepo "github.com/rodolfodpk/instagrano/internal/repository/postgres"
+	"github.com/rodolfodpk/instagrano/internal/service"
+)
+
+var _ = Describe("Saves", func() {
+	var (
+		saveService *service.SaveService
+		postRepo    postgresRepo.PostRepository
+		user        *domain.User
+	)
+
+	BeforeEach(func() {
+		postRepo = postgresRepo.NewPostRepository(sharedContainers.DB)
+		saveService = service.NewSaveService(postgresRepo.NewSaveRepository(sharedContainers.DB), postRepo, zap.NewNop())
+		user = createTestUser(sharedContainers.DB, "saver", "saver@example.com")
+	})
+
+	postIDs := func(posts []*domain.Post) []uint {
+		ids := make([]uint, len(posts))
+		for i, post := range posts {
+			ids[i] = post.ID
+		}
+		return ids
+	}
+
+	Describe("SavePost", func() {
+		It("should save a post once and count it for ranking", func() {
+			// Given: A post
+			post := createTestPost(sharedContainers.DB, user.ID, "Saved", "Caption")
+
+			// When: Saving it twice
+			Expect(saveService.SavePost(user.ID, post.ID, nil)).To(Succeed())
+			Expect(saveService.SavePost(user.ID, post.ID, nil)).To(Succeed())
+
+			// Then: It is counted once and shows as saved
+			saved, err := postRepo.FindByID(post.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(saved.SavesCount).To(Equal(1))
+
+			// Then: The count is kept out of the post's JSON, which events and caches reuse
+			data, err := json.Marshal(saved)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).NotTo(ContainSubstring("saves_count"))
+
+			states, err := postRepo.FindViewerStates(user.ID, []uint{post.ID})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(states[post.ID].Saved).To(BeTrue())
+
+			// When: Unsaving it twice
+			Expect(saveService.UnsavePost(user.ID, post.ID)).To(Succeed())
+			Expect(saveService.UnsavePost(user.ID, post.ID)).To(Succeed())
+
+			// Then: The count is back to zero
+			saved, err = postRepo.FindByID(post.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(saved.SavesCount).To(Equal(0))
+		})
+
+		It("should move a saved post between collections", func() {
+			// Given: Two collections and a post saved in the first
+			trips, err := saveService.CreateCollection(user.ID, "Trips")
+			Expect(err).NotTo(HaveOccurred())
+			food, err := saveService.CreateCollection(user.ID, "Food")
+			Expect(err).NotTo(HaveOccurred())
+			post := createTestPost(sharedContainers.DB, user.ID, "Beach", "Caption")
+			Expect(saveService.SavePost(user.ID, post.ID, &trips.ID)).To(Succeed())
+
+			// When: Saving it in the second
+			Expect(saveService.SavePost(user.ID, post.ID, &food.ID)).To(Succeed())
+
+			// Then: Only the second collection holds it
+			posts, _, err := saveService.GetCollectionPosts(user.ID, trips.ID, 10, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(posts).To(BeEmpty())
+
+			posts, _, err = saveService.GetCollectionPosts(user.ID, food.ID, 10, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(postIDs(posts)).To(Equal([]uint{post.ID}))
+
+			collections, err := saveService.ListCollections(user.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(collections).To(HaveLen(2))
+			Expect(collections[0].PostsCount).To(Equal(0))
+			Expect(collections[1].PostsCount).To(Equal(1))
+		})
+
+		It("should reject unknown posts and other users' collections", func() {
+			other := createTestUser(sharedContainers.DB, "other", "other@example.com")
+			theirs, err := saveService.CreateCollection(other.ID, "Private")
+			Expect(err).NotTo(HaveOccurred())
+			post := createTestPost(sharedContainers.DB, user.ID, "Mine", "Caption")
+
+			Expect(saveService.SavePost(user.ID, 9999, nil)).To(MatchError(service.ErrPostNotFound))
+			Expect(saveService.SavePost(user.ID, post.ID, &theirs.ID)).To(MatchError(service.ErrCollectionNotFound))
+		})
+
+		It("should name the constraints violated by rows deleted concurrently", func() {
+			// Given: A collection deleted after it was checked
+			saveRepo := postgresRepo.NewSaveRepository(sharedContainers.DB)
+			trips, err := saveService.CreateCollection(user.ID, "Trips")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(saveRepo.DeleteCollection(trips.ID)).To(Succeed())
+			post := createTestPost(sharedContainers.DB, user.ID, "Beach", "Caption")
+
+			// When: Saving into it, or saving a missing post
+			_, collectionErr := saveRepo.Save(user.ID, post.ID, &trips.ID)
+			_, postErr := saveRepo.Save(user.ID, 9999, nil)
+
+			// Then: The violated constraints tell which one is gone
+			Expect(postgresRepo.ViolatedConstraint(collectionErr)).To(Equal(postgresRepo.ConstraintSaveCollection))
+			Expect(postgresRepo.ViolatedConstraint(postErr)).To(Equal(postgresRepo.ConstraintSavePost))
+
+			// Then: A duplicate collection name is reported by its constraint too
+			_, err = sharedContainers.DB.Exec(`INSERT INTO collections (user_id, name) VALUES ($1, 'Food'), ($1, 'Food')`, user.ID)
+			Expect(postgresRepo.ViolatedConstraint(err)).To(Equal(postgresRepo.ConstraintCollectionName))
+			Expect(postgresRepo.ViolatedConstraint(nil)).To(BeEmpty())
+
+			// Then: Renaming a deleted collection is told apart from a taken name
+			renamed, err := saveRepo.RenameCollection(trips.ID, "Travel")
+			Expect(renamed).To(BeFalse())
+			Expect(err).To(MatchError(sql.ErrNoRows))
+		})
+	})
+
+	Describe("Listing", func() {
+		It("should page saved posts, most recently saved first", func() {
+			// Given: Three posts saved oldest first, one in a collection
+			trips, err := saveService.CreateCollection(user.ID, "Trips")
+			Expect(err).NotTo(HaveOccurred())
+			var posts []*domain.Post
+			for i := 0; i < 3; i++ {
+				posts = append(posts, createTestPost(sharedContainers.DB, user.ID, fmt.Sprintf("Post%d", i), "Caption"))
+			}
+			Expect(saveService.SavePost(user.ID, posts[2].ID, nil)).To(Succeed())
+			Expect(saveService.SavePost(user.ID, posts[0].ID, &trips.ID)).To(Succeed())
+			Expect(saveService.SavePost(user.ID, posts[1].ID, nil)).To(Succeed())
+
+			// When: Paging every saved post two at a time
+			page, nextCursor, err := saveService.GetSavedPosts(user.ID, 2, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(postIDs(page)).To(Equal([]uint{posts[1].ID, posts[0].ID}))
+			Expect(nextCursor).NotTo(BeEmpty())
+
+			// Then: The last page holds the first save
+			page, nextCursor, err = saveService.GetSavedPosts(user.ID, 2, nextCursor)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(postIDs(page)).To(Equal([]uint{posts[2].ID}))
+			Expect(nextCursor).To(BeEmpty())
+		})
+
+		It("should reject invalid cursors", func() {
+			_, _, err := saveService.GetSavedPosts(user.ID, 10, "not-a-cursor")
+			Expect(err).To(MatchError(service.ErrInvalidCursor))
+		})
+	})
+
+	Describe("Collections", func() {
+		It("should keep names unique per user", func() {
+			_, err := saveService.CreateCollection(user.ID, "Trips")
+			Expect(err).NotTo(HaveOccurred())
+			food, err := saveService.CreateCollection(user.ID, "  Food ")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(food.Name).To(Equal("Food"))
+
+			_, err = saveService.CreateCollection(user.ID, "Trips")
+			Expect(err).To(MatchError(service.ErrCollectionNameTaken))
+			_, err = saveService.RenameCollection(user.ID, food.ID, "Trips")
+			Expect(err).To(MatchError(service.ErrCollectionNameTaken))
+			_, err = saveService.CreateCollection(user.ID, "   ")
+			Expect(err).To(MatchError(service.ErrInvalidCollectionName))
+
+			// Another user may reuse the name
+			other := createTestUser(sharedContainers.DB, "other", "other@example.com")
+			_, err = saveService.CreateCollection(other.ID, "Trips")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should rename collections", func() {
+			trips, err := saveService.CreateCollection(user.ID, "Trips")
+			Expect(err).NotTo(HaveOccurred())
+
+			renamed, err := saveService.RenameCollection(user.ID, trips.ID, "Travel")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(renamed.Name).To(Equal("Travel"))
+
+			collections, err := saveService.ListCollections(user.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(collections).To(HaveLen(1))
+			Expect(collections[0].Name).To(Equal("Travel"))
+		})
+
+		It("should keep posts saved when their collection is deleted", func() {
+			// Given: A post saved in a collection
+			trips, err := saveService.CreateCollection(user.ID, "Trips")
+			Expect(err).NotTo(HaveOccurred())
+			post := createTestPost(sharedContainers.DB, user.ID, "Beach", "Caption")
+			Expect(saveService.SavePost(user.ID, post.ID, &trips.ID)).To(Succeed())
+
+			// When: Deleting the collection
+			Expect(saveService.DeleteCollection(user.ID, trips.ID)).To(Succeed())
+
+			// Then: The collection is gone but the post is still saved
+			_, _, err = saveService.GetCollectionPosts(user.ID, trips.ID, 10, "")
+			Expect(err).To(MatchError(service.ErrCollectionNotFound))
+
+			posts, _, err := saveService.GetSavedPosts(user.ID, 10, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(postIDs(posts)).To(Equal([]uint{post.ID}))
+		})
+
+		It("should hide other users' collections", func() {
+			other := createTestUser(sharedContainers.DB, "other", "other@example.com")
+			theirs, err := saveService.CreateCollection(other.ID, "Private")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = saveService.RenameCollection(user.ID, theirs.ID, "Mine")
+			Expect(err).To(MatchError(service.ErrCollectionNotFound))
+			Expect(saveService.DeleteCollection(user.ID, theirs.ID)).To(MatchError(service.ErrCollectionNotFound))
+		})
+	})
+
+	Describe("HTTP", func() {
+		It("should save into a collection and show saved_by_me", func() {
+			app, _, cleanup := setupTestApp()
+			defer cleanup()
+
+			token := registerAndLogin(app, "collector", "collector@example.com", "password123")
+			postData := createTestPostWithSSE(app, token, "Keeper", "Worth keeping")
+			postID := uint(postData["id"].(float64))
+
+			// Given: A collection
+			req := httptest.NewRequest("POST", "/api/collections", strings.NewReader(`{"name": "Favorites"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+			resp, err := app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(201))
+			var collection domain.Collection
+			Expect(json.NewDecoder(resp.Body).Decode(&collection)).To(Succeed())
+
+			// When: Saving the post in it
+			body := fmt.Sprintf(`{"collection_id": %d}`, collection.ID)
+			req = httptest.NewRequest("PUT", fmt.Sprintf("/api/posts/%d/save", postID), strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+			resp, err = app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(200))
+
+			// Then: The collection lists the post as saved by the caller
+			req = httptest.NewRequest("GET", fmt.Sprintf("/api/collections/%d/posts", collection.ID), nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			resp, err = app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(200))
+
+			var feed dto.FeedResponse
+			Expect(json.NewDecoder(resp.Body).Decode(&feed)).To(Succeed())
+			Expect(feed.Posts).To(HaveLen(1))
+			Expect(feed.Posts[0].ID).To(Equal(postID))
+			Expect(feed.Posts[0].SavedByMe).To(BeTrue())
+			Expect(feed.HasMore).To(BeFalse())
+
+			// Then: Unknown collections are not found
+			req = httptest.NewRequest("GET", "/api/collections/9999/posts", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			resp, err = app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(404))
+		})
+	})
+})
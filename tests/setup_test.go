@@ -184,6 +184,25 @@ func runSharedMigrations(db *sql.DB) {
 		"../migrations/004_create_comments.up.sql",
 		"../migrations/005_create_post_views.up.sql",
 		"../migrations/006_optimize_indexes.up.sql",
+		"../migrations/007_create_refresh_tokens.up.sql",
+		"../migrations/008_create_follows.up.sql",
+		"../migrations/009_create_outbox.up.sql",
+		"../migrations/010_create_webhooks.up.sql",
+		"../migrations/011_create_notifications.up.sql",
+		"../migrations/012_add_comment_replies.up.sql",
+		"../migrations/013_add_comment_edits.up.sql",
+		"../migrations/014_create_comment_likes.up.sql",
+		"../migrations/015_add_comment_top_index.up.sql",
+		"../migrations/016_add_post_reactions.up.sql",
+		"../migrations/017_add_likers_list.up.sql",
+		"../migrations/018_add_comments_user_post_index.up.sql",
+		"../migrations/019_create_post_edits.up.sql",
+		"../migrations/020_create_post_media.up.sql",
+		"../migrations/021_create_hashtags.up.sql",
+		"../migrations/022_create_mentions.up.sql",
+		"../migrations/023_create_saves.up.sql",
+		"../migrations/024_outbox_dead_letter.up.sql",
+		"../migrations/025_rename_hide_likers.up.sql",
 	}
 
 	for _, migration := range migrations {
@@ -205,7 +224,22 @@ func truncateAllTables(db *sql.DB) {
 
 	// Truncate tables in order to respect foreign key constraints
 	tables := []string{
+		"comment_likes",
+		"notification_actors",
+		"notifications",
+		"webhook_deliveries",
+		"webhooks",
+		"outbox",
+		"follows",
+		"refresh_tokens",
 		"post_views", // Delete in order to respect foreign keys
+		"post_edits",
+		"post_media",
+		"saves",
+		"collections",
+		"mentions",
+		"post_hashtags",
+		"hashtags",
 		"comments",
 		"likes",
 		"posts",
@@ -224,6 +258,17 @@ func truncateAllTables(db *sql.DB) {
 		"likes_id_seq",
 		"comments_id_seq",
 		"post_views_id_seq",
+		"refresh_tokens_id_seq",
+		"outbox_id_seq",
+		"webhooks_id_seq",
+		"webhook_deliveries_id_seq",
+		"notifications_id_seq",
+		"comment_likes_id_seq",
+		"post_edits_id_seq",
+		"post_media_id_seq",
+		"hashtags_id_seq",
+		"mentions_id_seq",
+		"collections_id_seq",
 	}
 
 	for _, seq := range sequences {
@@ -264,6 +309,25 @@ func runMigrations(t *testing.T, db *sql.DB) {
 		"../migrations/004_create_comments.up.sql",
 		"../migrations/005_create_post_views.up.sql",
 		"../migrations/006_optimize_indexes.up.sql",
+		"../migrations/007_create_refresh_tokens.up.sql",
+		"../migrations/008_create_follows.up.sql",
+		"../migrations/009_create_outbox.up.sql",
+		"../migrations/010_create_webhooks.up.sql",
+		"../migrations/011_create_notifications.up.sql",
+		"../migrations/012_add_comment_replies.up.sql",
+		"../migrations/013_add_comment_edits.up.sql",
+		"../migrations/014_create_comment_likes.up.sql",
+		"../migrations/015_add_comment_top_index.up.sql",
+		"../migrations/016_add_post_reactions.up.sql",
+		"../migrations/017_add_likers_list.up.sql",
+		"../migrations/018_add_comments_user_post_index.up.sql",
+		"../migrations/019_create_post_edits.up.sql",
+		"../migrations/020_create_post_media.up.sql",
+		"../migrations/021_create_hashtags.up.sql",
+		"../migrations/022_create_mentions.up.sql",
+		"../migrations/023_create_saves.up.sql",
+		"../migrations/024_outbox_dead_letter.up.sql",
+		"../migrations/025_rename_hide_likers.up.sql",
 	}
 
 	for _, migration := range migrations {
@@ -277,7 +341,9 @@ func runMigrations(t *testing.T, db *sql.DB) {
 
 // setupTestApp creates Fiber app with shared Testcontainers dependencies
 func setupTestApp() (*fiber.App, *TestContainers, func()) {
+	hubCtx, stopHub := context.WithCancel(context.Background())
 	cleanup := func() {
+		stopHub()
 		truncateAllTables(sharedContainers.DB)
 
 		// Flush Redis cache
@@ -291,11 +357,13 @@ func setupTestApp() (*fiber.App, *TestContainers, func()) {
 	}
 
 	cfg := &config.Config{
-		JWTSecret:  "test-secret",
-		CacheTTL:   5 * time.Minute,
-		S3Endpoint: sharedContainers.S3Endpoint,
-		S3Region:   "us-east-1",
-		S3Bucket:   "test-bucket",
+		JWTSecret:       "test-secret",
+		CacheTTL:        5 * time.Minute,
+		S3Endpoint:      sharedContainers.S3Endpoint,
+		S3Region:        "us-east-1",
+		S3Bucket:        "test-bucket",
+		DefaultPageSize: 20,
+		MaxPageSize:     100,
 	}
 
 	// Initialize repositories
@@ -303,17 +371,34 @@ func setupTestApp() (*fiber.App, *TestContainers, func()) {
 	postRepo := postgresRepo.NewPostRepository(sharedContainers.DB)
 	likeRepo := postgresRepo.NewLikeRepository(sharedContainers.DB)
 	commentRepo := postgresRepo.NewCommentRepository(sharedContainers.DB)
+	refreshTokenRepo := postgresRepo.NewRefreshTokenRepository(sharedContainers.DB)
+	followRepo := postgresRepo.NewFollowRepository(sharedContainers.DB)
+	outboxRepo := postgresRepo.NewOutboxRepository(sharedContainers.DB)
+	webhookRepo := postgresRepo.NewWebhookRepository(sharedContainers.DB)
+	notificationRepo := postgresRepo.NewNotificationRepository(sharedContainers.DB)
+	mentionRepo := postgresRepo.NewMentionRepository(sharedContainers.DB)
+	saveRepo := postgresRepo.NewSaveRepository(sharedContainers.DB)
 
 	// Initialize services
-	authService := service.NewAuthService(userRepo, cfg.JWTSecret)
-	feedService := service.NewFeedService(postRepo, sharedContainers.Cache, cfg.CacheTTL)
+	authService := service.NewAuthService(userRepo, refreshTokenRepo, sharedContainers.Cache, cfg.JWTSecret, 15*time.Minute, 24*time.Hour)
+	feedService := createFeedService(postRepo, 1000)
 
 	// Initialize event publisher
 	logger, _ := zap.NewProduction()
 	defer logger.Sync()
-	eventPublisher := events.NewPublisher(sharedContainers.Cache, logger)
-
-	interactionService := service.NewInteractionService(likeRepo, commentRepo, postRepo, sharedContainers.Cache, eventPublisher, logger)
+	eventBus := events.NewStreamBus(sharedContainers.Cache, 1000, logger)
+	eventPublisher := events.NewPublisher(eventBus, logger)
+	eventHub := events.NewHub(eventBus, 64, logger)
+	go eventHub.Run(hubCtx)
+	outboxRelay := service.NewOutboxRelay(outboxRepo, eventPublisher, 100, 20, 50*time.Millisecond, time.Hour, logger)
+	go outboxRelay.Run(hubCtx)
+	webhookSender := service.NewWebhookSender(5*time.Second, true)
+	webhookWorker := service.NewWebhookWorker(webhookRepo, eventBus, sharedContainers.Cache, webhookSender, 3, 50*time.Millisecond, logger)
+	go webhookWorker.Run(hubCtx)
+
+	notificationService := service.NewNotificationService(notificationRepo, logger)
+	mentionService := service.NewMentionService(userRepo, mentionRepo, logger)
+	interactionService := service.NewInteractionService(likeRepo, commentRepo, postRepo, sharedContainers.Cache, notificationService, mentionService, logger)
 
 	// Initialize real S3 storage for testing
 	webclientConfig := webclient.Config{
@@ -336,13 +421,25 @@ func setupTestApp() (*fiber.App, *TestContainers, func()) {
 		panic(fmt.Sprintf("Failed to create S3 bucket: %v", err))
 	}
 
-	postService := service.NewPostService(postRepo, mediaStorage, sharedContainers.Cache, cfg.CacheTTL)
+	timelineService := service.NewTimelineService(followRepo, userRepo, postRepo, sharedContainers.Cache, 10000, 800, logger)
+	hashtagService := service.NewHashtagService(sharedContainers.Cache, logger)
+	postService := service.NewPostService(postRepo, mediaStorage, sharedContainers.Cache, cfg.CacheTTL, timelineService, hashtagService, mentionService)
+	followService := service.NewFollowService(followRepo, userRepo, timelineService, logger)
+	userService := service.NewUserService(userRepo, logger)
+	webhookService := service.NewWebhookService(webhookRepo, webhookSender, logger)
+	saveService := service.NewSaveService(saveRepo, postRepo, logger)
 
 	// Initialize handlers
 	authHandler := handler.NewAuthHandler(authService)
-	feedHandler := handler.NewFeedHandler(feedService, cfg)
-	postHandler := handler.NewPostHandler(postService, eventPublisher, logger)
-	interactionHandler := handler.NewInteractionHandler(interactionService, eventPublisher, logger)
+	feedHandler := handler.NewFeedHandler(feedService, timelineService, interactionService, cfg)
+	postHandler := handler.NewPostHandler(postService, interactionService, eventPublisher, logger)
+	interactionHandler := handler.NewInteractionHandler(interactionService, cfg, logger)
+	userHandler := handler.NewUserHandler(userService, followService, cfg, logger)
+	webhookHandler := handler.NewWebhookHandler(webhookService, cfg, logger)
+	notificationHandler := handler.NewNotificationHandler(notificationService, cfg, logger)
+	hashtagHandler := handler.NewHashtagHandler(hashtagService, logger)
+	saveHandler := handler.NewSaveHandler(saveService, interactionService, cfg, logger)
+	sseHandler := handler.NewSSEHandler(eventHub, authService, logger)
 
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
@@ -355,16 +452,54 @@ func setupTestApp() (*fiber.App, *TestContainers, func()) {
 	api := app.Group("/api")
 	api.Post("/auth/register", authHandler.Register)
 	api.Post("/auth/login", authHandler.Login)
+	api.Post("/auth/refresh", authHandler.Refresh)
 
 	// Serve static test image
 	app.Static("/test/image", "./web/public/test-image.jpg")
 
-	protected := api.Group("/", middleware.JWT(cfg.JWTSecret))
+	protected := api.Group("/", middleware.JWT(cfg.JWTSecret, authService))
+	protected.Post("/auth/logout", authHandler.Logout)
+	protected.Get("/events/stream", sseHandler.Stream)
 	protected.Get("/feed", feedHandler.GetFeed)
 	protected.Post("/posts", postHandler.CreatePost)
 	protected.Get("/posts/:id", postHandler.GetPost)
+	protected.Patch("/posts/:id", postHandler.UpdatePost)
 	protected.Post("/posts/:id/like", interactionHandler.LikePost)
+	protected.Put("/posts/:id/like", interactionHandler.PutLike)
+	protected.Delete("/posts/:id/like", interactionHandler.DeleteLike)
+	protected.Put("/posts/:id/reaction", interactionHandler.PutReaction)
+	protected.Delete("/posts/:id/reaction", interactionHandler.DeleteReaction)
+	protected.Get("/posts/:id/likes", interactionHandler.GetLikers)
+	protected.Put("/posts/:id/save", saveHandler.SavePost)
+	protected.Delete("/posts/:id/save", saveHandler.UnsavePost)
 	protected.Post("/posts/:id/comment", interactionHandler.CommentPost)
+	protected.Get("/posts/:id/comments", interactionHandler.GetComments)
+	protected.Get("/posts/:id/comments/:commentId/replies", interactionHandler.GetReplies)
+	protected.Put("/posts/:id/comments/:commentId", interactionHandler.UpdateComment)
+	protected.Delete("/posts/:id/comments/:commentId", interactionHandler.DeleteComment)
+	protected.Post("/posts/:id/comments/:commentId/like", interactionHandler.LikeComment)
+	protected.Delete("/posts/:id/comments/:commentId/like", interactionHandler.UnlikeComment)
+	protected.Put("/users/me/settings", userHandler.UpdateSettings)
+	protected.Get("/users/me/saved", saveHandler.GetSavedPosts)
+	protected.Post("/users/:id/follow", userHandler.Follow)
+	protected.Delete("/users/:id/follow", userHandler.Unfollow)
+	protected.Get("/users/:id/posts", feedHandler.GetUserPosts)
+	protected.Get("/hashtags/trending", hashtagHandler.GetTrending)
+	protected.Get("/hashtags/:tag/posts", feedHandler.GetHashtagPosts)
+	protected.Get("/collections", saveHandler.ListCollections)
+	protected.Post("/collections", saveHandler.CreateCollection)
+	protected.Patch("/collections/:id", saveHandler.RenameCollection)
+	protected.Delete("/collections/:id", saveHandler.DeleteCollection)
+	protected.Get("/collections/:id/posts", saveHandler.GetCollectionPosts)
+	protected.Post("/webhooks", webhookHandler.CreateWebhook)
+	protected.Get("/webhooks", webhookHandler.ListWebhooks)
+	protected.Delete("/webhooks/:id", webhookHandler.DeleteWebhook)
+	protected.Post("/webhooks/:id/test", webhookHandler.TestWebhook)
+	protected.Get("/webhooks/:id/deliveries", webhookHandler.ListDeliveries)
+	protected.Get("/notifications", notificationHandler.ListNotifications)
+	protected.Get("/notifications/unread_count", notificationHandler.UnreadCount)
+	protected.Post("/notifications/read_all", notificationHandler.MarkAllRead)
+	protected.Post("/notifications/:id/read", notificationHandler.MarkRead)
 
 	return app, sharedContainers, cleanup
 }
@@ -483,7 +618,8 @@ func connectSSE(app *fiber.App, token string) (<-chan SSEEvent, func()) {
 	done := make(chan bool)
 
 	// Create SSE request
-	req := httptest.NewRequest("GET", "/api/events/stream?token="+token, nil)
+	req := httptest.NewRequest("GET", "/api/events/stream", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
 
 	// Start SSE connection in goroutine
 	go func() {
@@ -615,7 +751,7 @@ func marshalJSON(data interface{}) string {
 
 func createTestJWT(userID uint) (string, error) {
 	userRepo := postgresRepo.NewUserRepository(sharedContainers.DB)
-	authService := service.NewAuthService(userRepo, "test-secret")
+	authService := createAuthService()
 
 	// Get user to get username
 	user, err := userRepo.FindByID(userID)
@@ -0,0 +1,206 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/rodolfodpk/instagrano/internal/domain"
+	postgresRepo "github.com/rodolfodpk/instagrano/internal/repository/postgres"
+	"github.com/rodolfodpk/instagrano/internal/service"
+	"go.uber.org/zap"
+)
+
+// Helper function to create a timeline service and a post service that fans out into it
+func createTimelineService(celebrityThreshold int) (*service.TimelineService, *service.PostService) {
+	followRepo := postgresRepo.NewFollowRepository(sharedContainers.DB)
+	userRepo := postgresRepo.NewUserRepository(sharedContainers.DB)
+	postRepo := postgresRepo.NewPostRepository(sharedContainers.DB)
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+	timelineService := service.NewTimelineService(followRepo, userRepo, postRepo, sharedContainers.Cache, celebrityThreshold, 800, logger)
+	postService := service.NewPostService(postRepo, NewMockMediaStorage(), sharedContainers.Cache, 5*time.Minute, timelineService, nil, nil)
+	return timelineService, postService
+}
+
+func createTimelinePost(postService *service.PostService, userID uint, title string) *domain.Post {
+	post, err := postService.CreatePost(userID, title, "", domain.MediaTypeImage, strings.NewReader("image"), "test.jpg")
+	Expect(err).NotTo(HaveOccurred())
+	return post
+}
+
+var _ = Describe("TimelineService", func() {
+	var followService *service.FollowService
+
+	BeforeEach(func() {
+		followService = createFollowService()
+	})
+
+	postIDs := func(posts []interface{}) []uint {
+		ids := make([]uint, len(posts))
+		for i, p := range posts {
+			ids[i] = p.(*domain.Post).ID
+		}
+		return ids
+	}
+
+	Describe("GetFeed", func() {
+		It("should contain posts of followed users and own posts, newest first", func() {
+			// Given: Alice follows Bob, Carol is not followed
+			timelineService, postService := createTimelineService(10000)
+			alice := createTestUser(sharedContainers.DB, "alice", "alice@example.com")
+			bob := createTestUser(sharedContainers.DB, "bob", "bob@example.com")
+			carol := createTestUser(sharedContainers.DB, "carol", "carol@example.com")
+			_, err := followService.Follow(alice.ID, bob.ID, alice.Username)
+			Expect(err).NotTo(HaveOccurred())
+
+			// When: Everyone posts
+			bobPost := createTimelinePost(postService, bob.ID, "Bob post")
+			createTimelinePost(postService, carol.ID, "Carol post")
+			alicePost := createTimelinePost(postService, alice.ID, "Alice post")
+
+			// Then: Alice's timeline has her own post and Bob's, not Carol's
+			result, err := timelineService.GetFeed(alice.ID, 10, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(postIDs(result.Posts)).To(Equal([]uint{alicePost.ID, bobPost.ID}))
+			Expect(result.HasMore).To(BeFalse())
+		})
+
+		It("should paginate with the feed cursor", func() {
+			// Given: Alice follows Bob who posted three times
+			timelineService, postService := createTimelineService(10000)
+			alice := createTestUser(sharedContainers.DB, "alice", "alice@example.com")
+			bob := createTestUser(sharedContainers.DB, "bob", "bob@example.com")
+			_, err := followService.Follow(alice.ID, bob.ID, alice.Username)
+			Expect(err).NotTo(HaveOccurred())
+			first := createTimelinePost(postService, bob.ID, "First")
+			second := createTimelinePost(postService, bob.ID, "Second")
+			third := createTimelinePost(postService, bob.ID, "Third")
+
+			// When: Reading pages of two
+			page1, err := timelineService.GetFeed(alice.ID, 2, "")
+			Expect(err).NotTo(HaveOccurred())
+			page2, err := timelineService.GetFeed(alice.ID, 2, page1.NextCursor)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Then: Posts are split across pages without gaps or duplicates
+			Expect(postIDs(page1.Posts)).To(Equal([]uint{third.ID, second.ID}))
+			Expect(page1.HasMore).To(BeTrue())
+			Expect(postIDs(page2.Posts)).To(Equal([]uint{first.ID}))
+			Expect(page2.HasMore).To(BeFalse())
+		})
+
+		It("should merge posts of celebrities on read", func() {
+			// Given: Bob has more followers than the threshold
+			timelineService, postService := createTimelineService(1)
+			alice := createTestUser(sharedContainers.DB, "alice", "alice@example.com")
+			bob := createTestUser(sharedContainers.DB, "bob", "bob@example.com")
+			dave := createTestUser(sharedContainers.DB, "dave", "dave@example.com")
+			for _, u := range []*domain.User{alice, dave} {
+				_, err := followService.Follow(u.ID, bob.ID, u.Username)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			// When: Bob posts
+			bobPost := createTimelinePost(postService, bob.ID, "Celebrity post")
+
+			// Then: The post was not fanned out to Alice's timeline...
+			members, err := sharedContainers.Cache.ZRevRangeByScore(context.Background(), fmt.Sprintf("timeline:%d", alice.ID), 1e18, 0, 10)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(members).To(BeEmpty())
+
+			// ...but still shows up in her feed
+			result, err := timelineService.GetFeed(alice.ID, 10, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(postIDs(result.Posts)).To(Equal([]uint{bobPost.ID}))
+		})
+
+		It("should reject an invalid cursor", func() {
+			timelineService, _ := createTimelineService(10000)
+			alice := createTestUser(sharedContainers.DB, "alice", "alice@example.com")
+
+			_, err := timelineService.GetFeed(alice.ID, 10, "not-a-cursor!")
+
+			Expect(err).To(MatchError(service.ErrInvalidCursor))
+		})
+	})
+
+	Describe("RemovePost", func() {
+		It("should remove a deleted post from follower timelines", func() {
+			// Given: Alice follows Bob who posted
+			timelineService, postService := createTimelineService(10000)
+			alice := createTestUser(sharedContainers.DB, "alice", "alice@example.com")
+			bob := createTestUser(sharedContainers.DB, "bob", "bob@example.com")
+			_, err := followService.Follow(alice.ID, bob.ID, alice.Username)
+			Expect(err).NotTo(HaveOccurred())
+			post := createTimelinePost(postService, bob.ID, "Short lived")
+
+			// When: Bob deletes the post
+			Expect(postService.DeletePost(post.ID, bob.ID)).To(Succeed())
+
+			// Then: It is gone from Alice's materialized timeline
+			members, err := sharedContainers.Cache.ZRevRangeByScore(context.Background(), fmt.Sprintf("timeline:%d", alice.ID), 1e18, 0, 10)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(members).To(BeEmpty())
+
+			result, err := timelineService.GetFeed(alice.ID, 10, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Posts).To(BeEmpty())
+		})
+	})
+
+	Describe("Follow changes", func() {
+		var (
+			timelineService       *service.TimelineService
+			postService           *service.PostService
+			timelineFollowService *service.FollowService
+			alice, bob            *domain.User
+		)
+
+		BeforeEach(func() {
+			timelineService, postService = createTimelineService(10000)
+			timelineFollowService = service.NewFollowService(
+				postgresRepo.NewFollowRepository(sharedContainers.DB),
+				postgresRepo.NewUserRepository(sharedContainers.DB),
+				timelineService, zap.NewNop())
+			alice = createTestUser(sharedContainers.DB, "alice", "alice@example.com")
+			bob = createTestUser(sharedContainers.DB, "bob", "bob@example.com")
+		})
+
+		It("should backfill the posts of a newly followed account", func() {
+			// Given: Bob posted before Alice followed him
+			older := createTimelinePost(postService, bob.ID, "Older")
+			newer := createTimelinePost(postService, bob.ID, "Newer")
+
+			// When: Alice follows Bob
+			_, err := timelineFollowService.Follow(alice.ID, bob.ID, alice.Username)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Then: Bob's existing posts are in Alice's timeline
+			result, err := timelineService.GetFeed(alice.ID, 10, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(postIDs(result.Posts)).To(Equal([]uint{newer.ID, older.ID}))
+		})
+
+		It("should drop the posts of an unfollowed account", func() {
+			// Given: Alice follows Bob who posted, and Alice posted too
+			_, err := timelineFollowService.Follow(alice.ID, bob.ID, alice.Username)
+			Expect(err).NotTo(HaveOccurred())
+			createTimelinePost(postService, bob.ID, "Bob post")
+			alicePost := createTimelinePost(postService, alice.ID, "Alice post")
+
+			// When: Alice unfollows Bob
+			_, err = timelineFollowService.Unfollow(alice.ID, bob.ID)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Then: Only Alice's own post is left
+			result, err := timelineService.GetFeed(alice.ID, 10, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(postIDs(result.Posts)).To(Equal([]uint{alicePost.ID}))
+		})
+	})
+})
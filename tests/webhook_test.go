@@ -0,0 +1,308 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+
+	"github.com/rodolfodpk/instagrano/internal/domain"
+	"github.com/rodolfodpk/instagrano/internal/events"
+	postgresRepo "github.com/rodolfodpk/instagrano/internal/repository/postgres"
+	"github.com/rodolfodpk/instagrano/internal/service"
+)
+
+// webhookRequest is a request received by a webhookReceiver
+type webhookRequest struct {
+	Header http.Header
+	Body   []byte
+}
+
+// webhookReceiver starts an HTTP server answering webhooks with status and
+// recording the requests it receives
+func webhookReceiver(status int) (*httptest.Server, chan webhookRequest) {
+	received := make(chan webhookRequest, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- webhookRequest{Header: r.Header.Clone(), Body: body}
+		w.WriteHeader(status)
+	}))
+	return server, received
+}
+
+// expectSigned checks the request's signature against the webhook secret
+func expectSigned(req webhookRequest, secret string) {
+	timestamp, err := strconv.ParseInt(req.Header.Get(service.WebhookTimestampHeader), 10, 64)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(req.Header.Get(service.WebhookSignatureHeader)).To(Equal(service.SignWebhookPayload(secret, timestamp, req.Body)))
+}
+
+var _ = Describe("Webhooks", func() {
+	var (
+		logger         *zap.Logger
+		webhookRepo    postgresRepo.WebhookRepository
+		webhookService *service.WebhookService
+		webhookWorker  *service.WebhookWorker
+	)
+
+	BeforeEach(func() {
+		logger, _ = zap.NewDevelopment()
+		webhookRepo = postgresRepo.NewWebhookRepository(sharedContainers.DB)
+		sender := service.NewWebhookSender(2*time.Second, true)
+		webhookService = service.NewWebhookService(webhookRepo, sender, logger)
+		webhookWorker = service.NewWebhookWorker(webhookRepo, events.NewMemoryBus(100), sharedContainers.Cache, sender, 2, time.Second, logger)
+	})
+
+	Describe("WebhookService", func() {
+		It("should register a webhook for every event type by default", func() {
+			user := createTestUser(sharedContainers.DB, "hookuser", "hook@example.com")
+
+			webhook, err := webhookService.Register(context.Background(), user.ID, "https://example.com/hooks", "", nil)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(webhook.ID).NotTo(BeZero())
+			Expect(webhook.Scope).To(Equal(domain.WebhookScopeUser))
+			Expect(webhook.EventTypes).To(ConsistOf("new_post", "post_liked", "post_commented", "post_deleted"))
+			Expect(webhook.Secret).To(HavePrefix("whsec_"))
+		})
+
+		It("should reject invalid webhooks", func() {
+			user := createTestUser(sharedContainers.DB, "hookuser", "hook@example.com")
+
+			_, err := webhookService.Register(context.Background(), user.ID, "ftp://example.com", "", nil)
+			Expect(err).To(MatchError(service.ErrInvalidWebhook))
+
+			_, err = webhookService.Register(context.Background(), user.ID, "https://example.com", "everyone", nil)
+			Expect(err).To(MatchError(service.ErrInvalidWebhook))
+
+			_, err = webhookService.Register(context.Background(), user.ID, "https://example.com", "", []string{"user_followed"})
+			Expect(err).To(MatchError(service.ErrInvalidWebhook))
+		})
+
+		It("should reject and refuse to reach non-public addresses", func() {
+			server, received := webhookReceiver(http.StatusOK)
+			defer server.Close()
+			user := createTestUser(sharedContainers.DB, "hookuser", "hook@example.com")
+			guarded := service.NewWebhookSender(2*time.Second, false)
+			guardedService := service.NewWebhookService(webhookRepo, guarded, logger)
+
+			// Registering loopback, private, link-local or unspecified hosts fails
+			for _, url := range []string{server.URL, "http://localhost:8080/hooks", "http://10.0.0.5/hooks",
+				"http://169.254.169.254/latest", "http://[::1]/hooks", "http://0.0.0.0/hooks"} {
+				_, err := guardedService.Register(context.Background(), user.ID, url, "", nil)
+				Expect(err).To(MatchError(service.ErrInvalidWebhook), url)
+			}
+
+			// A host that re-resolves to loopback after registering is blocked on dial
+			webhook := &domain.Webhook{URL: server.URL, Secret: "whsec_test"}
+			statusCode, err := guarded.Send(context.Background(), webhook, "ping", "1", []byte(`{}`))
+			Expect(err).To(MatchError(ContainSubstring("not publicly routable")))
+			Expect(statusCode).To(BeZero())
+			Consistently(received, 100*time.Millisecond).ShouldNot(Receive())
+		})
+
+		It("should hide other users' webhooks", func() {
+			owner := createTestUser(sharedContainers.DB, "owner", "owner@example.com")
+			other := createTestUser(sharedContainers.DB, "other", "other@example.com")
+			webhook, err := webhookService.Register(context.Background(), owner.ID, "https://example.com/hooks", "", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(webhookService.Delete(other.ID, webhook.ID)).To(MatchError(service.ErrWebhookNotFound))
+			Expect(webhookService.Delete(owner.ID, webhook.ID)).To(Succeed())
+
+			webhooks, err := webhookService.List(owner.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(webhooks).To(BeEmpty())
+		})
+
+		It("should test-fire a signed ping", func() {
+			server, received := webhookReceiver(http.StatusNoContent)
+			defer server.Close()
+			user := createTestUser(sharedContainers.DB, "hookuser", "hook@example.com")
+			webhook, err := webhookService.Register(context.Background(), user.ID, server.URL, "", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			result, err := webhookService.Test(context.Background(), user.ID, webhook.ID)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Err).NotTo(HaveOccurred())
+			Expect(result.StatusCode).To(Equal(http.StatusNoContent))
+
+			var req webhookRequest
+			Eventually(received).Should(Receive(&req))
+			Expect(req.Header.Get(service.WebhookEventHeader)).To(Equal("ping"))
+			expectSigned(req, webhook.Secret)
+		})
+	})
+
+	Describe("WebhookWorker", func() {
+		It("should queue events for matching webhooks only once", func() {
+			// Given: An author with a user webhook, a bystander with one, and an app webhook
+			author := createTestUser(sharedContainers.DB, "author", "author@example.com")
+			bystander := createTestUser(sharedContainers.DB, "bystander", "bystander@example.com")
+			liker := createTestUser(sharedContainers.DB, "liker", "liker@example.com")
+			post := createTestPost(sharedContainers.DB, author.ID, "Hooked", "Caption")
+
+			authorHook, _ := webhookService.Register(context.Background(), author.ID, "https://example.com/author", "", nil)
+			bystanderHook, _ := webhookService.Register(context.Background(), bystander.ID, "https://example.com/bystander", "", nil)
+			appHook, _ := webhookService.Register(context.Background(), bystander.ID, "https://example.com/app", domain.WebhookScopeApp, []string{"post_liked"})
+
+			// When: Someone likes the author's post, and the event is read twice
+			event := events.NewPostLikedEvent(post.ID, liker.ID, 1, 0)
+			event.ID = "1700000000000-0"
+			queued, err := webhookWorker.Enqueue(event)
+			Expect(err).NotTo(HaveOccurred())
+			requeued, err := webhookWorker.Enqueue(event)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Then: The author's and the app webhook get it once
+			Expect(queued).To(Equal(2))
+			Expect(requeued).To(BeZero())
+
+			for hook, count := range map[uint]int{authorHook.ID: 1, bystanderHook.ID: 0, appHook.ID: 1} {
+				deliveries, err := webhookRepo.FindDeliveries(hook, 10)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(deliveries).To(HaveLen(count))
+			}
+		})
+
+		It("should deliver signed events and record the attempt", func() {
+			server, received := webhookReceiver(http.StatusOK)
+			defer server.Close()
+			author := createTestUser(sharedContainers.DB, "author", "author@example.com")
+			post := createTestPost(sharedContainers.DB, author.ID, "Hooked", "Caption")
+			webhook, err := webhookService.Register(context.Background(), author.ID, server.URL, "", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			event := events.NewPostDeletedEvent(post.ID, author.ID)
+			event.ID = "1700000000000-0"
+			_, err = webhookWorker.Enqueue(event)
+			Expect(err).NotTo(HaveOccurred())
+
+			delivered, err := webhookWorker.DeliverDue(context.Background())
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(delivered).To(Equal(1))
+
+			var req webhookRequest
+			Eventually(received).Should(Receive(&req))
+			Expect(req.Header.Get(service.WebhookEventHeader)).To(Equal("post_deleted"))
+			expectSigned(req, webhook.Secret)
+
+			var payload events.Event
+			Expect(json.Unmarshal(req.Body, &payload)).To(Succeed())
+			Expect(payload.ID).To(Equal(event.ID))
+			Expect(payload.PostID).To(Equal(post.ID))
+
+			deliveries, err := webhookRepo.FindDeliveries(webhook.ID, 10)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deliveries[0].Status).To(Equal(domain.WebhookDeliveryDelivered))
+			Expect(deliveries[0].Attempts).To(Equal(1))
+			Expect(*deliveries[0].ResponseStatus).To(Equal(http.StatusOK))
+		})
+
+		It("should retry failed deliveries until the attempts run out", func() {
+			server, _ := webhookReceiver(http.StatusInternalServerError)
+			defer server.Close()
+			author := createTestUser(sharedContainers.DB, "author", "author@example.com")
+			post := createTestPost(sharedContainers.DB, author.ID, "Hooked", "Caption")
+			webhook, err := webhookService.Register(context.Background(), author.ID, server.URL, "", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			event := events.NewPostLikedEvent(post.ID, author.ID, 1, 0)
+			event.ID = "1700000000000-0"
+			_, err = webhookWorker.Enqueue(event)
+			Expect(err).NotTo(HaveOccurred())
+
+			// When: The first attempt fails
+			_, err = webhookWorker.DeliverDue(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+
+			// Then: The delivery is pending and backs off
+			deliveries, _ := webhookRepo.FindDeliveries(webhook.ID, 10)
+			Expect(deliveries[0].Status).To(Equal(domain.WebhookDeliveryPending))
+			Expect(deliveries[0].Attempts).To(Equal(1))
+			Expect(*deliveries[0].ResponseStatus).To(Equal(http.StatusInternalServerError))
+			Expect(*deliveries[0].LastError).To(ContainSubstring("500"))
+
+			delivered, err := webhookWorker.DeliverDue(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(delivered).To(BeZero())
+
+			// When: The retry is due and fails as well
+			_, err = sharedContainers.DB.Exec(`UPDATE webhook_deliveries SET next_attempt_at = NOW()`)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = webhookWorker.DeliverDue(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+
+			// Then: The worker gives up after its two attempts
+			deliveries, _ = webhookRepo.FindDeliveries(webhook.ID, 10)
+			Expect(deliveries[0].Status).To(Equal(domain.WebhookDeliveryFailed))
+			Expect(deliveries[0].Attempts).To(Equal(2))
+		})
+	})
+
+	Describe("Webhook API", func() {
+		It("should deliver a like on the owner's post", func() {
+			// Given: Test app setup
+			app, _, cleanup := setupTestApp()
+			defer cleanup()
+			server, received := webhookReceiver(http.StatusOK)
+			defer server.Close()
+
+			// Given: A user registers a webhook for likes
+			token := registerAndLogin(app, "hookapi", "hookapi@example.com", "pass123")
+			body := fmt.Sprintf(`{"url":%q,"event_types":["post_liked"]}`, server.URL)
+			req := httptest.NewRequest("POST", "/api/webhooks", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+			resp, err := app.Test(req, 2000)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(201))
+
+			var webhook map[string]interface{}
+			json.NewDecoder(resp.Body).Decode(&webhook)
+			Expect(webhook["secret"]).NotTo(BeEmpty())
+
+			// Given: The user has a post
+			var userID uint
+			err = sharedContainers.DB.QueryRow(`SELECT id FROM users WHERE username = 'hookapi'`).Scan(&userID)
+			Expect(err).NotTo(HaveOccurred())
+			post := createTestPost(sharedContainers.DB, userID, "Hooked", "Caption")
+
+			// When: Another user likes it
+			likerToken := registerAndLogin(app, "hookliker", "hookliker@example.com", "pass123")
+			likeReq := httptest.NewRequest("POST", fmt.Sprintf("/api/posts/%d/like", post.ID), nil)
+			likeReq.Header.Set("Authorization", "Bearer "+likerToken)
+			likeResp, err := app.Test(likeReq, 2000)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(likeResp.StatusCode).To(Equal(200))
+
+			// Then: The webhook receives the signed event
+			var delivered webhookRequest
+			Eventually(received, 5*time.Second).Should(Receive(&delivered))
+			Expect(delivered.Header.Get(service.WebhookEventHeader)).To(Equal("post_liked"))
+			expectSigned(delivered, webhook["secret"].(string))
+
+			// Then: Listing the webhooks does not reveal the secret
+			listReq := httptest.NewRequest("GET", "/api/webhooks", nil)
+			listReq.Header.Set("Authorization", "Bearer "+token)
+			listResp, err := app.Test(listReq, 2000)
+			Expect(err).NotTo(HaveOccurred())
+
+			var list map[string][]map[string]interface{}
+			json.NewDecoder(listResp.Body).Decode(&list)
+			Expect(list["webhooks"]).To(HaveLen(1))
+			Expect(list["webhooks"][0]).NotTo(HaveKey("secret"))
+		})
+	})
+})